@@ -0,0 +1,213 @@
+package bit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gioui.org/io/pointer"
+)
+
+// Binding is a key or pointer button that can trigger an action.
+// Its text form is the key name as reported by key.Event, or "mouse:" followed
+// by primary, secondary or tertiary for pointer buttons.
+type Binding struct {
+	Key    string
+	Button pointer.Buttons
+}
+
+func KeyBinding(name string) Binding          { return Binding{Key: name} }
+func ButtonBinding(b pointer.Buttons) Binding { return Binding{Button: b} }
+func (b Binding) Down(in Input) bool          { return b.test(in.Down, in.Buttons) }
+func (b Binding) Pressed(in Input) bool       { return b.test(in.Pressed, in.ButtonsPressed) }
+func (b Binding) Released(in Input) bool      { return b.test(in.Released, in.ButtonsReleased) }
+func (b Binding) String() string              { t, _ := b.MarshalText(); return string(t) }
+
+func (b Binding) test(key func(string) bool, buttons pointer.Buttons) bool {
+	if b.Button != 0 {
+		return buttons.Contain(b.Button)
+	}
+	return key(b.Key)
+}
+
+var buttonNames = []struct {
+	name   string
+	button pointer.Buttons
+}{
+	{"primary", pointer.ButtonPrimary},
+	{"secondary", pointer.ButtonSecondary},
+	{"tertiary", pointer.ButtonTertiary},
+}
+
+const buttonPrefix = "mouse:"
+
+func (b Binding) MarshalText() ([]byte, error) {
+	if b.Button == 0 {
+		return []byte(b.Key), nil
+	}
+	for _, n := range buttonNames {
+		if n.button == b.Button {
+			return []byte(buttonPrefix + n.name), nil
+		}
+	}
+	return nil, fmt.Errorf("bit: cannot bind pointer buttons %v", b.Button)
+}
+
+func (b *Binding) UnmarshalText(text []byte) error {
+	s := string(text)
+	if name, ok := strings.CutPrefix(s, buttonPrefix); ok {
+		for _, n := range buttonNames {
+			if n.name == name {
+				*b = Binding{Button: n.button}
+				return nil
+			}
+		}
+		return fmt.Errorf("bit: unknown pointer button %q", name)
+	}
+	if s == "" {
+		return fmt.Errorf("bit: empty binding")
+	}
+	*b = Binding{Key: s}
+	return nil
+}
+
+// AxisBinding maps bindings to an analog value in [-1, 1].
+type AxisBinding struct {
+	Negative []Binding `json:"negative"`
+	Positive []Binding `json:"positive"`
+}
+
+// ActionMap maps named actions and axes to bindings, so that Update can
+// query "jump" rather than a particular key.
+type ActionMap struct {
+	Actions map[string][]Binding   `json:"actions"`
+	Axes    map[string]AxisBinding `json:"axes"`
+}
+
+func NewActionMap() *ActionMap {
+	return &ActionMap{
+		Actions: make(map[string][]Binding),
+		Axes:    make(map[string]AxisBinding),
+	}
+}
+
+func LoadActionMap(path string) (*ActionMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := NewActionMap()
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("bit: action map %s: %w", path, err)
+	}
+	return m, nil
+}
+
+func (m *ActionMap) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Bind adds bindings to an action, ignoring any it already has.
+func (m *ActionMap) Bind(action string, bindings ...Binding) {
+	if m.Actions == nil {
+		m.Actions = make(map[string][]Binding)
+	}
+	for _, b := range bindings {
+		if !containsBinding(m.Actions[action], b) {
+			m.Actions[action] = append(m.Actions[action], b)
+		}
+	}
+}
+
+// Rebind replaces all bindings of an action.
+func (m *ActionMap) Rebind(action string, bindings ...Binding) {
+	delete(m.Actions, action)
+	m.Bind(action, bindings...)
+}
+
+// Unbind removes a binding from an action.
+func (m *ActionMap) Unbind(action string, b Binding) {
+	bs := m.Actions[action]
+	for i, x := range bs {
+		if x == b {
+			m.Actions[action] = append(bs[:i:i], bs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (m *ActionMap) BindAxis(axis string, negative, positive []Binding) {
+	if m.Axes == nil {
+		m.Axes = make(map[string]AxisBinding)
+	}
+	m.Axes[axis] = AxisBinding{Negative: negative, Positive: positive}
+}
+
+// Down reports whether any binding of the action is held down.
+func (m *ActionMap) Down(in Input, action string) bool {
+	return anyBinding(m.Actions[action], in, Binding.Down)
+}
+
+// Pressed reports whether any binding of the action went down since the
+// previous Update.
+func (m *ActionMap) Pressed(in Input, action string) bool {
+	return anyBinding(m.Actions[action], in, Binding.Pressed)
+}
+
+// Released reports whether the action stopped being held down since the
+// previous Update.
+func (m *ActionMap) Released(in Input, action string) bool {
+	bs := m.Actions[action]
+	return anyBinding(bs, in, Binding.Released) && !anyBinding(bs, in, Binding.Down)
+}
+
+// Axis returns -1, 0 or 1 depending on which side of the axis is held down.
+// Holding both sides cancels out.
+func (m *ActionMap) Axis(in Input, axis string) float64 {
+	a := m.Axes[axis]
+	var v float64
+	if anyBinding(a.Negative, in, Binding.Down) {
+		v--
+	}
+	if anyBinding(a.Positive, in, Binding.Down) {
+		v++
+	}
+	return v
+}
+
+// PressedBinding returns a binding that went down since the previous Update,
+// for capturing a new binding at runtime. Keys take precedence over buttons.
+func PressedBinding(in Input) (Binding, bool) {
+	if keys := in.PressedKeys(); len(keys) > 0 {
+		return KeyBinding(keys[0]), true
+	}
+	for _, n := range buttonNames {
+		if in.ButtonsPressed.Contain(n.button) {
+			return ButtonBinding(n.button), true
+		}
+	}
+	return Binding{}, false
+}
+
+func anyBinding(bs []Binding, in Input, test func(Binding, Input) bool) bool {
+	for _, b := range bs {
+		if test(b, in) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsBinding(bs []Binding, b Binding) bool {
+	for _, x := range bs {
+		if x == b {
+			return true
+		}
+	}
+	return false
+}
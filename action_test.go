@@ -0,0 +1,180 @@
+package bit
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"github.com/jncornett/bit/gfx"
+)
+
+func TestBindingText(t *testing.T) {
+	for _, tt := range []struct {
+		b    Binding
+		text string
+	}{
+		{KeyBinding("Space"), "Space"},
+		{KeyBinding("A"), "A"},
+		{ButtonBinding(pointer.ButtonPrimary), "mouse:primary"},
+		{ButtonBinding(pointer.ButtonSecondary), "mouse:secondary"},
+		{ButtonBinding(pointer.ButtonTertiary), "mouse:tertiary"},
+	} {
+		text, err := tt.b.MarshalText()
+		if err != nil || string(text) != tt.text {
+			t.Errorf("%+v.MarshalText() = %q, %v; want %q", tt.b, text, err, tt.text)
+		}
+		var b Binding
+		if err := b.UnmarshalText([]byte(tt.text)); err != nil || b != tt.b {
+			t.Errorf("UnmarshalText(%q) = %+v, %v; want %+v", tt.text, b, err, tt.b)
+		}
+		if s := tt.b.String(); s != tt.text {
+			t.Errorf("String() = %q; want %q", s, tt.text)
+		}
+	}
+
+	if _, err := ButtonBinding(pointer.ButtonPrimary | pointer.ButtonSecondary).MarshalText(); err == nil {
+		t.Errorf("MarshalText() of two buttons succeeded")
+	}
+	for _, text := range []string{"", "mouse:", "mouse:left", "mouse:Primary"} {
+		b := KeyBinding("keep")
+		if err := b.UnmarshalText([]byte(text)); err == nil {
+			t.Errorf("UnmarshalText(%q) = %+v; want an error", text, b)
+		} else if b != KeyBinding("keep") {
+			t.Errorf("UnmarshalText(%q) changed the binding to %+v", text, b)
+		}
+	}
+}
+
+func TestActionMapSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "actions.json")
+	m := NewActionMap()
+	m.Bind("jump", KeyBinding("Space"), ButtonBinding(pointer.ButtonPrimary))
+	m.Bind("fire", KeyBinding("F"))
+	m.BindAxis("x", []Binding{KeyBinding("A"), KeyBinding(key.NameLeftArrow)}, []Binding{KeyBinding("D")})
+	if err := m.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"mouse:primary"`) {
+		t.Errorf("saved map does not use the text form of bindings:\n%s", data)
+	}
+	got, err := LoadActionMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("LoadActionMap() = %+v; want %+v", got, m)
+	}
+
+	for name, text := range map[string]string{
+		"not json":    "{",
+		"bad binding": `{"actions": {"jump": ["mouse:left"]}}`,
+		"empty key":   `{"actions": {"jump": [""]}}`,
+	} {
+		os.WriteFile(path, []byte(text), 0o644)
+		if _, err := LoadActionMap(path); err == nil {
+			t.Errorf("%s: LoadActionMap() succeeded", name)
+		}
+	}
+	if _, err := LoadActionMap(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("LoadActionMap() of a missing file = %v; want not exist", err)
+	}
+}
+
+func TestActionMapRebind(t *testing.T) {
+	var m ActionMap
+	m.Bind("jump", KeyBinding("Space"), KeyBinding("W"), KeyBinding("Space"))
+	if want := []Binding{KeyBinding("Space"), KeyBinding("W")}; !reflect.DeepEqual(m.Actions["jump"], want) {
+		t.Errorf("Bind() = %v; want %v", m.Actions["jump"], want)
+	}
+	m.Unbind("jump", KeyBinding("Space"))
+	m.Unbind("jump", KeyBinding("X"))
+	m.Unbind("missing", KeyBinding("X"))
+	if want := []Binding{KeyBinding("W")}; !reflect.DeepEqual(m.Actions["jump"], want) {
+		t.Errorf("after Unbind: %v; want %v", m.Actions["jump"], want)
+	}
+	m.Rebind("jump", KeyBinding("Up"))
+	if want := []Binding{KeyBinding("Up")}; !reflect.DeepEqual(m.Actions["jump"], want) {
+		t.Errorf("after Rebind: %v; want %v", m.Actions["jump"], want)
+	}
+	m.Rebind("jump")
+	if len(m.Actions["jump"]) != 0 {
+		t.Errorf("Rebind() with no bindings left %v", m.Actions["jump"])
+	}
+
+	// Unbinding must not change a slice shared with the caller.
+	shared := []Binding{KeyBinding("A"), KeyBinding("B"), KeyBinding("C")}
+	m.Actions["shared"] = shared
+	m.Unbind("shared", KeyBinding("A"))
+	if shared[0] != KeyBinding("A") {
+		t.Errorf("Unbind changed the caller's slice to %v", shared)
+	}
+}
+
+func TestActionMapInput(t *testing.T) {
+	m := NewActionMap()
+	m.Bind("jump", KeyBinding("Space"), KeyBinding("W"))
+	m.Bind("fire", ButtonBinding(pointer.ButtonPrimary))
+	m.BindAxis("x", []Binding{KeyBinding("A")}, []Binding{KeyBinding("D")})
+	button := func(typ pointer.Type, b pointer.Buttons) Event {
+		return Event{Kind: EventPointer, Pointer: PointerEvent{Type: typ, Buttons: b, Position: gfx.V(1, 1)}}
+	}
+
+	var s inputState
+	for i, frame := range []struct {
+		events                  []Event
+		down, pressed, released bool
+		fire                    bool
+		x                       float64
+	}{
+		{events: keys("Space", key.Press), down: true, pressed: true},
+		{down: true},
+		// A second binding going down while the first is held.
+		{events: keys("W", key.Press), down: true, pressed: true},
+		// Still held with W, so not released.
+		{events: keys("Space", key.Release), down: true},
+		{events: keys("W", key.Release), released: true},
+		{},
+		// A tap within one frame is both pressed and released.
+		{events: keys("Space", key.Press, "Space", key.Release), pressed: true, released: true},
+		{events: []Event{button(pointer.Press, pointer.ButtonPrimary)}, fire: true},
+		{events: append(keys("A", key.Press), button(pointer.Release, 0)), x: -1},
+		{events: keys("D", key.Press), x: 0},
+		{events: keys("A", key.Release), x: 1},
+	} {
+		s.Apply(frame.events)
+		in := s.Take()
+		if m.Down(in, "jump") != frame.down || m.Pressed(in, "jump") != frame.pressed || m.Released(in, "jump") != frame.released {
+			t.Errorf("frame %d: jump down, pressed, released = %v, %v, %v; want %v, %v, %v", i,
+				m.Down(in, "jump"), m.Pressed(in, "jump"), m.Released(in, "jump"), frame.down, frame.pressed, frame.released)
+		}
+		if m.Pressed(in, "fire") != frame.fire {
+			t.Errorf("frame %d: fire pressed = %v; want %v", i, m.Pressed(in, "fire"), frame.fire)
+		}
+		if x := m.Axis(in, "x"); x != frame.x {
+			t.Errorf("frame %d: Axis = %v; want %v", i, x, frame.x)
+		}
+	}
+	if m.Down(Input{}, "missing") || m.Axis(Input{}, "missing") != 0 {
+		t.Errorf("unbound action or axis is active")
+	}
+}
+
+func TestPressedBinding(t *testing.T) {
+	var s inputState
+	s.Apply([]Event{{Kind: EventPointer, Pointer: PointerEvent{Type: pointer.Press, Buttons: pointer.ButtonSecondary}}})
+	if b, ok := PressedBinding(s.Take()); !ok || b != ButtonBinding(pointer.ButtonSecondary) {
+		t.Errorf("PressedBinding() = %+v, %v; want the secondary button", b, ok)
+	}
+	s.Apply(append(keys("Z", key.Press, "B", key.Press), Event{Kind: EventPointer, Pointer: PointerEvent{Type: pointer.Press, Buttons: pointer.ButtonSecondary | pointer.ButtonPrimary}}))
+	if b, ok := PressedBinding(s.Take()); !ok || b != KeyBinding("B") {
+		t.Errorf("PressedBinding() = %+v, %v; want key B", b, ok)
+	}
+	if b, ok := PressedBinding(s.Take()); ok {
+		t.Errorf("PressedBinding() with nothing pressed = %+v", b)
+	}
+}
@@ -0,0 +1,170 @@
+package bit
+
+import "time"
+
+type AnimMode uint8
+
+const (
+	// AnimLoop plays the frames in order and starts over after the last.
+	AnimLoop AnimMode = iota
+	// AnimPingPong plays the frames forwards, then backwards, and so on.
+	AnimPingPong
+	// AnimOnce plays the frames in order and then stays on the last one.
+	AnimOnce
+)
+
+type AnimFrame struct {
+	AtlasFrame
+	// Duration is how long the frame is shown. Non-positive durations show
+	// the frame forever.
+	Duration time.Duration
+	// Event, if set, is reported by Animator.Advance when the frame is entered.
+	Event string
+}
+
+// Clip is a named sequence of frames.
+type Clip struct {
+	Name   string
+	Frames []AnimFrame
+	Mode   AnimMode
+}
+
+// NewClip makes a clip of sprites shown for d each.
+func NewClip(name string, mode AnimMode, d time.Duration, sprites ...Sprite) *Clip {
+	c := &Clip{Name: name, Mode: mode}
+	for _, s := range sprites {
+		c.Frames = append(c.Frames, AnimFrame{
+			AtlasFrame: AtlasFrame{Sprite: s, Size: s.Bounds().Size()},
+			Duration:   d,
+		})
+	}
+	return c
+}
+
+// NewAtlasClip makes a clip of the named atlas frames shown for d each.
+// It reports false if any of the names is missing.
+func NewAtlasClip(name string, mode AnimMode, d time.Duration, atlas *Atlas, frames ...string) (*Clip, bool) {
+	c := &Clip{Name: name, Mode: mode}
+	for _, n := range frames {
+		f, ok := atlas.Frame(n)
+		if !ok {
+			return nil, false
+		}
+		c.Frames = append(c.Frames, AnimFrame{AtlasFrame: f, Duration: d})
+	}
+	return c, true
+}
+
+// Animator plays one clip at a time. It is a value type so that it can be
+// part of a game state; copies share their clips, which must not be modified.
+type Animator struct {
+	clips map[string]*Clip
+
+	Clip     string
+	Frame    int
+	Elapsed  time.Duration
+	Reverse  bool // playing backwards in AnimPingPong
+	Finished bool // reached the end in AnimOnce
+	entered  bool // the current frame's event has been reported
+}
+
+func NewAnimator(clips ...*Clip) Animator {
+	a := Animator{clips: make(map[string]*Clip, len(clips))}
+	for _, c := range clips {
+		a.clips[c.Name] = c
+	}
+	return a
+}
+
+// Play switches to the named clip from its first frame, unless it is already
+// playing. It reports false if there is no such clip.
+func (a *Animator) Play(name string) bool {
+	if _, ok := a.clips[name]; !ok {
+		return false
+	}
+	if a.Clip != name {
+		a.Restart(name)
+	}
+	return true
+}
+
+// Restart plays the named clip from its first frame.
+func (a *Animator) Restart(name string) {
+	*a = Animator{clips: a.clips, Clip: name}
+}
+
+// Current returns the frame to draw, or false if nothing is playing.
+func (a *Animator) Current() (AnimFrame, bool) {
+	c := a.clips[a.Clip]
+	if c == nil || a.Frame >= len(c.Frames) {
+		return AnimFrame{}, false
+	}
+	return c.Frames[a.Frame], true
+}
+
+// Update advances the animation by the tick's delta.
+func (a *Animator) Update(t Tick) []string { return a.Advance(t.Delta()) }
+
+// Advance advances the animation by d and returns the events of the frames
+// entered along the way, in order.
+func (a *Animator) Advance(d time.Duration) (events []string) {
+	c := a.clips[a.Clip]
+	if c == nil || len(c.Frames) == 0 {
+		return nil
+	}
+	if !a.entered {
+		a.entered = true
+		events = appendEvent(events, c.Frames[a.Frame])
+	}
+	if a.Finished {
+		return events
+	}
+	a.Elapsed += d
+	for {
+		fd := c.Frames[a.Frame].Duration
+		if fd <= 0 || a.Elapsed < fd {
+			return events
+		}
+		a.Elapsed -= fd
+		if !a.next(c) {
+			a.Elapsed = 0
+			return events
+		}
+		events = appendEvent(events, c.Frames[a.Frame])
+	}
+}
+
+// next moves to the following frame, reporting false if there is none.
+func (a *Animator) next(c *Clip) bool {
+	n := len(c.Frames)
+	switch c.Mode {
+	case AnimLoop:
+		a.Frame = (a.Frame + 1) % n
+	case AnimPingPong:
+		if n == 1 {
+			return true
+		}
+		if a.Reverse && a.Frame == 0 || !a.Reverse && a.Frame == n-1 {
+			a.Reverse = !a.Reverse
+		}
+		if a.Reverse {
+			a.Frame--
+		} else {
+			a.Frame++
+		}
+	case AnimOnce:
+		if a.Frame == n-1 {
+			a.Finished = true
+			return false
+		}
+		a.Frame++
+	}
+	return true
+}
+
+func appendEvent(events []string, f AnimFrame) []string {
+	if f.Event == "" {
+		return events
+	}
+	return append(events, f.Event)
+}
@@ -0,0 +1,128 @@
+package bit
+
+import (
+	"image"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// eventClip returns a clip of frames 10ms long, with one frame per event.
+func eventClip(name string, mode AnimMode, events ...string) *Clip {
+	sheet := NewSprite(image.NewNRGBA(image.Rect(0, 0, len(events), 1)))
+	c := NewClip(name, mode, 10*time.Millisecond, sheet.Grid(1, 1)...)
+	for i, e := range events {
+		c.Frames[i].Event = e
+	}
+	return c
+}
+
+func TestAnimatorModes(t *testing.T) {
+	type step struct {
+		d      time.Duration
+		events []string
+		frame  int
+	}
+	ms := time.Millisecond
+	for _, tt := range []struct {
+		name  string
+		mode  AnimMode
+		steps []step
+	}{
+		{"loop", AnimLoop, []step{
+			{0, []string{"a"}, 0},
+			{9 * ms, nil, 0},
+			{16 * ms, []string{"b", "c"}, 2},
+			{10 * ms, []string{"a"}, 0},
+			// Skipping several frames reports each of them, in order.
+			{65 * ms, []string{"b", "c", "a", "b", "c", "a", "b"}, 1},
+		}},
+		{"ping-pong", AnimPingPong, []step{
+			{0, []string{"a"}, 0},
+			{40 * ms, []string{"b", "c", "b", "a"}, 0},
+			{10 * ms, []string{"b"}, 1},
+			{25 * ms, []string{"c", "b"}, 1},
+		}},
+		{"once", AnimOnce, []step{
+			{0, []string{"a"}, 0},
+			{15 * ms, []string{"b"}, 1},
+			{time.Second, []string{"c"}, 2},
+			{time.Second, nil, 2},
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAnimator(eventClip("walk", tt.mode, "a", "b", "c"))
+			if !a.Play("walk") {
+				t.Fatal("Play() = false")
+			}
+			for i, s := range tt.steps {
+				events := a.Advance(s.d)
+				if !reflect.DeepEqual(events, s.events) || a.Frame != s.frame {
+					t.Errorf("step %d: Advance(%v) = %v at frame %d; want %v at frame %d", i, s.d, events, a.Frame, s.events, s.frame)
+				}
+			}
+		})
+	}
+}
+
+func TestAnimatorFinished(t *testing.T) {
+	a := NewAnimator(eventClip("die", AnimOnce, "", "thud"))
+	a.Play("die")
+	if events := a.Advance(25 * time.Millisecond); !reflect.DeepEqual(events, []string{"thud"}) {
+		t.Errorf("Advance() = %v; want [thud]", events)
+	}
+	if !a.Finished || a.Frame != 1 || a.Elapsed != 0 {
+		t.Errorf("at the end: finished %v, frame %d, elapsed %v; want true, 1, 0", a.Finished, a.Frame, a.Elapsed)
+	}
+	if f, ok := a.Current(); !ok || f.Event != "thud" {
+		t.Errorf("Current() = %+v, %v; want the last frame", f, ok)
+	}
+	// Play does not restart the clip playing, but Restart does.
+	a.Play("die")
+	if !a.Finished {
+		t.Errorf("Play() of the same clip restarted it")
+	}
+	a.Restart("die")
+	if a.Finished || a.Frame != 0 {
+		t.Errorf("Restart() left finished %v, frame %d", a.Finished, a.Frame)
+	}
+}
+
+func TestAnimatorPlay(t *testing.T) {
+	a := NewAnimator(eventClip("idle", AnimLoop, "i"), eventClip("run", AnimLoop, "r1", "r2"))
+	if _, ok := a.Current(); ok {
+		t.Errorf("Current() before Play succeeded")
+	}
+	if a.Advance(time.Second) != nil {
+		t.Errorf("Advance() before Play reported events")
+	}
+	if a.Play("fly") {
+		t.Errorf("Play() of a missing clip = true")
+	}
+	a.Play("run")
+	a.Advance(15 * time.Millisecond)
+	// Copies of an animator play on their own.
+	b := a
+	b.Advance(10 * time.Millisecond)
+	if a.Frame != 1 || b.Frame != 0 {
+		t.Errorf("frames = %d, %d; want 1, 0", a.Frame, b.Frame)
+	}
+	a.Play("idle")
+	tick := NewTick(epoch).Step(epoch.Add(time.Millisecond))
+	if events := a.Update(tick); !reflect.DeepEqual(events, []string{"i"}) || a.Clip != "idle" {
+		t.Errorf("Update() after Play = %v in %q; want [i] in idle", events, a.Clip)
+	}
+}
+
+func TestAnimatorForever(t *testing.T) {
+	c := eventClip("hold", AnimLoop, "a", "b")
+	c.Frames[1].Duration = 0
+	a := NewAnimator(c)
+	a.Play("hold")
+	if events := a.Advance(time.Hour); !reflect.DeepEqual(events, []string{"a", "b"}) || a.Frame != 1 {
+		t.Errorf("Advance() = %v at frame %d; want to stop on b", events, a.Frame)
+	}
+	if events := a.Advance(time.Hour); events != nil || a.Frame != 1 {
+		t.Errorf("Advance() = %v at frame %d; want to stay on b", events, a.Frame)
+	}
+}
@@ -2,32 +2,139 @@ package bit
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
+	"io"
+	"io/fs"
+	"math"
 	"os"
 	"time"
 
 	"gioui.org/app"
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
 	"gioui.org/io/system"
 	"gioui.org/layout"
 	"gioui.org/op"
+	"gioui.org/op/clip"
 	"gioui.org/op/paint"
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/cli"
+	"github.com/jncornett/bit/gfx"
 )
 
-type App[GameState any] struct {
+// App runs a game whose Update returns a RenderState. It has the same fields
+// and options as DrawApp.
+type App[GameState any] DrawApp[GameState, RenderState]
+
+func NewApp[GameState any](size image.Point, initialGameState GameState, update func(Tick, GameState) (GameState, RenderState)) *App[GameState] {
+	return (*App[GameState])(NewDrawApp(size, initialGameState, update))
+}
+
+// NewInputApp is like NewApp, but update also receives the input state.
+func NewInputApp[GameState any](size image.Point, initialGameState GameState, update func(Tick, Input, GameState) (GameState, RenderState)) *App[GameState] {
+	return (*App[GameState])(NewInputDrawApp(size, initialGameState, update))
+}
+
+func (a *App[GameState]) draw() *DrawApp[GameState, RenderState] {
+	return (*DrawApp[GameState, RenderState])(a)
+}
+
+func (a *App[GameState]) Debug() *App[GameState] {
+	a.draw().Debug()
+	return a
+}
+
+// Control is DrawApp.Control.
+func (a *App[GameState]) Control(c *Control) *App[GameState] {
+	a.draw().Control(c)
+	return a
+}
+
+// Rewind is DrawApp.Rewind.
+func (a *App[GameState]) Rewind(frames int, clone func(GameState) GameState) *App[GameState] {
+	a.draw().Rewind(frames, clone)
+	return a
+}
+
+// Fixed is DrawApp.Fixed. The rectangles of the last two steps are
+// interpolated unless Interpolate is set.
+func (a *App[GameState]) Fixed(step time.Duration) *App[GameState] {
+	a.draw().Fixed(step)
+	return a
+}
+
+// Headless is DrawApp.Headless.
+func (a *App[GameState]) Headless(h Headless) *App[GameState] {
+	a.draw().Headless(h)
+	return a
+}
+
+// Clock is DrawApp.Clock.
+func (a *App[GameState]) Clock(start func() (ticks <-chan Tick, stop func())) *App[GameState] {
+	a.draw().Clock(start)
+	return a
+}
+
+// HashState is DrawApp.HashState.
+func (a *App[GameState]) HashState(hash func(GameState) (uint64, error)) *App[GameState] {
+	a.draw().HashState(hash)
+	return a
+}
+
+// Record is DrawApp.Record.
+func (a *App[GameState]) Record(w io.Writer) *App[GameState] {
+	a.draw().Record(w)
+	return a
+}
+
+// Replay is DrawApp.Replay.
+func (a *App[GameState]) Replay(r *Replay) *App[GameState] {
+	a.draw().Replay(r)
+	return a
+}
+
+// Saves is DrawApp.Saves.
+func (a *App[GameState]) Saves(s *Saves[GameState], slot string) *App[GameState] {
+	a.draw().Saves(s, slot)
+	return a
+}
+
+// Run runs the app until it is stopped or ctx is cancelled.
+// Unless the app is headless, Main must also be running.
+func (a *App[GameState]) Run(ctx context.Context) error { return a.draw().Run(ctx) }
+
+func (a *App[GameState]) Main() { a.draw().Main() }
+
+// DrawApp is an App whose Update returns any Drawer, such as a DrawList,
+// instead of a RenderState.
+type DrawApp[GameState any, R Drawer] struct {
 	FPS              FPS
 	Size             image.Point
 	InitialGameState GameState
-	Update           func(Tick, GameState) (GameState, RenderState)
+	Update           func(Tick, GameState) (GameState, R)
 	DebugEnabled     bool
+	FixedStep        time.Duration
+	HeadlessOptions  *Headless
+	StartClock       func() (ticks <-chan Tick, stop func())
+	Hash             func(GameState) (uint64, error)
+	RecordTo         io.Writer
+	ReplayFrom       *Replay
+	UpdateInput      func(Tick, Input, GameState) (GameState, R)
+	Interpolate      func(prev, curr R, alpha float64) R
+	SaveTo           *Saves[GameState]
+	SaveSlot         string
+	RewindFrames     int
+	Clone            func(GameState) GameState
+	Controller       *Control
 }
 
-func NewApp[GameState any](size image.Point, initialGameState GameState, update func(Tick, GameState) (GameState, RenderState)) *App[GameState] {
-	return &App[GameState]{
+func NewDrawApp[GameState any, R Drawer](size image.Point, initialGameState GameState, update func(Tick, GameState) (GameState, R)) *DrawApp[GameState, R] {
+	return &DrawApp[GameState, R]{
 		FPS:              60,
 		Size:             size,
 		InitialGameState: initialGameState,
@@ -35,63 +142,240 @@ func NewApp[GameState any](size image.Point, initialGameState GameState, update
 	}
 }
 
-func (a *App[GameState]) Debug() *App[GameState] {
+// NewInputDrawApp is like NewDrawApp, but update also receives the input
+// state.
+func NewInputDrawApp[GameState any, R Drawer](size image.Point, initialGameState GameState, update func(Tick, Input, GameState) (GameState, R)) *DrawApp[GameState, R] {
+	return &DrawApp[GameState, R]{
+		FPS:              60,
+		Size:             size,
+		InitialGameState: initialGameState,
+		UpdateInput:      update,
+	}
+}
+
+func (a *DrawApp[GameState, R]) Debug() *DrawApp[GameState, R] {
 	a.DebugEnabled = true
 	return a
 }
 
-func (a *App[GameState]) Main() {
-	e := Engine[GameState, RenderState]{
-		StartClock: MakeClock(a.FPS),
-		Update:     a.Update,
-		Render:     defaultRender,
-		StartDraw:  mainLoop(a.Size),
-		Size:       a.Size,
-		Metrics:    MakeEngineMetrics(time.Now()),
+// Control lets c pause, step and scale the time of the app while it runs.
+func (a *DrawApp[GameState, R]) Control(c *Control) *DrawApp[GameState, R] {
+	a.Controller = c
+	return a
+}
+
+// Rewind enables debugging and keeps the last frames game states, copied
+// with clone, so the game can be paused, stepped and rewound with
+// DefaultDebugKeys. See Debugger.
+func (a *DrawApp[GameState, R]) Rewind(frames int, clone func(GameState) GameState) *DrawApp[GameState, R] {
+	a.DebugEnabled = true
+	a.RewindFrames, a.Clone = frames, clone
+	return a
+}
+
+// Fixed runs Update at a constant step instead of once per frame. If
+// Interpolate is set, or R is RenderState, the last two steps are interpolated
+// when rendering.
+func (a *DrawApp[GameState, R]) Fixed(step time.Duration) *DrawApp[GameState, R] {
+	a.FixedStep = step
+	return a
+}
+
+// Headless runs the app without a window, drawing frames with h instead.
+func (a *DrawApp[GameState, R]) Headless(h Headless) *DrawApp[GameState, R] {
+	a.HeadlessOptions = &h
+	return a
+}
+
+// Clock replaces the wall clock derived from FPS, e.g. with MakeVirtualClock.
+func (a *DrawApp[GameState, R]) Clock(start func() (ticks <-chan Tick, stop func())) *DrawApp[GameState, R] {
+	a.StartClock = start
+	return a
+}
+
+// HashState sets the hash recorded with each frame, e.g. HashJSON.
+func (a *DrawApp[GameState, R]) HashState(hash func(GameState) (uint64, error)) *DrawApp[GameState, R] {
+	a.Hash = hash
+	return a
+}
+
+// Record records every frame to w. It cannot be used with Control or
+// Rewind.
+func (a *DrawApp[GameState, R]) Record(w io.Writer) *DrawApp[GameState, R] {
+	a.RecordTo = w
+	return a
+}
+
+// Replay plays back r instead of the live clock and window input. It cannot
+// be used with Control or Rewind.
+func (a *DrawApp[GameState, R]) Replay(r *Replay) *DrawApp[GameState, R] {
+	a.ReplayFrom = r
+	return a
+}
+
+// Saves loads the game state from slot at startup, instead of using
+// InitialGameState, if the slot exists. The last game state is saved to slot
+// when the app stops.
+func (a *DrawApp[GameState, R]) Saves(s *Saves[GameState], slot string) *DrawApp[GameState, R] {
+	a.SaveTo, a.SaveSlot = s, slot
+	return a
+}
+
+func (a *DrawApp[GameState, R]) engine() (*Engine[GameState, R], error) {
+	// A replay brings its own events, so window input is not forwarded, and
+	// cannot fill a channel nobody reads.
+	var events chan Event
+	if a.ReplayFrom == nil {
+		events = make(chan Event, 128)
+	}
+	e := &Engine[GameState, R]{
+		StartClock:  MakeClock(a.FPS),
+		Update:      a.Update,
+		UpdateInput: a.UpdateInput,
+		Render:      R.Draw,
+		StartDraw:   mainLoop(a.Size, events),
+		Size:        a.Size,
+		Metrics:     MakeEngineMetrics(time.Now()),
+		Events:      events,
+		Replay:      a.ReplayFrom,
+		Hash:        a.Hash,
+		Control:     a.Controller,
+	}
+	if a.FPS > 0 {
+		e.Metrics.SetBudget(a.FPS.Duration())
+	}
+	if a.RecordTo != nil {
+		r, err := NewRecorder(a.RecordTo)
+		if err != nil {
+			return nil, err
+		}
+		e.Recorder = r
+	}
+	if a.StartClock != nil {
+		e.StartClock = a.StartClock
+	}
+	if a.HeadlessOptions != nil {
+		e.StartDraw = a.HeadlessOptions.StartDraw
+	}
+	if a.DebugEnabled && a.Clone != nil {
+		e.Debugger = NewDebugger[GameState, R](a.RewindFrames, a.Clone)
+	}
+	if a.SaveTo != nil {
+		e.Stopped = func(state GameState) error { return a.SaveTo.Save(a.SaveSlot, state) }
+	}
+	if a.FixedStep > 0 {
+		e.FixedStep = a.FixedStep
+		e.Interpolate = a.Interpolate
+		if lerp, ok := any(LerpRenderState).(func(prev, curr R, alpha float64) R); ok && e.Interpolate == nil {
+			e.Interpolate = lerp
+		}
+	}
+	return e, nil
+}
+
+// Run runs the app until it is stopped or ctx is cancelled.
+// Unless the app is headless, Main must also be running.
+func (a *DrawApp[GameState, R]) Run(ctx context.Context) error {
+	e, err := a.engine()
+	if err != nil {
+		return err
 	}
 	if a.DebugEnabled {
-		log.SetHandler(cli.Default)
-		log.SetLevel(log.DebugLevel)
-		go func() {
-			for range time.Tick(time.Second) {
-				m := e.Metrics.Load()
-				log.
-					WithField("loop", m.Loop().String()).
-					WithField("update", m.Update.String()).
-					WithField("render", m.Render.String()).
-					Info("metric")
-			}
-		}()
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go logMetrics(ctx, &e.Metrics)
+	}
+	state := a.InitialGameState
+	if a.SaveTo != nil {
+		saved, err := a.SaveTo.Load(a.SaveSlot)
+		switch {
+		case err == nil:
+			state = saved
+		case !errors.Is(err, fs.ErrNotExist):
+			return err
+		}
 	}
-	go func() {
-		if err := e.Run(context.Background(), a.InitialGameState); err != nil {
+	return e.Run(ctx, state)
+}
+
+func (a *DrawApp[GameState, R]) Main() {
+	run := func() {
+		if err := a.Run(context.Background()); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		os.Exit(0)
-	}()
+	}
+	if a.HeadlessOptions != nil {
+		run()
+	}
+	go run()
 	app.Main()
 }
 
-func defaultRender(state RenderState, img *image.NRGBA) {
-	draw.Draw(img, img.Bounds(), image.Black, image.Point{}, draw.Src)
-	for _, rect := range state {
-		draw.Draw(img, rect, image.NewUniform(color.NRGBA{R: 0xff, A: 0xff}), image.Point{}, draw.Src)
+func logMetrics(ctx context.Context, metrics *EngineMetrics) {
+	log.SetHandler(cli.Default)
+	log.SetLevel(log.DebugLevel)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m := metrics.Load()
+			log.
+				WithField("loop", m.Loop().String()).
+				WithField("update", m.Update.String()).
+				WithField("render", m.Render.String()).
+				WithField("recent_loop", m.LoopWindow.Stats().String()).
+				WithField("recent_update", m.UpdateWindow.Stats().String()).
+				WithField("recent_render", m.RenderWindow.Stats().String()).
+				Info("metric")
+		}
 	}
 }
 
-func mainLoop(size image.Point) func(buf ReadBuffer) error {
+func mainLoop(size image.Point, events chan<- Event) func(buf ReadBuffer) error {
 	return func(buf ReadBuffer) error {
 		w := app.NewWindow()
 		var ops op.Ops
 		var resized bool
+		tag := new(int)
+		send := eventSender(events, buf.Done())
 		for {
-			e := <-w.Events()
+			var e event.Event
+			select {
+			case e = <-w.Events():
+			case <-buf.Done():
+				return nil
+			}
 			switch e := e.(type) {
 			case system.DestroyEvent:
 				return e.Err
 			case system.FrameEvent:
+				for _, ev := range e.Queue.Events(tag) {
+					switch ev := ev.(type) {
+					case key.Event:
+						send(Event{Kind: EventKey, Key: KeyEvent{Name: ev.Name, Modifiers: ev.Modifiers, State: ev.State}})
+					case pointer.Event:
+						send(Event{Kind: EventPointer, Pointer: PointerEvent{
+							Type:     ev.Type,
+							Position: gfx.V(float64(ev.Position.X), float64(ev.Position.Y)),
+							Buttons:  ev.Buttons,
+							Scroll:   gfx.V(float64(ev.Scroll.X), float64(ev.Scroll.Y)),
+						}})
+					}
+				}
 				gtx := layout.NewContext(&ops, e)
+				key.InputOp{Tag: tag}.Add(gtx.Ops)
+				area := clip.Rect{Max: e.Size}.Push(gtx.Ops)
+				pointer.InputOp{
+					Tag:          tag,
+					Types:        pointer.Press | pointer.Release | pointer.Move | pointer.Drag | pointer.Scroll,
+					ScrollBounds: image.Rect(-math.MaxInt32, -math.MaxInt32, math.MaxInt32, math.MaxInt32),
+				}.Add(gtx.Ops)
+				area.Pop()
 				if !resized {
 					resized = true
 					w.Option(app.Size(
@@ -110,5 +394,47 @@ func mainLoop(size image.Point) func(buf ReadBuffer) error {
 	}
 }
 
+// eventSender returns a function that sends window events to events until
+// done is closed. If events is nil, the events are dropped.
+func eventSender(events chan<- Event, done <-chan struct{}) func(Event) {
+	if events == nil {
+		return func(Event) {}
+	}
+	return func(ev Event) {
+		select {
+		case events <- ev:
+		case <-done:
+		}
+	}
+}
+
 // RenderState is whatever we send to the render function to draw on the image buffer.
 type RenderState []image.Rectangle
+
+// Draw paints every rectangle solid red on black.
+func (state RenderState) Draw(img *image.NRGBA) {
+	draw.Draw(img, img.Bounds(), image.Black, image.Point{}, draw.Src)
+	for _, rect := range state {
+		draw.Draw(img, rect, image.NewUniform(color.NRGBA{R: 0xff, A: 0xff}), image.Point{}, draw.Src)
+	}
+}
+
+// LerpRenderState linearly interpolates between the rectangles of two render
+// states. If they differ in length, curr is returned unchanged.
+func LerpRenderState(prev, curr RenderState, alpha float64) RenderState {
+	if len(prev) != len(curr) {
+		return curr
+	}
+	lerp := func(a, b int) int {
+		return a + int(math.Round(float64(b-a)*alpha))
+	}
+	out := make(RenderState, len(curr))
+	for i, r := range curr {
+		p := prev[i]
+		out[i] = image.Rect(
+			lerp(p.Min.X, r.Min.X), lerp(p.Min.Y, r.Min.Y),
+			lerp(p.Max.X, r.Max.X), lerp(p.Max.Y, r.Max.Y),
+		)
+	}
+	return out
+}
@@ -0,0 +1,96 @@
+package bit
+
+import (
+	"context"
+	"image"
+	"testing"
+	"time"
+)
+
+func TestAppRenderState(t *testing.T) {
+	var drawn int
+	app := &App[int]{
+		FPS:  60,
+		Size: image.Pt(4, 4),
+		Update: func(_ Tick, n int) (int, RenderState) {
+			return n + 1, RenderState{image.Rect(0, 0, 2, 2)}
+		},
+	}
+	app = app.
+		Fixed(time.Millisecond).
+		Clock(MakeVirtualClock(epoch, time.Millisecond, 0)).
+		Headless(Headless{Frames: 3, OnFrame: func(_ int, img *image.NRGBA) error {
+			drawn++
+			if img.NRGBAAt(1, 1).R != 0xff || img.NRGBAAt(3, 3).R != 0 {
+				t.Errorf("frame %d does not show the render state", drawn)
+			}
+			return nil
+		}})
+	if err := app.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if drawn != 3 {
+		t.Errorf("drew %d frames, want 3", drawn)
+	}
+}
+
+func TestNewInputApp(t *testing.T) {
+	var updates int
+	app := NewInputApp(image.Pt(4, 4), 0, func(_ Tick, _ Input, n int) (int, RenderState) {
+		updates++
+		return n, nil
+	}).
+		Clock(MakeVirtualClock(epoch, time.Millisecond, 4)).
+		Headless(Headless{})
+	if err := app.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if updates != 4 {
+		t.Errorf("updates = %d, want 4", updates)
+	}
+}
+
+func TestAppReplayEvents(t *testing.T) {
+	update := func(_ Tick, n int) (int, DrawList) { return n, nil }
+	live, err := NewDrawApp(image.Pt(1, 1), 0, update).engine()
+	if err != nil || live.Events == nil {
+		t.Fatalf("live app has no events: %v", err)
+	}
+	replay, err := NewDrawApp(image.Pt(1, 1), 0, update).Replay(&Replay{Frames: make([]ReplayFrame, 1)}).engine()
+	if err != nil || replay.Events != nil {
+		t.Fatalf("replaying app forwards window events: %v", err)
+	}
+
+	// The window keeps sending events during a replay, more than any buffer
+	// holds, without anyone reading them.
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		send := eventSender(nil, make(chan struct{}))
+		for i := 0; i < 1000; i++ {
+			send(Event{Kind: EventKey, Key: KeyEvent{Name: "A"}})
+		}
+	}()
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sending window events blocked during a replay")
+	}
+}
+
+func TestEventSender(t *testing.T) {
+	events := make(chan Event, 1)
+	done := make(chan struct{})
+	send := eventSender(events, done)
+	send(Event{Kind: EventKey})
+	if ev := <-events; ev.Kind != EventKey {
+		t.Errorf("received %+v", ev)
+	}
+	// Once the engine is done, a full channel does not block.
+	send(Event{})
+	close(done)
+	send(Event{})
+	if len(events) != 1 {
+		t.Errorf("%d events buffered; want 1", len(events))
+	}
+}
@@ -0,0 +1,140 @@
+package bit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io/fs"
+	"path"
+	"strconv"
+	"time"
+)
+
+// asepriteSheet is the JSON written by Aseprite's sprite sheet export, in
+// either its hash or array flavour.
+type asepriteSheet struct {
+	Frames json.RawMessage `json:"frames"`
+	Meta   struct {
+		Image     string `json:"image"`
+		FrameTags []struct {
+			Name      string `json:"name"`
+			From      int    `json:"from"`
+			To        int    `json:"to"`
+			Direction string `json:"direction"`
+			Repeat    string `json:"repeat"`
+		} `json:"frameTags"`
+	} `json:"meta"`
+}
+
+type asepriteFrame struct {
+	Frame            AtlasRect `json:"frame"`
+	Rotated          bool      `json:"rotated"`
+	SpriteSourceSize AtlasRect `json:"spriteSourceSize"`
+	SourceSize       AtlasSize `json:"sourceSize"`
+	Duration         int       `json:"duration"` // milliseconds
+}
+
+// LoadAseprite loads the clips of an Aseprite JSON export, along with the
+// sheet image it refers to, from a file system.
+func LoadAseprite(fsys fs.FS, name string) ([]*Clip, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	var sheet asepriteSheet
+	if err := json.Unmarshal(data, &sheet); err != nil {
+		return nil, fmt.Errorf("bit: aseprite %s: %w", name, err)
+	}
+	img, err := LoadSpriteFS(fsys, path.Join(path.Dir(name), sheet.Meta.Image))
+	if err != nil {
+		return nil, err
+	}
+	return ParseAseprite(data, img)
+}
+
+// ParseAseprite reads the clips of an Aseprite JSON export whose sheet image
+// is already loaded. Each frame tag becomes a clip; without tags there is a
+// single clip named "default" with every frame.
+func ParseAseprite(data []byte, sheet Sprite) ([]*Clip, error) {
+	var s asepriteSheet
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("bit: aseprite: %w", err)
+	}
+	raw, err := asepriteFrames(s.Frames)
+	if err != nil {
+		return nil, fmt.Errorf("bit: aseprite: %w", err)
+	}
+	frames := make([]AnimFrame, len(raw))
+	for i, f := range raw {
+		if f.Rotated {
+			return nil, fmt.Errorf("bit: aseprite: frame %d is rotated, which is not supported", i)
+		}
+		r := f.Frame.Rectangle()
+		if !r.In(sheet.Bounds()) {
+			return nil, fmt.Errorf("bit: aseprite: frame %d is outside of the sheet", i)
+		}
+		frames[i] = AnimFrame{
+			AtlasFrame: AtlasFrame{
+				Sprite: sheet.Sub(r),
+				Offset: image.Pt(f.SpriteSourceSize.X, f.SpriteSourceSize.Y),
+				Size:   image.Pt(f.SourceSize.W, f.SourceSize.H),
+			},
+			Duration: time.Duration(f.Duration) * time.Millisecond,
+		}
+	}
+	if len(s.Meta.FrameTags) == 0 {
+		return []*Clip{{Name: "default", Frames: frames}}, nil
+	}
+	var clips []*Clip
+	for _, tag := range s.Meta.FrameTags {
+		if tag.From < 0 || tag.To >= len(frames) || tag.From > tag.To {
+			return nil, fmt.Errorf("bit: aseprite: tag %q has bad frame range %d-%d", tag.Name, tag.From, tag.To)
+		}
+		c := &Clip{Name: tag.Name}
+		c.Frames = append(c.Frames, frames[tag.From:tag.To+1]...)
+		switch tag.Direction {
+		case "reverse", "pingpong_reverse":
+			for i, j := 0, len(c.Frames)-1; i < j; i, j = i+1, j-1 {
+				c.Frames[i], c.Frames[j] = c.Frames[j], c.Frames[i]
+			}
+		}
+		switch tag.Direction {
+		case "pingpong", "pingpong_reverse":
+			c.Mode = AnimPingPong
+		}
+		if n, err := strconv.Atoi(tag.Repeat); err == nil && n == 1 && c.Mode == AnimLoop {
+			c.Mode = AnimOnce
+		}
+		clips = append(clips, c)
+	}
+	return clips, nil
+}
+
+// asepriteFrames decodes frames in file order. The hash flavour is a JSON
+// object keyed by file name, so it is read token by token to keep its order.
+func asepriteFrames(raw json.RawMessage) ([]asepriteFrame, error) {
+	raw = bytes.TrimSpace(raw)
+	var frames []asepriteFrame
+	if len(raw) > 0 && raw[0] == '[' {
+		err := json.Unmarshal(raw, &frames)
+		return frames, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return nil, fmt.Errorf("frames must be an array or an object")
+	}
+	for dec.More() {
+		if _, err := dec.Token(); err != nil { // file name
+			return nil, err
+		}
+		var f asepriteFrame
+		if err := dec.Decode(&f); err != nil {
+			return nil, err
+		}
+		frames = append(frames, f)
+	}
+	return frames, nil
+}
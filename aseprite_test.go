@@ -0,0 +1,179 @@
+package bit
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// The frames of the test sheet, which is 8x2, in the order Aseprite wrote
+// them.
+const asepriteHash = `{
+	"frames": {
+		"walk 2.aseprite": {"frame": {"x": 2, "y": 0, "w": 2, "h": 2}, "rotated": false, "spriteSourceSize": {"x": 1, "y": 0, "w": 2, "h": 2}, "sourceSize": {"w": 4, "h": 2}, "duration": 100},
+		"walk 1.aseprite": {"frame": {"x": 0, "y": 0, "w": 2, "h": 2}, "rotated": false, "spriteSourceSize": {"x": 0, "y": 0, "w": 2, "h": 2}, "sourceSize": {"w": 4, "h": 2}, "duration": 50},
+		"walk 3.aseprite": {"frame": {"x": 4, "y": 0, "w": 2, "h": 2}, "rotated": false, "spriteSourceSize": {"x": 0, "y": 0, "w": 2, "h": 2}, "sourceSize": {"w": 4, "h": 2}, "duration": 75},
+		"walk 4.aseprite": {"frame": {"x": 6, "y": 0, "w": 2, "h": 2}, "rotated": false, "spriteSourceSize": {"x": 0, "y": 0, "w": 2, "h": 2}, "sourceSize": {"w": 4, "h": 2}, "duration": 25}
+	},
+	"meta": {"image": "sheet.png"%s}
+}`
+
+const asepriteArray = `{
+	"frames": [
+		{"filename": "0", "frame": {"x": 2, "y": 0, "w": 2, "h": 2}, "spriteSourceSize": {"x": 1, "y": 0, "w": 2, "h": 2}, "sourceSize": {"w": 4, "h": 2}, "duration": 100},
+		{"filename": "1", "frame": {"x": 0, "y": 0, "w": 2, "h": 2}, "spriteSourceSize": {"x": 0, "y": 0, "w": 2, "h": 2}, "sourceSize": {"w": 4, "h": 2}, "duration": 50},
+		{"filename": "2", "frame": {"x": 4, "y": 0, "w": 2, "h": 2}, "spriteSourceSize": {"x": 0, "y": 0, "w": 2, "h": 2}, "sourceSize": {"w": 4, "h": 2}, "duration": 75},
+		{"filename": "3", "frame": {"x": 6, "y": 0, "w": 2, "h": 2}, "spriteSourceSize": {"x": 0, "y": 0, "w": 2, "h": 2}, "sourceSize": {"w": 4, "h": 2}, "duration": 25}
+	],
+	"meta": {"image": "sheet.png"%s}
+}`
+
+const asepriteTags = `, "frameTags": [
+	{"name": "fwd", "from": 0, "to": 2, "direction": "forward"},
+	{"name": "rev", "from": 1, "to": 3, "direction": "reverse"},
+	{"name": "pp", "from": 0, "to": 3, "direction": "pingpong"},
+	{"name": "ppr", "from": 0, "to": 1, "direction": "pingpong_reverse"},
+	{"name": "one", "from": 2, "to": 3, "direction": "forward", "repeat": "1"},
+	{"name": "two", "from": 2, "to": 3, "direction": "forward", "repeat": "2"}
+]`
+
+func asepriteJSON(format, tags string) []byte {
+	return []byte(strings.Replace(format, "%s", tags, 1))
+}
+
+func asepriteSheetImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 2))
+	// Mark each frame's top left pixel with its position on the sheet.
+	for x := 0; x < 8; x += 2 {
+		img.Pix[img.PixOffset(x, 0)] = uint8(x)
+	}
+	return img
+}
+
+// clipSheetX returns where each frame of c is on the sheet.
+func clipSheetX(c *Clip) []int {
+	var xs []int
+	for _, f := range c.Frames {
+		xs = append(xs, f.Bounds().Min.X)
+	}
+	return xs
+}
+
+func TestParseAseprite(t *testing.T) {
+	sheet := NewSprite(asepriteSheetImage())
+	for _, format := range []string{asepriteHash, asepriteArray} {
+		clips, err := ParseAseprite(asepriteJSON(format, ""), sheet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(clips) != 1 || clips[0].Name != "default" || clips[0].Mode != AnimLoop {
+			t.Fatalf("clips = %+v; want one default clip", clips)
+		}
+		c := clips[0]
+		// Hash frames keep their order in the file, not their sorted names.
+		if xs := clipSheetX(c); !equalInts(xs, []int{2, 0, 4, 6}) {
+			t.Errorf("frames at %v; want [2 0 4 6]", xs)
+		}
+		f := c.Frames[0]
+		if f.Duration != 100*time.Millisecond || f.Offset != image.Pt(1, 0) || f.Size != image.Pt(4, 2) || f.NRGBAAt(2, 0).R != 2 {
+			t.Errorf("first frame = %v, offset %v, size %v", f.Duration, f.Offset, f.Size)
+		}
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParseAsepriteTags(t *testing.T) {
+	clips, err := ParseAseprite(asepriteJSON(asepriteArray, asepriteTags), NewSprite(asepriteSheetImage()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		name string
+		mode AnimMode
+		xs   []int
+	}{
+		{"fwd", AnimLoop, []int{2, 0, 4}},
+		{"rev", AnimLoop, []int{6, 4, 0}},
+		{"pp", AnimPingPong, []int{2, 0, 4, 6}},
+		{"ppr", AnimPingPong, []int{0, 2}},
+		{"one", AnimOnce, []int{4, 6}},
+		{"two", AnimLoop, []int{4, 6}},
+	}
+	if len(clips) != len(want) {
+		t.Fatalf("got %d clips; want %d", len(clips), len(want))
+	}
+	for i, w := range want {
+		c := clips[i]
+		if c.Name != w.name || c.Mode != w.mode || !equalInts(clipSheetX(c), w.xs) {
+			t.Errorf("clip %d = %q mode %d at %v; want %q mode %d at %v", i, c.Name, c.Mode, clipSheetX(c), w.name, w.mode, w.xs)
+		}
+	}
+	// Reversing a clip must not reorder the frames of others.
+	if xs := clipSheetX(clips[0]); !equalInts(xs, []int{2, 0, 4}) {
+		t.Errorf("fwd frames changed to %v", xs)
+	}
+}
+
+func TestParseAsepriteErrors(t *testing.T) {
+	sheet := NewSprite(asepriteSheetImage())
+	frame := func(fields string) string {
+		return `{"frames": [{"frame": {"x": 0, "y": 0, "w": 2, "h": 2}` + fields + `}], "meta": {}}`
+	}
+	for name, data := range map[string]string{
+		"not json":        `{"frames": [`,
+		"frames a number": `{"frames": 5}`,
+		"truncated hash":  `{"frames": {"a": {"frame": {}}, "b": `,
+		"bad hash frame":  `{"frames": {"a": 5}}`,
+		"rotated":         frame(`, "rotated": true`),
+		"outside sheet":   `{"frames": [{"frame": {"x": 7, "y": 0, "w": 2, "h": 2}}]}`,
+		"tag past end":    strings.Replace(frame(""), `"meta": {}`, `"meta": {"frameTags": [{"name": "x", "from": 0, "to": 1}]}`, 1),
+		"tag backwards":   strings.Replace(asepriteJSONString(), `"meta": {}`, `"meta": {"frameTags": [{"name": "x", "from": 1, "to": 0}]}`, 1),
+		"tag negative":    strings.Replace(frame(""), `"meta": {}`, `"meta": {"frameTags": [{"name": "x", "from": -1, "to": 0}]}`, 1),
+	} {
+		if clips, err := ParseAseprite([]byte(data), sheet); err == nil {
+			t.Errorf("%s: ParseAseprite() = %v; want an error", name, clips)
+		}
+	}
+}
+
+// asepriteJSONString is an array export of two frames without tags.
+func asepriteJSONString() string {
+	return `{"frames": [{"frame": {"x": 0, "y": 0, "w": 2, "h": 2}}, {"frame": {"x": 2, "y": 0, "w": 2, "h": 2}}], "meta": {}}`
+}
+
+func TestLoadAseprite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, asepriteSheetImage()); err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"anim/hero.json": {Data: asepriteJSON(asepriteHash, asepriteTags)},
+		"anim/sheet.png": {Data: buf.Bytes()},
+		"anim/bad.json":  {Data: []byte("{")},
+		"anim/lost.json": {Data: []byte(`{"frames": [], "meta": {"image": "missing.png"}}`)},
+	}
+	clips, err := LoadAseprite(fsys, "anim/hero.json")
+	if err != nil || len(clips) != 6 {
+		t.Fatalf("LoadAseprite() = %d clips, %v; want 6", len(clips), err)
+	}
+	for _, name := range []string{"anim/bad.json", "anim/lost.json", "anim/missing.json"} {
+		if _, err := LoadAseprite(fsys, name); err == nil {
+			t.Errorf("LoadAseprite(%q) succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package bit
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/jncornett/bit/gfx"
+)
+
+const AtlasVersion = 1
+
+// AtlasManifest describes the pages of a texture atlas and where each sprite
+// was packed. It is written by cmd/bitpack.
+type AtlasManifest struct {
+	Version int         `json:"version"`
+	Pages   []AtlasPage `json:"pages"`
+}
+
+type AtlasPage struct {
+	// Image is the page's file name, relative to the manifest.
+	Image   string        `json:"image"`
+	Sprites []AtlasSprite `json:"sprites"`
+}
+
+type AtlasSprite struct {
+	Name string `json:"name"`
+	// Rect is where the (possibly trimmed) sprite is on the page.
+	Rect AtlasRect `json:"rect"`
+	// Trim is where Rect goes within the original image, whose size is Size.
+	Trim AtlasRect `json:"trim"`
+	Size AtlasSize `json:"size"`
+	// Pivot is relative to the original image, in units of Size.
+	Pivot AtlasPivot `json:"pivot"`
+}
+
+type AtlasRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+func RectToAtlas(r image.Rectangle) AtlasRect {
+	return AtlasRect{r.Min.X, r.Min.Y, r.Dx(), r.Dy()}
+}
+
+func (r AtlasRect) Rectangle() image.Rectangle { return image.Rect(r.X, r.Y, r.X+r.W, r.Y+r.H) }
+
+type AtlasPivot struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type AtlasSize struct {
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// AtlasFrame is a sprite looked up in an atlas.
+type AtlasFrame struct {
+	Sprite
+	// Offset is where the trimmed sprite goes within the original image.
+	Offset image.Point
+	Size   image.Point
+	// Pivot is in pixels, relative to the original image.
+	Pivot gfx.Vec
+}
+
+type Atlas struct {
+	frames map[string]AtlasFrame
+}
+
+// LoadAtlas loads a manifest and its pages from a file system, e.g. an embed.FS.
+func LoadAtlas(fsys fs.FS, manifest string) (*Atlas, error) {
+	data, err := fs.ReadFile(fsys, manifest)
+	if err != nil {
+		return nil, err
+	}
+	var m AtlasManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("bit: atlas %s: %w", manifest, err)
+	}
+	if m.Version != AtlasVersion {
+		return nil, fmt.Errorf("bit: atlas %s: unsupported version %d", manifest, m.Version)
+	}
+	dir := path.Dir(manifest)
+	pages := make([]Sprite, len(m.Pages))
+	for i, p := range m.Pages {
+		if pages[i], err = LoadSpriteFS(fsys, path.Join(dir, p.Image)); err != nil {
+			return nil, err
+		}
+	}
+	return NewAtlas(m, pages)
+}
+
+func LoadAtlasFile(manifest string) (*Atlas, error) {
+	return LoadAtlas(os.DirFS(filepath.Dir(manifest)), filepath.Base(manifest))
+}
+
+// NewAtlas builds an atlas from a manifest and its already loaded pages.
+func NewAtlas(m AtlasManifest, pages []Sprite) (*Atlas, error) {
+	if len(pages) != len(m.Pages) {
+		return nil, fmt.Errorf("bit: atlas has %d pages, got %d images", len(m.Pages), len(pages))
+	}
+	a := &Atlas{frames: make(map[string]AtlasFrame)}
+	for i, p := range m.Pages {
+		for _, s := range p.Sprites {
+			r := s.Rect.Rectangle()
+			if !r.In(pages[i].Bounds()) {
+				return nil, fmt.Errorf("bit: atlas sprite %q is outside of page %d", s.Name, i)
+			}
+			if _, ok := a.frames[s.Name]; ok {
+				return nil, fmt.Errorf("bit: atlas sprite %q is defined twice", s.Name)
+			}
+			a.frames[s.Name] = AtlasFrame{
+				Sprite: pages[i].Sub(r),
+				Offset: image.Pt(s.Trim.X, s.Trim.Y),
+				Size:   image.Pt(s.Size.W, s.Size.H),
+				Pivot:  gfx.V(s.Pivot.X*float64(s.Size.W), s.Pivot.Y*float64(s.Size.H)),
+			}
+		}
+	}
+	return a, nil
+}
+
+func (a *Atlas) Sprite(name string) (Sprite, bool) {
+	f, ok := a.frames[name]
+	return f.Sprite, ok
+}
+
+func (a *Atlas) Frame(name string) (AtlasFrame, bool) {
+	f, ok := a.frames[name]
+	return f, ok
+}
+
+// Names returns the names of all sprites in the atlas, sorted.
+func (a *Atlas) Names() []string {
+	names := make([]string, 0, len(a.frames))
+	for name := range a.frames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,241 @@
+package bit
+
+import (
+	"image"
+	"math"
+	"time"
+
+	"github.com/jncornett/bit/gfx"
+)
+
+// Camera maps a region of the world onto a viewport on the screen. It is a
+// value type so that it can be part of a game state.
+type Camera struct {
+	// Pos is the world position shown at the center of the viewport.
+	Pos gfx.Vec
+	// Zoom scales the world. Zero is treated as 1.
+	Zoom float64
+	// Rotation rotates the camera clockwise, in radians, so the world
+	// appears rotated the other way.
+	Rotation float64
+	// Viewport is the part of the screen the camera draws to.
+	Viewport gfx.Rect
+	// Bounds, if not empty, keeps the view inside of this world rectangle.
+	Bounds gfx.Rect
+
+	// DeadZone is the half size of a box around Pos in which Follow's
+	// target can move without moving the camera.
+	DeadZone gfx.Vec
+	// Smoothing is how quickly Follow catches up with its target, per
+	// second. Zero snaps to the target immediately.
+	Smoothing float64
+
+	ShakeAmplitude float64
+	ShakeDuration  time.Duration
+	ShakeElapsed   time.Duration
+}
+
+func NewCamera(viewport gfx.Rect) Camera {
+	return Camera{Zoom: 1, Viewport: viewport, Pos: viewport.Size().Mul(0.5)}
+}
+
+func (c Camera) zoom() float64 {
+	if c.Zoom == 0 {
+		return 1
+	}
+	return c.Zoom
+}
+
+// Follow moves the camera towards target, taking the dead zone, smoothing
+// and bounds into account. Dt is the time since the previous call.
+func (c *Camera) Follow(target gfx.Vec, dt time.Duration) {
+	goal := c.Pos
+	d := target.Sub(c.Pos)
+	switch {
+	case d.X > c.DeadZone.X:
+		goal.X = target.X - c.DeadZone.X
+	case d.X < -c.DeadZone.X:
+		goal.X = target.X + c.DeadZone.X
+	}
+	switch {
+	case d.Y > c.DeadZone.Y:
+		goal.Y = target.Y - c.DeadZone.Y
+	case d.Y < -c.DeadZone.Y:
+		goal.Y = target.Y + c.DeadZone.Y
+	}
+	if c.Smoothing > 0 {
+		k := 1 - math.Exp(-c.Smoothing*dt.Seconds())
+		goal = c.Pos.Add(goal.Sub(c.Pos).Mul(k))
+	}
+	c.Pos = goal
+	c.Clamp()
+}
+
+// Clamp moves the camera so that its view stays inside Bounds. A view larger
+// than Bounds is centered on it. Rotation is ignored.
+func (c *Camera) Clamp() {
+	b := c.Bounds
+	if b.Empty() {
+		return
+	}
+	half := c.Viewport.Size().Mul(0.5 / c.zoom())
+	clamp := func(p, min, max, half float64) float64 {
+		if max-min < 2*half {
+			return (min + max) / 2
+		}
+		return math.Max(min+half, math.Min(max-half, p))
+	}
+	c.Pos = gfx.V(
+		clamp(c.Pos.X, b.Min.X, b.Max.X, half.X),
+		clamp(c.Pos.Y, b.Min.Y, b.Max.Y, half.Y),
+	)
+}
+
+// Shake starts shaking the camera by up to amplitude world units, fading out
+// over d. It replaces any shake in progress.
+func (c *Camera) Shake(amplitude float64, d time.Duration) {
+	c.ShakeAmplitude, c.ShakeDuration, c.ShakeElapsed = amplitude, d, 0
+}
+
+// Update advances the camera's shake by the tick's delta.
+func (c *Camera) Update(t Tick) {
+	if c.ShakeElapsed < c.ShakeDuration {
+		c.ShakeElapsed += t.Delta()
+	}
+}
+
+// ShakeOffset returns how far the shake currently displaces the camera. It
+// only depends on the shake's progress, so it is deterministic.
+func (c Camera) ShakeOffset() gfx.Vec {
+	if c.ShakeDuration <= 0 || c.ShakeElapsed >= c.ShakeDuration {
+		return gfx.Vec{}
+	}
+	left := 1 - float64(c.ShakeElapsed)/float64(c.ShakeDuration)
+	t := c.ShakeElapsed.Seconds()
+	amp := c.ShakeAmplitude * left * left
+	return gfx.V(
+		amp*(math.Sin(t*71)+math.Sin(t*113+1))/2,
+		amp*(math.Sin(t*89+2)+math.Sin(t*131+3))/2,
+	)
+}
+
+// Transform returns the mapping from world to screen coordinates.
+func (c Camera) Transform() gfx.Affine {
+	z := c.zoom()
+	return gfx.Translate(c.Pos.Add(c.ShakeOffset()).Mul(-1)).
+		Then(gfx.Scale(gfx.V(z, z))).
+		Then(gfx.Rotate(-c.Rotation)).
+		Then(gfx.Translate(c.Viewport.Center()))
+}
+
+// inverse returns the mapping from screen to world coordinates.
+func (c Camera) inverse() gfx.Affine {
+	inv, _ := c.Transform().Invert()
+	return inv
+}
+
+func (c Camera) WorldToScreen(p gfx.Vec) gfx.Vec { return c.Transform().Apply(p) }
+func (c Camera) ScreenToWorld(p gfx.Vec) gfx.Vec { return c.inverse().Apply(p) }
+
+// WorldToScreenRect returns the screen bounding box of a world rectangle.
+func (c Camera) WorldToScreenRect(r gfx.Rect) gfx.Rect { return c.Transform().ApplyRect(r) }
+
+// View returns the bounding box of the part of the world that is visible.
+func (c Camera) View() gfx.Rect { return c.inverse().ApplyRect(c.Viewport) }
+
+// viewDrawer is implemented by draw commands that can be drawn through a
+// camera. Other commands are drawn in screen coordinates.
+type viewDrawer interface {
+	drawView(dst *image.NRGBA, m gfx.Affine)
+}
+
+// WithCamera draws a list of commands in world coordinates through a camera,
+// clipped to its viewport. Use several for split screen or a minimap.
+type WithCamera struct {
+	Camera Camera
+	List   DrawList
+}
+
+func (w WithCamera) Draw(dst *image.NRGBA) {
+	vp := w.Camera.Viewport.Round().Rectangle().Intersect(dst.Rect)
+	if vp.Empty() {
+		return
+	}
+	w.List.drawView(dst.SubImage(vp).(*image.NRGBA), w.Camera.Transform())
+}
+
+func (l DrawList) drawView(dst *image.NRGBA, m gfx.Affine) {
+	for _, cmd := range l {
+		if vd, ok := cmd.(viewDrawer); ok {
+			vd.drawView(dst, m)
+		} else {
+			cmd.Draw(dst)
+		}
+	}
+}
+
+func (f FillRect) drawView(dst *image.NRGBA, m gfx.Affine) {
+	if m.AxisAligned() {
+		FillRect{Rect: m.ApplyRect(f.Rect), Color: f.Color}.Draw(dst)
+		return
+	}
+	cs := m.Corners(f.Rect)
+	fillPolygon(dst, cs[:], f.Color)
+}
+
+// The width of a stroked rectangle is scaled along with the world.
+func (s StrokeRect) drawView(dst *image.NRGBA, m gfx.Affine) {
+	w := float64(s.Width)
+	if w <= 0 {
+		w = 1
+	}
+	if m.AxisAligned() {
+		width := int(math.Round(w * m.ScaleFactor()))
+		if width < 1 {
+			width = 1
+		}
+		StrokeRect{Rect: m.ApplyRect(s.Rect), Color: s.Color, Width: width}.Draw(dst)
+		return
+	}
+	size := s.Rect.Size()
+	if 2*w >= size.X || 2*w >= size.Y {
+		FillRect{Rect: s.Rect, Color: s.Color}.drawView(dst, m)
+		return
+	}
+	outer := m.Corners(s.Rect)
+	inner := m.Corners(s.Rect.Inset(w))
+	for i := range outer {
+		j := (i + 1) % len(outer)
+		fillPolygon(dst, []gfx.Vec{outer[i], outer[j], inner[j], inner[i]}, s.Color)
+	}
+}
+
+func (l Line) drawView(dst *image.NRGBA, m gfx.Affine) {
+	Line{From: m.Apply(l.From), To: m.Apply(l.To), Color: l.Color}.Draw(dst)
+}
+
+func (f FillCircle) drawView(dst *image.NRGBA, m gfx.Affine) {
+	FillCircle{Center: m.Apply(f.Center), Radius: f.Radius * m.ScaleFactor(), Color: f.Color}.Draw(dst)
+}
+
+func (s StrokeCircle) drawView(dst *image.NRGBA, m gfx.Affine) {
+	w := s.Width
+	if w <= 0 {
+		w = 1
+	}
+	StrokeCircle{Center: m.Apply(s.Center), Radius: s.Radius * m.ScaleFactor(), Color: s.Color, Width: w * m.ScaleFactor()}.Draw(dst)
+}
+
+func (s DrawSprite) drawView(dst *image.NRGBA, m gfx.Affine) {
+	sprite := s.Sprite
+	if !s.Src.Empty() {
+		sprite = sprite.Sub(s.Src)
+	}
+	sprite.drawView(dst, s.Pos, s.Options, m)
+}
+
+// Text is positioned through the camera, but not scaled or rotated.
+func (t Text) drawView(dst *image.NRGBA, m gfx.Affine) {
+	t.Pos = m.Apply(t.Pos)
+	t.Draw(dst)
+}
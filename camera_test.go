@@ -0,0 +1,141 @@
+package bit
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jncornett/bit/gfx"
+)
+
+func nearVec(a, b gfx.Vec) bool { return a.Dist(b) < 1e-9 }
+
+func TestCameraFollowDeadZone(t *testing.T) {
+	for _, tt := range []struct {
+		target, want gfx.Vec
+	}{
+		{gfx.V(105, 98), gfx.V(100, 100)},
+		{gfx.V(110, 104), gfx.V(100, 100)},
+		{gfx.V(130, 100), gfx.V(120, 100)},
+		{gfx.V(70, 120), gfx.V(80, 116)},
+	} {
+		c := NewCamera(gfx.R(0, 0, 200, 200))
+		c.DeadZone = gfx.V(10, 4)
+		c.Follow(tt.target, time.Second)
+		if !nearVec(c.Pos, tt.want) {
+			t.Errorf("Follow(%v) moved to %v; want %v", tt.target, c.Pos, tt.want)
+		}
+	}
+}
+
+func TestCameraFollowSmoothing(t *testing.T) {
+	c := NewCamera(gfx.R(0, 0, 200, 200))
+	c.Smoothing = 2
+	target := gfx.V(200, 100)
+	c.Follow(target, 500*time.Millisecond)
+	// After half a second at 2 per second, 1-1/e of the way has been covered.
+	if want := gfx.V(100+100*(1-1/math.E), 100); !nearVec(c.Pos, want) {
+		t.Errorf("Follow() moved to %v; want %v", c.Pos, want)
+	}
+	last := c.Pos.Dist(target)
+	for i := 0; i < 100; i++ {
+		c.Follow(target, 100*time.Millisecond)
+		if d := c.Pos.Dist(target); d >= last && d > 1e-9 {
+			t.Fatalf("step %d: distance %v did not shrink from %v", i, d, last)
+		} else {
+			last = d
+		}
+	}
+	if last > 1e-6 {
+		t.Errorf("camera still %v from the target", last)
+	}
+}
+
+func TestCameraClamp(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		bounds gfx.Rect
+		zoom   float64
+		pos    gfx.Vec
+		want   gfx.Vec
+	}{
+		{"inside", gfx.R(0, 0, 1000, 1000), 1, gfx.V(500, 400), gfx.V(500, 400)},
+		{"top left", gfx.R(0, 0, 1000, 1000), 1, gfx.V(-50, 10), gfx.V(100, 50)},
+		{"bottom right", gfx.R(0, 0, 1000, 1000), 1, gfx.V(990, 2000), gfx.V(900, 950)},
+		{"zoomed in", gfx.R(0, 0, 1000, 1000), 2, gfx.V(0, 0), gfx.V(50, 25)},
+		{"world smaller than view", gfx.R(0, 0, 120, 60), 1, gfx.V(500, -500), gfx.V(60, 30)},
+		{"narrower than view", gfx.R(0, 0, 150, 1000), 1, gfx.V(0, 0), gfx.V(75, 50)},
+		{"zoomed out of the world", gfx.R(0, 0, 300, 150), 0.5, gfx.V(0, 0), gfx.V(150, 75)},
+		{"no bounds", gfx.Rect{}, 1, gfx.V(-50, -50), gfx.V(-50, -50)},
+	} {
+		c := NewCamera(gfx.R(0, 0, 200, 100))
+		c.Bounds, c.Zoom, c.Pos = tt.bounds, tt.zoom, tt.pos
+		c.Clamp()
+		if !nearVec(c.Pos, tt.want) {
+			t.Errorf("%s: Clamp() moved to %v; want %v", tt.name, c.Pos, tt.want)
+		}
+		if !tt.bounds.Empty() && tt.bounds.Size().X >= c.View().Size().X && !c.View().In(tt.bounds) {
+			t.Errorf("%s: view %v is outside of %v", tt.name, c.View(), tt.bounds)
+		}
+	}
+}
+
+func TestCameraShake(t *testing.T) {
+	c := NewCamera(gfx.R(0, 0, 200, 100))
+	c.Shake(8, time.Second)
+	now := epoch
+	tick := NewTick(now)
+	var moved bool
+	peak := math.Inf(1)
+	for i := 0; i < 10; i++ {
+		now = now.Add(100 * time.Millisecond)
+		tick = tick.Step(now)
+		c.Update(tick)
+		off := c.ShakeOffset().Len()
+		if off > 8 {
+			t.Errorf("step %d: offset %v exceeds the amplitude", i, off)
+		}
+		// The envelope of the shake only shrinks.
+		left := 1 - float64(c.ShakeElapsed)/float64(c.ShakeDuration)
+		if bound := 8 * left * left * math.Sqrt2; off > bound+1e-9 || bound > peak {
+			t.Errorf("step %d: offset %v outside the envelope %v", i, off, bound)
+		} else {
+			peak = bound
+		}
+		moved = moved || off > 0
+	}
+	if !moved {
+		t.Errorf("shake never moved the camera")
+	}
+	if off := c.ShakeOffset(); off != (gfx.Vec{}) {
+		t.Errorf("offset after the shake = %v; want zero", off)
+	}
+	if elapsed := c.ShakeElapsed; elapsed != time.Second {
+		t.Errorf("elapsed = %v; want it to stop at the duration", elapsed)
+	}
+	c.Update(tick.Step(now.Add(time.Second)))
+	if c.ShakeElapsed != time.Second || c.WorldToScreen(c.Pos) != c.Viewport.Center() {
+		t.Errorf("finished shake kept moving the camera")
+	}
+}
+
+func TestCameraRoundTrip(t *testing.T) {
+	c := NewCamera(gfx.R(20, 10, 220, 110))
+	c.Pos = gfx.V(37, -12)
+	c.Zoom = 2.5
+	c.Rotation = 0.7
+	c.Shake(3, time.Second)
+	c.ShakeElapsed = 300 * time.Millisecond
+	for _, p := range []gfx.Vec{{}, gfx.V(37, -12), gfx.V(-500, 250), gfx.V(1e4, 3)} {
+		if got := c.ScreenToWorld(c.WorldToScreen(p)); got.Dist(p) > 1e-9*math.Max(1, p.Len()) {
+			t.Errorf("ScreenToWorld(WorldToScreen(%v)) = %v", p, got)
+		}
+	}
+	c.ShakeDuration = 0
+	if got := c.WorldToScreen(c.Pos); !nearVec(got, c.Viewport.Center()) {
+		t.Errorf("WorldToScreen(Pos) = %v; want the viewport center %v", got, c.Viewport.Center())
+	}
+	if got := c.ScreenToWorld(c.Viewport.Center()); !nearVec(got, c.Pos) {
+		t.Errorf("ScreenToWorld(center) = %v; want %v", got, c.Pos)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"image"
 	"math"
 	"math/rand"
@@ -10,6 +11,8 @@ import (
 )
 
 func main() {
+	frames := flag.Int("frames", 0, "run without a window for this many frames")
+	flag.Parse()
 	var window = image.Pt(1600, 1200)
 	type gameState struct {
 		renderState bit.RenderState
@@ -17,6 +20,7 @@ func main() {
 		velocities  []gfx.Vec
 	}
 	var initialGameState = gameState{}
+	bounds := gfx.FromImage(image.Rectangle{Max: window})
 	halfSize := 2.0
 	{
 		initialGameState.renderState = make(bit.RenderState, 10000)
@@ -24,51 +28,42 @@ func main() {
 		initialGameState.velocities = make([]gfx.Vec, len(initialGameState.renderState))
 		for i := range initialGameState.renderState {
 			center := gfx.Vec{X: float64(20 + rand.Intn(window.X-40)), Y: float64(20 + rand.Intn(window.Y-40))}
-			rect := gfx.Rect{
-				Min: center.Sub(gfx.Vec{X: halfSize, Y: halfSize}),
-				Max: center.Add(gfx.Vec{X: halfSize, Y: halfSize}),
-			}
+			rect := gfx.FromCenter(center, gfx.V(2*halfSize, 2*halfSize))
 			initialGameState.positions[i] = center
-			theta := rand.Float64() * 2 * math.Pi
-			initialGameState.velocities[i] = gfx.Vec{X: math.Cos(theta), Y: math.Sin(theta)}
+			initialGameState.velocities[i] = gfx.Polar(1, rand.Float64()*2*math.Pi)
 			initialGameState.renderState[i] = rect.Rectangle()
 		}
 	}
 	const speed = 500.0
-	bit.
+	app := bit.
 		NewApp(window, &initialGameState, func(t bit.Tick, state *gameState) (*gameState, bit.RenderState) {
 			for i, v := range state.velocities {
 				p := state.positions[i]
 				d := v.Mul(speed * t.Delta().Seconds())
 				p = p.Add(d)
 				state.positions[i] = p
-				rect := gfx.Rect{
-					Min: p.Round().Sub(gfx.Vec{X: halfSize, Y: halfSize}),
-					Max: p.Round().Add(gfx.Vec{X: halfSize, Y: halfSize}),
-				}
-				abs := func(x float64) float64 {
-					if x < 0 {
-						return -x
-					}
-					return x
+				rect := gfx.FromCenter(p.Round(), gfx.V(2*halfSize, 2*halfSize))
+				abs := v.Abs()
+				if rect.Min.X < bounds.Min.X {
+					v.X = abs.X
 				}
-				if rect.Min.X < 0 {
-					v = gfx.V(abs(v.X), v.Y)
+				if rect.Min.Y < bounds.Min.Y {
+					v.Y = abs.Y
 				}
-				if rect.Min.Y < 0 {
-					v = gfx.V(v.X, abs(v.Y))
+				if rect.Max.X > bounds.Max.X {
+					v.X = -abs.X
 				}
-				if rect.Max.X > float64(window.X) {
-					v = gfx.V(-abs(v.X), v.Y)
-				}
-				if rect.Max.Y > float64(window.Y) {
-					v = gfx.V(v.X, -abs(v.Y))
+				if rect.Max.Y > bounds.Max.Y {
+					v.Y = -abs.Y
 				}
 				state.velocities[i] = v
 				state.renderState[i] = rect.Rectangle()
 			}
 			return state, state.renderState
 		}).
-		Debug().
-		Main()
+		Debug()
+	if *frames > 0 {
+		app.Headless(bit.Headless{Frames: *frames})
+	}
+	app.Main()
 }
@@ -0,0 +1,266 @@
+// Command bitpack packs a directory of images into texture atlas pages and a
+// JSON manifest that can be loaded with bit.LoadAtlas.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jncornett/bit"
+)
+
+func main() {
+	out := flag.String("o", "atlas", "output path prefix; writes PREFIX.json and PREFIX-N.png")
+	pageSize := flag.Int("size", 2048, "maximum page width and height")
+	padding := flag.Int("padding", 2, "transparent pixels around each sprite")
+	extrude := flag.Int("extrude", 1, "pixels to repeat around each sprite's edges")
+	trim := flag.Bool("trim", true, "trim transparent borders")
+	pivot := flag.String("pivot", "0,0", "default pivot as x,y in units of sprite size")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: bitpack [flags] DIR\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	px, py, err := parsePivot(*pivot)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sprites, err := loadDir(flag.Arg(0), *trim)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for i := range sprites {
+		sprites[i].pivot = bit.AtlasPivot{X: px, Y: py}
+	}
+	pages, err := pack(sprites, *pageSize, *padding, *extrude)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := write(*out, pages, *extrude); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type sprite struct {
+	name  string
+	img   *image.NRGBA // trimmed
+	trim  image.Rectangle
+	size  image.Point
+	pivot bit.AtlasPivot
+	rect  image.Rectangle // on the page, excluding extrusion
+}
+
+type page struct {
+	bin     *maxRects
+	sprites []*sprite
+}
+
+func parsePivot(s string) (x, y float64, err error) {
+	xs, ys, ok := strings.Cut(s, ",")
+	if ok {
+		if x, err = strconv.ParseFloat(xs, 64); err == nil {
+			y, err = strconv.ParseFloat(ys, 64)
+		}
+	}
+	if !ok || err != nil {
+		return 0, 0, fmt.Errorf("bad pivot %q", s)
+	}
+	return x, y, nil
+}
+
+// loadDir loads every PNG and GIF below dir. Sprites are named by their
+// slash separated path relative to dir, without the extension, so files that
+// differ only by extension are an error.
+func loadDir(dir string, trim bool) ([]sprite, error) {
+	var sprites []sprite
+	paths := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".png" && ext != ".gif" {
+			return nil
+		}
+		s, err := bit.LoadSpriteFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+		if other, ok := paths[name]; ok {
+			return fmt.Errorf("%s and %s are both named %q", other, path, name)
+		}
+		paths[name] = path
+		img := s.NRGBA
+		bounds := img.Bounds()
+		r := bounds
+		if trim {
+			r = opaqueBounds(img)
+		}
+		sprites = append(sprites, sprite{
+			name: name,
+			img:  img.SubImage(r).(*image.NRGBA),
+			trim: r.Sub(bounds.Min),
+			size: bounds.Size(),
+		})
+		return nil
+	})
+	return sprites, err
+}
+
+// opaqueBounds returns the smallest rectangle containing every pixel of img
+// that is not fully transparent. Fully transparent images keep one pixel.
+func opaqueBounds(img *image.NRGBA) image.Rectangle {
+	b := img.Bounds()
+	r := image.Rectangle{Min: b.Max, Max: b.Min}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.Pix[img.PixOffset(x, y)+3] != 0 {
+				r.Min.X, r.Min.Y = min(r.Min.X, x), min(r.Min.Y, y)
+				r.Max.X, r.Max.Y = max(r.Max.X, x+1), max(r.Max.Y, y+1)
+			}
+		}
+	}
+	if r.Empty() {
+		return image.Rectangle{Min: b.Min, Max: b.Min.Add(image.Pt(1, 1))}
+	}
+	return r
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// pack places sprites on as few pages as it can. Sprites are placed tallest
+// first, breaking ties by name, so that the output only depends on the input.
+func pack(sprites []sprite, pageSize, padding, extrude int) ([]*page, error) {
+	order := make([]*sprite, len(sprites))
+	for i := range sprites {
+		order[i] = &sprites[i]
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if ah, bh := a.img.Rect.Dy(), b.img.Rect.Dy(); ah != bh {
+			return ah > bh
+		}
+		if aw, bw := a.img.Rect.Dx(), b.img.Rect.Dx(); aw != bw {
+			return aw > bw
+		}
+		return a.name < b.name
+	})
+	var pages []*page
+	border := extrude + padding
+	for _, s := range order {
+		// Each sprite is surrounded by its extrusion and then padding on
+		// every side.
+		size := s.img.Rect.Size().Add(image.Pt(2*border, 2*border))
+		if size.X > pageSize || size.Y > pageSize {
+			return nil, fmt.Errorf("%s: %v does not fit on a %dx%d page", s.name, s.img.Rect.Size(), pageSize, pageSize)
+		}
+		placed := false
+		for _, p := range pages {
+			if r, ok := p.bin.Insert(size); ok {
+				s.rect = r.Inset(border)
+				p.sprites = append(p.sprites, s)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			p := &page{bin: newMaxRects(image.Pt(pageSize, pageSize))}
+			r, _ := p.bin.Insert(size)
+			s.rect = r.Inset(border)
+			p.sprites = append(p.sprites, s)
+			pages = append(pages, p)
+		}
+	}
+	return pages, nil
+}
+
+func write(prefix string, pages []*page, extrude int) error {
+	manifest := bit.AtlasManifest{Version: bit.AtlasVersion}
+	for i, p := range pages {
+		name := fmt.Sprintf("%s-%d.png", filepath.Base(prefix), i)
+		img := image.NewNRGBA(image.Rectangle{Max: p.bin.used.Max})
+		sort.Slice(p.sprites, func(i, j int) bool { return p.sprites[i].name < p.sprites[j].name })
+		ap := bit.AtlasPage{Image: name}
+		for _, s := range p.sprites {
+			draw.Draw(img, s.rect, s.img, s.img.Rect.Min, draw.Src)
+			extrudeEdges(img, s.rect, extrude)
+			ap.Sprites = append(ap.Sprites, bit.AtlasSprite{
+				Name:  s.name,
+				Rect:  bit.RectToAtlas(s.rect),
+				Trim:  bit.RectToAtlas(s.trim),
+				Size:  bit.AtlasSize{W: s.size.X, H: s.size.Y},
+				Pivot: s.pivot,
+			})
+		}
+		manifest.Pages = append(manifest.Pages, ap)
+		if err := writePNG(filepath.Join(filepath.Dir(prefix), name), img); err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(prefix+".json", append(data, '\n'), 0o644)
+}
+
+// extrudeEdges repeats the outermost pixels of r outwards by n pixels, so
+// that filtering at the sprite's edges does not bleed in its neighbours.
+func extrudeEdges(img *image.NRGBA, r image.Rectangle, n int) {
+	for i := 1; i <= n; i++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetNRGBA(x, r.Min.Y-i, img.NRGBAAt(x, r.Min.Y))
+			img.SetNRGBA(x, r.Max.Y-1+i, img.NRGBAAt(x, r.Max.Y-1))
+		}
+	}
+	for i := 1; i <= n; i++ {
+		for y := r.Min.Y - n; y < r.Max.Y+n; y++ {
+			img.SetNRGBA(r.Min.X-i, y, img.NRGBAAt(r.Min.X, y))
+			img.SetNRGBA(r.Max.X-1+i, y, img.NRGBAAt(r.Max.X-1, y))
+		}
+	}
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
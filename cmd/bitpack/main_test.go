@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTestPNG(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, image.NewNRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadDirNames(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, filepath.Join(dir, "hero.png"))
+	writeTestPNG(t, filepath.Join(dir, "enemies", "hero.png"))
+	sprites, err := loadDir(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range sprites {
+		names = append(names, s.name)
+	}
+	if got := strings.Join(names, " "); got != "enemies/hero hero" {
+		t.Errorf("names = %q", got)
+	}
+}
+
+func TestLoadDirDuplicate(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, filepath.Join(dir, "hero.png"))
+	writeTestPNG(t, filepath.Join(dir, "hero.PNG"))
+	if _, err := loadDir(dir, true); err == nil || !strings.Contains(err.Error(), `"hero"`) {
+		t.Errorf("loadDir = %v, want a duplicate name error", err)
+	}
+}
+
+// testSprites returns n sprites of random sizes up to max.
+func testSprites(n, max int, seed int64) []sprite {
+	rng := rand.New(rand.NewSource(seed))
+	sprites := make([]sprite, n)
+	for i := range sprites {
+		size := image.Pt(1+rng.Intn(max), 1+rng.Intn(max))
+		sprites[i] = sprite{
+			name: fmt.Sprintf("s%03d", i),
+			img:  image.NewNRGBA(image.Rectangle{Max: size}),
+			size: size,
+		}
+	}
+	return sprites
+}
+
+type placement struct {
+	page int
+	rect image.Rectangle
+}
+
+func placements(pages []*page) map[string]placement {
+	out := make(map[string]placement)
+	for i, p := range pages {
+		for _, s := range p.sprites {
+			out[s.name] = placement{i, s.rect}
+		}
+	}
+	return out
+}
+
+func TestPack(t *testing.T) {
+	const pageSize, padding, extrude = 128, 1, 2
+	const border = padding + extrude
+	sprites := testSprites(200, 40, 1)
+	pages, err := pack(sprites, pageSize, padding, extrude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) < 2 {
+		t.Errorf("packed onto %d pages; want several", len(pages))
+	}
+	got := placements(pages)
+	if len(got) != len(sprites) {
+		t.Fatalf("placed %d sprites; want %d", len(got), len(sprites))
+	}
+	bounds := image.Rect(0, 0, pageSize, pageSize)
+	for i, p := range pages {
+		for j, s := range p.sprites {
+			// The extrusion and padding around a sprite are on the page too.
+			outer := s.rect.Inset(-border)
+			if s.rect.Size() != s.img.Rect.Size() || !outer.In(bounds) {
+				t.Errorf("page %d: %s at %v; want its size inside the page with a %dpx border", i, s.name, s.rect, border)
+			}
+			for _, o := range p.sprites[:j] {
+				if outer.Overlaps(o.rect.Inset(-border)) {
+					t.Errorf("page %d: %s at %v overlaps %s at %v", i, s.name, s.rect, o.name, o.rect)
+				}
+			}
+		}
+	}
+}
+
+func TestPackDeterministic(t *testing.T) {
+	want := placements(mustPack(t, testSprites(100, 30, 2)))
+	for seed := int64(0); seed < 5; seed++ {
+		// The same sprites, found in another order.
+		sprites := testSprites(100, 30, 2)
+		rand.New(rand.NewSource(seed)).Shuffle(len(sprites), func(i, j int) {
+			sprites[i], sprites[j] = sprites[j], sprites[i]
+		})
+		if got := placements(mustPack(t, sprites)); !reflect.DeepEqual(got, want) {
+			t.Errorf("shuffle %d: placements differ", seed)
+		}
+	}
+}
+
+func mustPack(t *testing.T, sprites []sprite) []*page {
+	t.Helper()
+	pages, err := pack(sprites, 128, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pages
+}
+
+func TestPackTooLarge(t *testing.T) {
+	sprites := testSprites(3, 10, 3)
+	sprites[1].img = image.NewNRGBA(image.Rect(0, 0, 30, 31))
+	// 31 pixels and a border of 1 on each side does not fit on 32.
+	if _, err := pack(sprites, 32, 0, 1); err == nil || !strings.Contains(err.Error(), "s001") {
+		t.Errorf("pack = %v; want an error naming s001", err)
+	}
+	if _, err := pack(sprites, 33, 0, 1); err != nil {
+		t.Errorf("pack with room = %v", err)
+	}
+}
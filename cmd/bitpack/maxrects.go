@@ -0,0 +1,83 @@
+package main
+
+import (
+	"image"
+	"math"
+)
+
+// maxRects packs rectangles into a fixed size bin using the MaxRects
+// algorithm with the best short side fit heuristic.
+type maxRects struct {
+	size image.Point
+	free []image.Rectangle
+	used image.Rectangle
+}
+
+func newMaxRects(size image.Point) *maxRects {
+	return &maxRects{size: size, free: []image.Rectangle{{Max: size}}}
+}
+
+// Insert finds room for a rectangle of the given size and returns its
+// position, or false if there is none.
+func (m *maxRects) Insert(size image.Point) (image.Rectangle, bool) {
+	best := image.Rectangle{}
+	bestShort, bestLong := math.MaxInt, math.MaxInt
+	for _, f := range m.free {
+		if f.Dx() < size.X || f.Dy() < size.Y {
+			continue
+		}
+		dx, dy := f.Dx()-size.X, f.Dy()-size.Y
+		short, long := dx, dy
+		if short > long {
+			short, long = long, short
+		}
+		if short < bestShort || (short == bestShort && long < bestLong) {
+			best = image.Rectangle{Min: f.Min, Max: f.Min.Add(size)}
+			bestShort, bestLong = short, long
+		}
+	}
+	if bestShort == math.MaxInt {
+		return image.Rectangle{}, false
+	}
+	m.place(best)
+	return best, true
+}
+
+func (m *maxRects) place(r image.Rectangle) {
+	m.used = m.used.Union(r)
+	var free []image.Rectangle
+	for _, f := range m.free {
+		if !f.Overlaps(r) {
+			free = append(free, f)
+			continue
+		}
+		// Split f into up to four maximal rectangles around r.
+		if r.Min.X > f.Min.X {
+			free = append(free, image.Rect(f.Min.X, f.Min.Y, r.Min.X, f.Max.Y))
+		}
+		if r.Max.X < f.Max.X {
+			free = append(free, image.Rect(r.Max.X, f.Min.Y, f.Max.X, f.Max.Y))
+		}
+		if r.Min.Y > f.Min.Y {
+			free = append(free, image.Rect(f.Min.X, f.Min.Y, f.Max.X, r.Min.Y))
+		}
+		if r.Max.Y < f.Max.Y {
+			free = append(free, image.Rect(f.Min.X, r.Max.Y, f.Max.X, f.Max.Y))
+		}
+	}
+	// Prune free rectangles contained in others.
+	pruned := make([]image.Rectangle, 0, len(free))
+	for i, a := range free {
+		contained := false
+		for j, b := range free {
+			if i != j && a.In(b) && (a != b || j < i) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			pruned = append(pruned, a)
+		}
+	}
+	m.free = pruned
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+)
+
+func TestMaxRects(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	bin := image.Rect(0, 0, 256, 256)
+	m := newMaxRects(bin.Max)
+	var placed []image.Rectangle
+	area := 0
+	for i := 0; i < 500; i++ {
+		size := image.Pt(1+rng.Intn(40), 1+rng.Intn(40))
+		r, ok := m.Insert(size)
+		if !ok {
+			continue
+		}
+		if r.Size() != size || !r.In(bin) {
+			t.Fatalf("Insert(%v) = %v; want that size inside %v", size, r, bin)
+		}
+		for _, other := range placed {
+			if r.Overlaps(other) {
+				t.Fatalf("Insert(%v) = %v, overlapping %v", size, r, other)
+			}
+		}
+		placed = append(placed, r)
+		area += size.X * size.Y
+	}
+	// Best short side fit should fill most of the bin.
+	if area < bin.Dx()*bin.Dy()*3/4 {
+		t.Errorf("packed %d of %d pixels", area, bin.Dx()*bin.Dy())
+	}
+	for _, size := range []image.Point{{257, 1}, {1, 257}} {
+		if r, ok := newMaxRects(bin.Max).Insert(size); ok {
+			t.Errorf("Insert(%v) = %v; want no room", size, r)
+		}
+	}
+	if r, ok := newMaxRects(bin.Max).Insert(bin.Max); !ok || r != bin {
+		t.Errorf("Insert of the whole bin = %v, %v", r, ok)
+	}
+}
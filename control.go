@@ -0,0 +1,99 @@
+package bit
+
+import (
+	"sync"
+	"time"
+)
+
+// Control pauses, steps and scales the time of a running Engine, while the
+// window keeps presenting. It may be used from any goroutine, including
+// from Update. The zero Control is running at normal speed.
+//
+// An engine that records or replays frames cannot have a Control, since the
+// replay could not reproduce what it does. See ErrControlledReplay.
+type Control struct {
+	mu     sync.Mutex
+	paused bool
+	steps  int
+	scale  float64
+}
+
+func (c *Control) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = true
+}
+
+// Resume resumes a paused engine, dropping any steps it has not taken.
+func (c *Control) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused, c.steps = false, 0
+}
+
+func (c *Control) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// Step pauses the engine and has it update once more, on its next frame.
+func (c *Control) Step() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = true
+	c.steps++
+}
+
+// SetTimeScale scales the time that passes in Update, e.g. 0.25 for slow
+// motion or 4 to fast forward. Zero or less is 1.
+func (c *Control) SetTimeScale(s float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scale = s
+}
+
+func (c *Control) TimeScale() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.timeScale()
+}
+
+func (c *Control) timeScale() float64 {
+	if c.scale <= 0 {
+		return 1
+	}
+	return c.scale
+}
+
+// take returns the state of the control, and the steps to take now.
+func (c *Control) take() (paused bool, steps int, scale float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	steps, c.steps = c.steps, 0
+	return c.paused, steps, c.timeScale()
+}
+
+// gameClock turns the ticks of real time into ticks of game time, which
+// stops while paused and runs faster or slower when scaled.
+type gameClock struct {
+	// lag is how far game time is behind real time.
+	lag time.Duration
+}
+
+// pass lets the real time of t pass without any game time passing.
+func (c *gameClock) pass(t Tick) { c.lag += t.Delta() }
+
+// next returns the tick in which d of game time passes, at the end of t.
+func (c *gameClock) next(t Tick, d time.Duration) Tick {
+	start := t[2].Add(-c.lag)
+	c.lag -= d
+	return Tick{t[0], start, start.Add(d)}
+}
+
+func scale(d time.Duration, s float64) time.Duration {
+	if s == 1 {
+		return d
+	}
+	return time.Duration(float64(d) * s)
+}
@@ -0,0 +1,180 @@
+package bit
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"gioui.org/io/key"
+	"github.com/jncornett/bit/gfx"
+)
+
+// DebugKeys are the names of the debugger's hotkeys, as in key.Event.
+type DebugKeys struct {
+	// Pause pauses the game, or resumes it from the frame being shown.
+	Pause string
+	// Back pauses the game, or shows the frame before the one being shown.
+	Back string
+	// Step pauses the game, or shows the frame after the one being shown,
+	// updating the game once if it is the newest.
+	Step string
+	// Slower and Faster halve and double the time scale.
+	Slower, Faster string
+}
+
+var DefaultDebugKeys = DebugKeys{
+	Pause:  key.NameF5,
+	Back:   key.NameF6,
+	Step:   key.NameF7,
+	Slower: key.NameF8,
+	Faster: key.NameF9,
+}
+
+// The debugger's time scale stays within these bounds.
+const (
+	minDebugScale = 1.0 / 64
+	maxDebugScale = 64
+)
+
+// Debugger keeps the game states of the last frames, so that a paused game
+// can be stepped back through and resumed from an earlier frame. The keys and
+// buttons held at that frame are restored with it. Its hotkeys
+// work through the engine's Control, and resuming the game from code also
+// resumes it from the frame being shown.
+//
+// Frames shown while paused are drawn from the render states Update
+// returned, so Update must not reuse their memory for them to be right.
+// An engine that records or replays frames cannot have a Debugger.
+type Debugger[GameState, RenderState any] struct {
+	// Clone returns a copy of a game state that later updates do not change.
+	Clone func(GameState) GameState
+	Keys  DebugKeys
+
+	frames []debugFrame[GameState, RenderState]
+	// next is where the next frame goes in frames, which holds n frames.
+	next, n int
+	// back is how many frames before the newest is being shown.
+	back int
+}
+
+type debugFrame[GameState, RenderState any] struct {
+	state  GameState
+	render RenderState
+	// input is what was held after the frame, without edges.
+	input Input
+}
+
+// NewDebugger returns a debugger that keeps the last frames game states.
+func NewDebugger[GameState, RenderState any](frames int, clone func(GameState) GameState) *Debugger[GameState, RenderState] {
+	if frames < 1 {
+		frames = 1
+	}
+	return &Debugger[GameState, RenderState]{
+		Clone:  clone,
+		Keys:   DefaultDebugKeys,
+		frames: make([]debugFrame[GameState, RenderState], frames),
+	}
+}
+
+// at returns the frame back frames before the newest.
+func (d *Debugger[GameState, RenderState]) at(back int) *debugFrame[GameState, RenderState] {
+	return &d.frames[(d.next-1-back+2*len(d.frames))%len(d.frames)]
+}
+
+func (d *Debugger[GameState, RenderState]) record(state GameState, render RenderState, input Input) {
+	d.frames[d.next] = debugFrame[GameState, RenderState]{d.Clone(state), render, input}
+	d.next = (d.next + 1) % len(d.frames)
+	if d.n < len(d.frames) {
+		d.n++
+	}
+}
+
+// hotkeys removes presses and releases of the debugger's keys from events,
+// and returns the keys pressed.
+func (d *Debugger[GameState, RenderState]) hotkeys(events []Event) (rest []Event, pressed []string) {
+	for _, ev := range events {
+		if ev.Kind == EventKey {
+			switch ev.Key.Name {
+			case d.Keys.Pause, d.Keys.Back, d.Keys.Step, d.Keys.Slower, d.Keys.Faster:
+				if ev.Key.State == key.Press {
+					pressed = append(pressed, ev.Key.Name)
+				}
+				continue
+			}
+		}
+		rest = append(rest, ev)
+	}
+	return rest, pressed
+}
+
+// control acts on the hotkeys pressed.
+func (d *Debugger[GameState, RenderState]) control(pressed []string, c *Control) {
+	for _, k := range pressed {
+		switch {
+		case k == d.Keys.Slower:
+			c.SetTimeScale(math.Max(c.TimeScale()/2, minDebugScale))
+		case k == d.Keys.Faster:
+			c.SetTimeScale(math.Min(c.TimeScale()*2, maxDebugScale))
+		case !c.Paused():
+			c.Pause()
+			d.back = 0
+			if k == d.Keys.Back && d.n > 1 {
+				d.back = 1
+			}
+		case k == d.Keys.Pause:
+			c.Resume()
+		case k == d.Keys.Back:
+			if d.back < d.n-1 {
+				d.back++
+			}
+		case k == d.Keys.Step:
+			if d.back > 0 {
+				d.back--
+			} else {
+				c.Step()
+			}
+		}
+	}
+}
+
+// rewind is called when the game is about to update. If an earlier frame is
+// being shown, the frames after it are forgotten and state, render and input
+// are set to it.
+func (d *Debugger[GameState, RenderState]) rewind(state *GameState, render *RenderState, input *inputState) {
+	if d.back == 0 {
+		return
+	}
+	d.next = (d.next - d.back + len(d.frames)) % len(d.frames)
+	d.n -= d.back
+	d.back = 0
+	f := d.at(0)
+	*state, *render = d.Clone(f.state), f.render
+	*input = inputState{in: f.input, shared: true}
+}
+
+// shown returns the render state of the frame being shown while paused.
+func (d *Debugger[GameState, RenderState]) shown(curr RenderState) RenderState {
+	if d.n == 0 {
+		return curr
+	}
+	return d.at(d.back).render
+}
+
+// drawStatus marks the buffer with whether the game is paused, the frame
+// being shown and the time scale.
+func (d *Debugger[GameState, RenderState]) drawStatus(dst *image.NRGBA, paused bool, scale float64) {
+	var msg string
+	if paused {
+		msg = "paused "
+		if d.back > 0 {
+			msg += fmt.Sprintf("%d/%d ", -d.back, d.n-1)
+		}
+	}
+	if scale != 1 {
+		msg += fmt.Sprintf("x%g", scale)
+	}
+	pos := gfx.V(float64(dst.Rect.Min.X+4), float64(dst.Rect.Min.Y+14))
+	Text{Text: msg, Pos: pos.Add(gfx.V(1, 1)), Color: color.NRGBA{A: 255}}.Draw(dst)
+	Text{Text: msg, Pos: pos, Color: color.NRGBA{255, 255, 0, 255}}.Draw(dst)
+}
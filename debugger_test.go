@@ -0,0 +1,161 @@
+package bit
+
+import (
+	"reflect"
+	"testing"
+
+	"gioui.org/io/key"
+)
+
+func keyEvent(name string, state key.State) Event {
+	return Event{Kind: EventKey, Key: KeyEvent{Name: name, State: state}}
+}
+
+func TestDebuggerControl(t *testing.T) {
+	k := DefaultDebugKeys
+	for _, tt := range []struct {
+		name    string
+		paused  bool
+		back, n int
+		keys    []string
+		// The state afterwards.
+		wantPaused bool
+		wantBack   int
+		wantSteps  int
+	}{
+		{name: "pause", n: 5, keys: []string{k.Pause}, wantPaused: true},
+		{name: "step while running pauses", n: 5, keys: []string{k.Step}, wantPaused: true},
+		{name: "back while running", n: 5, keys: []string{k.Back}, wantPaused: true, wantBack: 1},
+		{name: "back with one frame", n: 1, keys: []string{k.Back}, wantPaused: true},
+		{name: "resume", paused: true, back: 2, n: 5, keys: []string{k.Pause}, wantBack: 2},
+		{name: "back", paused: true, back: 1, n: 5, keys: []string{k.Back, k.Back}, wantPaused: true, wantBack: 3},
+		{name: "back stops at oldest", paused: true, back: 3, n: 5, keys: []string{k.Back, k.Back, k.Back}, wantPaused: true, wantBack: 4},
+		{name: "step forward", paused: true, back: 2, n: 5, keys: []string{k.Step}, wantPaused: true, wantBack: 1},
+		{name: "step newest", paused: true, n: 5, keys: []string{k.Step, k.Step}, wantPaused: true, wantSteps: 2},
+		{name: "step back to newest", paused: true, back: 1, n: 5, keys: []string{k.Step, k.Step}, wantPaused: true, wantSteps: 1},
+		{name: "pause then resume", n: 5, keys: []string{k.Back, k.Pause}, wantBack: 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDebugger[int, int](8, func(n int) int { return n })
+			d.back, d.n = tt.back, tt.n
+			var c Control
+			if tt.paused {
+				c.Pause()
+			}
+			d.control(tt.keys, &c)
+			paused, steps, _ := c.take()
+			if paused != tt.wantPaused || d.back != tt.wantBack || steps != tt.wantSteps {
+				t.Errorf("paused, back, steps = %v, %d, %d; want %v, %d, %d", paused, d.back, steps, tt.wantPaused, tt.wantBack, tt.wantSteps)
+			}
+		})
+	}
+}
+
+func TestDebuggerTimeScale(t *testing.T) {
+	d := NewDebugger[int, int](1, func(n int) int { return n })
+	var c Control
+	d.control([]string{d.Keys.Faster, d.Keys.Faster}, &c)
+	if s := c.TimeScale(); s != 4 {
+		t.Errorf("TimeScale() = %g; want 4", s)
+	}
+	for i := 0; i < 20; i++ {
+		d.control([]string{d.Keys.Slower}, &c)
+	}
+	if s := c.TimeScale(); s != minDebugScale {
+		t.Errorf("TimeScale() = %g; want %g", s, minDebugScale)
+	}
+	for i := 0; i < 20; i++ {
+		d.control([]string{d.Keys.Faster}, &c)
+	}
+	if s := c.TimeScale(); s != maxDebugScale {
+		t.Errorf("TimeScale() = %g; want %g", s, float64(maxDebugScale))
+	}
+	if c.Paused() {
+		t.Errorf("scaling time paused the game")
+	}
+}
+
+func TestDebuggerRing(t *testing.T) {
+	clones := 0
+	d := NewDebugger[int, int](3, func(n int) int { clones++; return n })
+	if got := d.shown(-1); got != -1 {
+		t.Errorf("shown() with no frames = %d; want -1", got)
+	}
+	for i := 1; i <= 5; i++ {
+		d.record(i, i*10, Input{})
+	}
+	if d.n != 3 || clones != 5 {
+		t.Errorf("n = %d, clones = %d; want 3, 5", d.n, clones)
+	}
+	for back, want := range []int{5, 4, 3} {
+		if f := d.at(back); f.state != want || f.render != want*10 {
+			t.Errorf("at(%d) = %d, %d; want %d, %d", back, f.state, f.render, want, want*10)
+		}
+	}
+
+	d.back = 2
+	if got := d.shown(0); got != 30 {
+		t.Errorf("shown() = %d; want 30", got)
+	}
+	state, render := 5, 50
+	var input inputState
+	d.rewind(&state, &render, &input)
+	if state != 3 || render != 30 || d.n != 1 || d.back != 0 {
+		t.Errorf("after rewind: state %d, render %d, n %d, back %d; want 3, 30, 1, 0", state, render, d.n, d.back)
+	}
+	d.rewind(&state, &render, &input) // nothing to rewind
+	if state != 3 || d.n != 1 {
+		t.Errorf("second rewind: state %d, n %d; want 3, 1", state, d.n)
+	}
+
+	// Frames recorded after rewinding replace the ones forgotten.
+	for i := 6; i <= 9; i++ {
+		d.record(i, i*10, Input{})
+	}
+	for back, want := range []int{9, 8, 7} {
+		if f := d.at(back); f.state != want {
+			t.Errorf("at(%d) = %d; want %d", back, f.state, want)
+		}
+	}
+}
+
+func TestDebuggerRewindInput(t *testing.T) {
+	d := NewDebugger[int, int](8, func(n int) int { return n })
+	var downs []bool
+	e := &Engine[int, int]{
+		Debugger: d,
+		UpdateInput: func(_ Tick, in Input, n int) (int, int) {
+			downs = append(downs, in.Down("A"))
+			return n + 1, n + 1
+		},
+	}
+	fs := frames(4)
+	fs[0].Events = []Event{keyEvent("A", key.Press)}
+	fs[1].Events = []Event{keyEvent("A", key.Release)}
+	fs[2].Events = []Event{keyEvent(d.Keys.Back, key.Press)}
+	fs[3].Events = []Event{keyEvent(d.Keys.Pause, key.Press)}
+	var last int
+	e.Stopped = func(n int) error { last = n; return nil }
+	runFrames(t, e, 0, fs, nil)
+
+	// Resuming from the first frame restores A being held then.
+	if want := []bool{true, false, true}; !reflect.DeepEqual(downs, want) {
+		t.Errorf("A down in updates = %v; want %v", downs, want)
+	}
+	if last != 2 {
+		t.Errorf("last state = %d; want 2", last)
+	}
+}
+
+func TestInputHeld(t *testing.T) {
+	var s inputState
+	s.Apply([]Event{keyEvent("A", key.Press)})
+	held := s.held()
+	s.Apply([]Event{keyEvent("A", key.Release), keyEvent("B", key.Press)})
+	if !held.Down("A") || held.Down("B") || held.Pressed("A") {
+		t.Errorf("held changed with later events: A %v, B %v, pressed A %v", held.Down("A"), held.Down("B"), held.Pressed("A"))
+	}
+	if in := s.Take(); in.Down("A") || !in.Down("B") || !in.Pressed("A") || !in.Released("A") {
+		t.Errorf("Take() lost events after held()")
+	}
+}
@@ -0,0 +1,145 @@
+package bit
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/jncornett/bit/gfx"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Drawer is a render state that knows how to draw itself onto the back buffer.
+type Drawer interface {
+	Draw(dst *image.NRGBA)
+}
+
+// DrawList is a render state made of draw commands, drawn in order.
+type DrawList []Drawer
+
+func (l DrawList) Draw(dst *image.NRGBA) {
+	for _, cmd := range l {
+		cmd.Draw(dst)
+	}
+}
+
+// Clear fills the whole buffer with a color, replacing what was there.
+type Clear struct {
+	Color color.NRGBA
+}
+
+func (c Clear) Draw(dst *image.NRGBA) {
+	draw.Draw(dst, dst.Rect, image.NewUniform(c.Color), image.Point{}, draw.Src)
+}
+
+type FillRect struct {
+	Rect  gfx.Rect
+	Color color.NRGBA
+}
+
+func (f FillRect) Draw(dst *image.NRGBA) {
+	fillRect(dst, f.Rect.Round().Rectangle(), f.Color)
+}
+
+// StrokeRect outlines a rectangle with a border Width pixels wide, drawn
+// inside the rectangle. A zero Width is one pixel.
+type StrokeRect struct {
+	Rect  gfx.Rect
+	Color color.NRGBA
+	Width int
+}
+
+func (s StrokeRect) Draw(dst *image.NRGBA) {
+	r := s.Rect.Round().Rectangle()
+	w := s.Width
+	if w <= 0 {
+		w = 1
+	}
+	if 2*w >= r.Dx() || 2*w >= r.Dy() {
+		fillRect(dst, r, s.Color)
+		return
+	}
+	fillRect(dst, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+w), s.Color)
+	fillRect(dst, image.Rect(r.Min.X, r.Max.Y-w, r.Max.X, r.Max.Y), s.Color)
+	fillRect(dst, image.Rect(r.Min.X, r.Min.Y+w, r.Min.X+w, r.Max.Y-w), s.Color)
+	fillRect(dst, image.Rect(r.Max.X-w, r.Min.Y+w, r.Max.X, r.Max.Y-w), s.Color)
+}
+
+// Line draws a one pixel wide line between the pixels containing From and To.
+type Line struct {
+	From, To gfx.Vec
+	Color    color.NRGBA
+}
+
+func (l Line) Draw(dst *image.NRGBA) {
+	line(dst, l.From.Floor().Point(), l.To.Floor().Point(), l.Color)
+}
+
+type FillCircle struct {
+	Center gfx.Vec
+	Radius float64
+	Color  color.NRGBA
+}
+
+func (f FillCircle) Draw(dst *image.NRGBA) {
+	fillCircle(dst, f.Center.X, f.Center.Y, f.Radius, f.Color)
+}
+
+// StrokeCircle outlines a circle with a ring Width pixels wide, drawn inside
+// the circle. A zero Width is one pixel.
+type StrokeCircle struct {
+	Center gfx.Vec
+	Radius float64
+	Color  color.NRGBA
+	Width  float64
+}
+
+func (s StrokeCircle) Draw(dst *image.NRGBA) {
+	w := s.Width
+	if w <= 0 {
+		w = 1
+	}
+	strokeCircle(dst, s.Center.X, s.Center.Y, s.Radius, w, s.Color)
+}
+
+// DrawSprite draws the Src part of a sprite with its top left corner at Pos.
+// An empty Src draws the whole sprite.
+type DrawSprite struct {
+	Sprite  Sprite
+	Src     image.Rectangle
+	Pos     gfx.Vec
+	Options DrawOptions
+}
+
+func (s DrawSprite) Draw(dst *image.NRGBA) {
+	sprite := s.Sprite
+	if !s.Src.Empty() {
+		sprite = sprite.Sub(s.Src)
+	}
+	sprite.Draw(dst, s.Pos, s.Options)
+}
+
+// Text draws a string with its baseline starting at Pos.
+// A nil Face uses a 7x13 pixel bitmap font.
+type Text struct {
+	Text  string
+	Pos   gfx.Vec
+	Color color.NRGBA
+	Face  font.Face
+}
+
+func (t Text) Draw(dst *image.NRGBA) {
+	face := t.Face
+	if face == nil {
+		face = basicfont.Face7x13
+	}
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(t.Color),
+		Face: face,
+		Dot:  fixed.Point26_6{X: fixed.Int26_6(t.Pos.X * 64), Y: fixed.Int26_6(t.Pos.Y * 64)},
+	}
+	d.DrawString(t.Text)
+}
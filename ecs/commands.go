@@ -0,0 +1,54 @@
+package ecs
+
+// Commands records changes to a world to be applied later, such as spawning
+// and despawning entities while a query is iterating. The zero Commands is
+// empty and ready to use.
+type Commands struct {
+	ops []func(w *World)
+}
+
+// Spawn records spawning an entity. When it is applied, f is called with the
+// new entity to add its components.
+func (c *Commands) Spawn(f func(w *World, e Entity)) {
+	c.ops = append(c.ops, func(w *World) {
+		e := w.Spawn()
+		if f != nil {
+			f(w, e)
+		}
+	})
+}
+
+func (c *Commands) Despawn(e Entity) {
+	c.ops = append(c.ops, func(w *World) { w.Despawn(e) })
+}
+
+// Do records calling f with the world.
+func (c *Commands) Do(f func(w *World)) { c.ops = append(c.ops, f) }
+
+// Len returns the number of recorded commands.
+func (c *Commands) Len() int { return len(c.ops) }
+
+// Apply applies the recorded commands in the order they were recorded, and
+// clears them. Commands recorded by those being applied are applied too.
+func (c *Commands) Apply(w *World) {
+	for i := 0; i < len(c.ops); i++ {
+		c.ops[i](w)
+		c.ops[i] = nil
+	}
+	c.ops = c.ops[:0]
+}
+
+// Insert records setting the component of type T of an entity. It does
+// nothing if the entity has been despawned by the time it is applied.
+func Insert[T any](c *Commands, e Entity, v T) {
+	c.ops = append(c.ops, func(w *World) {
+		if w.Alive(e) {
+			Add(w, e, v)
+		}
+	})
+}
+
+// Delete records removing the component of type T of an entity.
+func Delete[T any](c *Commands, e Entity) {
+	c.ops = append(c.ops, func(w *World) { Remove[T](w, e) })
+}
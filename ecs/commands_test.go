@@ -0,0 +1,73 @@
+package ecs
+
+import "testing"
+
+func TestCommands(t *testing.T) {
+	w := NewWorld()
+	var c Commands
+	var es []Entity
+	for i := 0; i < 4; i++ {
+		e := w.Spawn()
+		Add(w, e, pos{float64(i), 0})
+		es = append(es, e)
+	}
+
+	// Despawn odd positions and spawn a child for each even one, from inside
+	// the query.
+	NewQuery1[pos](w).Each(func(e Entity, p *pos) {
+		if int(p.X)%2 == 1 {
+			c.Despawn(e)
+			return
+		}
+		x := p.X
+		c.Spawn(func(w *World, child Entity) { Add(w, child, pos{x, 1}) })
+		Insert(&c, e, vel{1, 0})
+	})
+	if c.Len() != 6 || w.Len() != 4 {
+		t.Fatalf("before Apply: Len() = %d, world %d; want 6, 4", c.Len(), w.Len())
+	}
+	c.Apply(w)
+	if c.Len() != 0 {
+		t.Errorf("Len() after Apply = %d; want 0", c.Len())
+	}
+	if w.Len() != 4 || w.Alive(es[1]) || w.Alive(es[3]) {
+		t.Errorf("after Apply: Len() = %d, alive 1, 3 = %v, %v", w.Len(), w.Alive(es[1]), w.Alive(es[3]))
+	}
+	if Count[pos](w) != 4 || Count[vel](w) != 2 || !Has[vel](w, es[0]) || !Has[vel](w, es[2]) {
+		t.Errorf("after Apply: %d pos, %d vel", Count[pos](w), Count[vel](w))
+	}
+
+	Delete[vel](&c, es[0])
+	c.Apply(w)
+	if Has[vel](w, es[0]) {
+		t.Errorf("Delete did not remove the component")
+	}
+}
+
+func TestCommandsOrder(t *testing.T) {
+	w := NewWorld()
+	e := w.Spawn()
+	var c Commands
+	// Inserting into an entity despawned earlier in the same batch does
+	// nothing, rather than panicking.
+	c.Despawn(e)
+	Insert(&c, e, pos{})
+	var order []int
+	c.Do(func(*World) {
+		order = append(order, 1)
+		c.Do(func(*World) { order = append(order, 3) })
+	})
+	c.Do(func(*World) { order = append(order, 2) })
+	c.Spawn(nil)
+	c.Apply(w)
+
+	if w.Alive(e) || Count[pos](w) != 0 {
+		t.Errorf("Insert into a despawned entity added a component")
+	}
+	if len(order) != 3 || order[0] != 1 || order[1] != 2 || order[2] != 3 {
+		t.Errorf("commands applied in order %v; want [1 2 3]", order)
+	}
+	if w.Len() != 1 {
+		t.Errorf("Spawn(nil) left Len() = %d; want 1", w.Len())
+	}
+}
@@ -0,0 +1,92 @@
+package ecs
+
+// Queries visit every entity that has all of their component types. While a
+// query is iterating, components of its types must not be added or removed;
+// use Commands to defer such changes until the iteration is done.
+
+type Query1[A any] struct {
+	a *Storage[A]
+}
+
+func NewQuery1[A any](w *World) Query1[A] {
+	return Query1[A]{storageOf[A](w)}
+}
+
+func (q Query1[A]) Len() int { return len(q.a.dense) }
+
+func (q Query1[A]) Each(f func(e Entity, a *A)) {
+	for i, e := range q.a.dense {
+		f(e, &q.a.data[i])
+	}
+}
+
+type Query2[A, B any] struct {
+	a *Storage[A]
+	b *Storage[B]
+}
+
+func NewQuery2[A, B any](w *World) Query2[A, B] {
+	return Query2[A, B]{storageOf[A](w), storageOf[B](w)}
+}
+
+// Each visits the entities in the order of whichever of the query's
+// storages is smallest.
+func (q Query2[A, B]) Each(f func(e Entity, a *A, b *B)) {
+	if len(q.a.dense) <= len(q.b.dense) {
+		data := q.a.data
+		for i, e := range q.a.dense {
+			if j := q.b.find(e); j >= 0 {
+				f(e, &data[i], &q.b.data[j])
+			}
+		}
+		return
+	}
+	data := q.b.data
+	for i, e := range q.b.dense {
+		if j := q.a.find(e); j >= 0 {
+			f(e, &q.a.data[j], &data[i])
+		}
+	}
+}
+
+type Query3[A, B, C any] struct {
+	a *Storage[A]
+	b *Storage[B]
+	c *Storage[C]
+}
+
+func NewQuery3[A, B, C any](w *World) Query3[A, B, C] {
+	return Query3[A, B, C]{storageOf[A](w), storageOf[B](w), storageOf[C](w)}
+}
+
+// Each visits the entities in the order of whichever of the query's
+// storages is smallest.
+func (q Query3[A, B, C]) Each(f func(e Entity, a *A, b *B, c *C)) {
+	na, nb, nc := len(q.a.dense), len(q.b.dense), len(q.c.dense)
+	switch {
+	case na <= nb && na <= nc:
+		for i, e := range q.a.dense {
+			if b, ok := q.b.get(e); ok {
+				if c, ok := q.c.get(e); ok {
+					f(e, &q.a.data[i], b, c)
+				}
+			}
+		}
+	case nb <= nc:
+		for i, e := range q.b.dense {
+			if a, ok := q.a.get(e); ok {
+				if c, ok := q.c.get(e); ok {
+					f(e, a, &q.b.data[i], c)
+				}
+			}
+		}
+	default:
+		for i, e := range q.c.dense {
+			if a, ok := q.a.get(e); ok {
+				if b, ok := q.b.get(e); ok {
+					f(e, a, b, &q.c.data[i])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package ecs
+
+import (
+	"sort"
+	"testing"
+)
+
+func collect(f func(func(e Entity))) []Entity {
+	var es []Entity
+	f(func(e Entity) { es = append(es, e) })
+	sort.Slice(es, func(i, j int) bool { return es[i] < es[j] })
+	return es
+}
+
+func equal(a, b []Entity) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestQuery(t *testing.T) {
+	w := NewWorld()
+	var all, moving, tagged []Entity
+	for i := 0; i < 30; i++ {
+		e := w.Spawn()
+		Add(w, e, pos{float64(i), 0})
+		all = append(all, e)
+		if i%2 == 0 {
+			Add(w, e, vel{1, 0})
+			moving = append(moving, e)
+			if i%3 == 0 {
+				Add(w, e, tag{})
+				tagged = append(tagged, e)
+			}
+		}
+	}
+	// An entity with only a velocity matches no query with pos.
+	Add(w, w.Spawn(), vel{})
+
+	got := collect(func(f func(Entity)) {
+		NewQuery1[pos](w).Each(func(e Entity, _ *pos) { f(e) })
+	})
+	if !equal(got, all) {
+		t.Errorf("Query1[pos] = %v; want %v", got, all)
+	}
+	// Query both ways round, so each storage drives the iteration once.
+	got = collect(func(f func(Entity)) {
+		NewQuery2[pos, vel](w).Each(func(e Entity, _ *pos, _ *vel) { f(e) })
+	})
+	if !equal(got, moving) {
+		t.Errorf("Query2[pos, vel] = %v; want %v", got, moving)
+	}
+	got = collect(func(f func(Entity)) {
+		NewQuery2[vel, pos](w).Each(func(e Entity, _ *vel, _ *pos) { f(e) })
+	})
+	if !equal(got, moving) {
+		t.Errorf("Query2[vel, pos] = %v; want %v", got, moving)
+	}
+	for name, each := range map[string]func(func(Entity)){
+		"pos, vel, tag": func(f func(Entity)) {
+			NewQuery3[pos, vel, tag](w).Each(func(e Entity, _ *pos, _ *vel, _ *tag) { f(e) })
+		},
+		"tag, pos, vel": func(f func(Entity)) {
+			NewQuery3[tag, pos, vel](w).Each(func(e Entity, _ *tag, _ *pos, _ *vel) { f(e) })
+		},
+		"pos, tag, vel": func(f func(Entity)) {
+			NewQuery3[pos, tag, vel](w).Each(func(e Entity, _ *pos, _ *tag, _ *vel) { f(e) })
+		},
+	} {
+		if got := collect(each); !equal(got, tagged) {
+			t.Errorf("Query3[%s] = %v; want %v", name, got, tagged)
+		}
+	}
+}
+
+func TestQueryUpdates(t *testing.T) {
+	w := NewWorld()
+	e := w.Spawn()
+	Add(w, e, pos{1, 1})
+	Add(w, e, vel{2, 3})
+	NewQuery2[pos, vel](w).Each(func(_ Entity, p *pos, v *vel) {
+		p.X += v.X
+		p.Y += v.Y
+	})
+	if p, _ := Get[pos](w, e); *p != (pos{3, 4}) {
+		t.Errorf("pos after query = %v; want {3 4}", *p)
+	}
+}
+
+const benchEntities = 10000
+
+// BenchmarkQuery1 and BenchmarkQuery2 are best compared with
+// BenchmarkSliceLoop, which does the same work on a plain slice.
+
+func benchWorld(b *testing.B) *World {
+	w := NewWorld()
+	for i := 0; i < benchEntities; i++ {
+		e := w.Spawn()
+		Add(w, e, pos{float64(i), 0})
+		Add(w, e, vel{1, 1})
+	}
+	b.ResetTimer()
+	return w
+}
+
+func BenchmarkQuery1(b *testing.B) {
+	w := benchWorld(b)
+	q := NewQuery1[pos](w)
+	for i := 0; i < b.N; i++ {
+		q.Each(func(_ Entity, p *pos) { p.X++ })
+	}
+}
+
+func BenchmarkQuery2(b *testing.B) {
+	w := benchWorld(b)
+	q := NewQuery2[pos, vel](w)
+	for i := 0; i < b.N; i++ {
+		q.Each(func(_ Entity, p *pos, v *vel) {
+			p.X += v.X
+			p.Y += v.Y
+		})
+	}
+}
+
+func BenchmarkQuery2Sparse(b *testing.B) {
+	w := NewWorld()
+	for i := 0; i < benchEntities; i++ {
+		e := w.Spawn()
+		Add(w, e, pos{float64(i), 0})
+		if i%10 == 0 {
+			Add(w, e, vel{1, 1})
+		}
+	}
+	q := NewQuery2[pos, vel](w)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q.Each(func(_ Entity, p *pos, v *vel) {
+			p.X += v.X
+			p.Y += v.Y
+		})
+	}
+}
+
+func BenchmarkQuery3(b *testing.B) {
+	w := benchWorld(b)
+	NewQuery1[pos](w).Each(func(e Entity, _ *pos) { Add(w, e, tag{}) })
+	q := NewQuery3[pos, vel, tag](w)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q.Each(func(_ Entity, p *pos, v *vel, _ *tag) {
+			p.X += v.X
+			p.Y += v.Y
+		})
+	}
+}
+
+func BenchmarkSliceLoop(b *testing.B) {
+	type body struct {
+		p pos
+		v vel
+	}
+	bodies := make([]body, benchEntities)
+	for i := range bodies {
+		bodies[i] = body{pos{float64(i), 0}, vel{1, 1}}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range bodies {
+			p := &bodies[j]
+			p.p.X += p.v.X
+			p.p.Y += p.v.Y
+		}
+	}
+}
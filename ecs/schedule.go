@@ -0,0 +1,208 @@
+package ecs
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+
+	"github.com/jncornett/bit"
+)
+
+// System updates a world for one tick. Changes that cannot be made while a
+// query is iterating are recorded in c.
+type System func(t bit.Tick, w *World, c *Commands)
+
+// Access declares that a system reads or writes values of a type. The type
+// is usually a component, but may be any type standing for a resource that
+// systems share, such as *physics.World.
+type Access struct {
+	typ      reflect.Type
+	write    bool
+	register func(w *World)
+}
+
+func Reads[T any]() Access  { return access[T](false) }
+func Writes[T any]() Access { return access[T](true) }
+
+func access[T any](write bool) Access {
+	return Access{
+		typ:      reflect.TypeOf((*T)(nil)).Elem(),
+		write:    write,
+		register: func(w *World) { storageOf[T](w) },
+	}
+}
+
+func (a Access) conflicts(b Access) bool {
+	return a.typ == b.typ && (a.write || b.write)
+}
+
+// Schedule runs systems in the order they were added, except that systems
+// declaring access which does not conflict may run concurrently.
+//
+// A system that declares its access may only use queries and Get, Has and
+// Count on the types it declares, and must record every other change in its
+// Commands. A system that declares no access runs alone, and may do
+// anything.
+//
+// Commands are applied after each stage of systems that run together, not
+// after each system. A system alone in its stage sees the commands of every
+// system before it, but systems sharing a stage do not see each other's, so
+// entities one of them spawns or despawns only appear or disappear for the
+// next stage. A system whose commands change components of a type should
+// declare it written, so that later systems reading it see the changes.
+type Schedule struct {
+	// Workers is the most systems that run at once. Zero is GOMAXPROCS.
+	Workers int
+
+	systems []*scheduled
+	// stages groups the systems into runs of systems that do not conflict,
+	// in order. It is nil when systems have been added since it was built.
+	stages [][]*scheduled
+}
+
+type scheduled struct {
+	name     string
+	run      System
+	access   []Access
+	commands Commands
+	metric   bit.DurationMetric
+}
+
+// SystemMetrics are the durations of a system's runs.
+type SystemMetrics struct {
+	Name     string
+	Duration bit.DurationMetric
+}
+
+func NewSchedule() *Schedule { return &Schedule{} }
+
+// Add adds a system which accesses only the given types. With no access
+// given, the system is exclusive.
+func (s *Schedule) Add(name string, system System, access ...Access) *Schedule {
+	s.systems = append(s.systems, &scheduled{name: name, run: system, access: access})
+	s.stages = nil
+	return s
+}
+
+func (a *scheduled) exclusive() bool { return len(a.access) == 0 }
+
+func (a *scheduled) conflicts(b *scheduled) bool {
+	if a.exclusive() || b.exclusive() {
+		return true
+	}
+	for _, x := range a.access {
+		for _, y := range b.access {
+			if x.conflicts(y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// plan puts each system in the stage after the last earlier system it
+// conflicts with.
+func (s *Schedule) plan() [][]*scheduled {
+	if s.stages != nil {
+		return s.stages
+	}
+	stage := make([]int, len(s.systems))
+	for i, a := range s.systems {
+		for j, b := range s.systems[:i] {
+			if stage[j] >= stage[i] && a.conflicts(b) {
+				stage[i] = stage[j] + 1
+			}
+		}
+		for len(s.stages) <= stage[i] {
+			s.stages = append(s.stages, nil)
+		}
+		s.stages[stage[i]] = append(s.stages[stage[i]], a)
+	}
+	return s.stages
+}
+
+// Run runs every system once. The commands recorded during a stage are
+// applied after it, in the order their systems were added, so the result
+// does not depend on which systems finished first or on Workers.
+func (s *Schedule) Run(t bit.Tick, w *World) {
+	for _, stage := range s.plan() {
+		if len(stage) == 1 {
+			s.runOne(stage[0], t, w)
+		} else {
+			s.runStage(stage, t, w)
+		}
+		for _, sys := range stage {
+			sys.commands.Apply(w)
+		}
+	}
+}
+
+func (s *Schedule) runOne(sys *scheduled, t bit.Tick, w *World) {
+	bit.WithDurationMetric(&sys.metric, func() { sys.run(t, w, &sys.commands) })
+}
+
+func (s *Schedule) runStage(stage []*scheduled, t bit.Tick, w *World) {
+	// Make sure every storage the systems use exists, since creating one
+	// while they run would race.
+	for _, sys := range stage {
+		for _, a := range sys.access {
+			a.register(w)
+		}
+	}
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(stage) {
+		workers = len(stage)
+	}
+	jobs := make(chan *scheduled)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		panicked interface{}
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sys := range jobs {
+				func() {
+					defer func() {
+						if r := recover(); r != nil {
+							once.Do(func() { panicked = r })
+						}
+					}()
+					s.runOne(sys, t, w)
+				}()
+			}
+		}()
+	}
+	for _, sys := range stage {
+		jobs <- sys
+	}
+	close(jobs)
+	wg.Wait()
+	if panicked != nil {
+		panic(panicked)
+	}
+}
+
+// Metrics returns the metrics of every system, in the order they were added.
+// It may be called while the schedule runs.
+func (s *Schedule) Metrics() []SystemMetrics {
+	out := make([]SystemMetrics, len(s.systems))
+	for i, sys := range s.systems {
+		out[i] = SystemMetrics{sys.name, sys.metric.Load()}
+	}
+	return out
+}
+
+// Update returns an update function for an App whose game state is a world.
+// It runs the schedule and then renders the world.
+func Update[R any](s *Schedule, render func(w *World) R) func(bit.Tick, *World) (*World, R) {
+	return func(t bit.Tick, w *World) (*World, R) {
+		s.Run(t, w)
+		return w, render(w)
+	}
+}
@@ -0,0 +1,202 @@
+package ecs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jncornett/bit"
+)
+
+func stageNames(s *Schedule) [][]string {
+	var names [][]string
+	for _, stage := range s.plan() {
+		var ns []string
+		for _, sys := range stage {
+			ns = append(ns, sys.name)
+		}
+		names = append(names, ns)
+	}
+	return names
+}
+
+// movers returns a world of n entities with positions and velocities, half
+// of them tagged.
+func movers(n int) *World {
+	w := NewWorld()
+	for i := 0; i < n; i++ {
+		e := w.Spawn()
+		Add(w, e, pos{float64(i), 0})
+		Add(w, e, vel{1, 2})
+		if i%2 == 0 {
+			Add(w, e, tag{})
+		}
+	}
+	return w
+}
+
+func TestSchedulePlan(t *testing.T) {
+	nop := func(bit.Tick, *World, *Commands) {}
+	s := NewSchedule().
+		Add("move", nop, Writes[pos](), Reads[vel]()).
+		Add("sum", nop, Reads[vel]()).
+		Add("tags", nop, Writes[tag]()).
+		Add("accel", nop, Writes[vel]()).
+		Add("draw", nop, Reads[pos]()).
+		Add("all", nop).
+		Add("draw2", nop, Reads[pos]())
+	want := [][]string{{"move", "sum", "tags"}, {"accel", "draw"}, {"all"}, {"draw2"}}
+	if got := stageNames(s); !reflect.DeepEqual(got, want) {
+		t.Errorf("stages = %v; want %v", got, want)
+	}
+	s.Add("tags2", nop, Reads[tag]())
+	want[3] = append(want[3], "tags2")
+	if got := stageNames(s); !reflect.DeepEqual(got, want) {
+		t.Errorf("stages after Add = %v; want %v", got, want)
+	}
+}
+
+// TestScheduleRace runs conflicting and non-conflicting systems together
+// many times; run it with -race.
+func TestScheduleRace(t *testing.T) {
+	w := movers(200)
+	var sums [2]float64
+	s := &Schedule{Workers: 4}
+	s.Add("move", func(_ bit.Tick, w *World, _ *Commands) {
+		NewQuery2[pos, vel](w).Each(func(_ Entity, p *pos, v *vel) {
+			p.X += v.X
+			p.Y += v.Y
+		})
+	}, Writes[pos](), Reads[vel]())
+	s.Add("sum", func(_ bit.Tick, w *World, _ *Commands) {
+		sums[0] = 0
+		NewQuery1[vel](w).Each(func(_ Entity, v *vel) { sums[0] += v.X })
+	}, Reads[vel]())
+	s.Add("count", func(_ bit.Tick, w *World, _ *Commands) {
+		sums[1] = float64(Count[tag](w))
+	}, Reads[tag]())
+	s.Add("accel", func(_ bit.Tick, w *World, _ *Commands) {
+		NewQuery1[vel](w).Each(func(_ Entity, v *vel) { v.X++ })
+	}, Writes[vel]())
+	s.Add("double", func(_ bit.Tick, w *World, _ *Commands) {
+		NewQuery1[pos](w).Each(func(_ Entity, p *pos) { p.Y *= 2 })
+	}, Writes[pos]())
+
+	serial := movers(200)
+	var want [2]float64
+	for i := 0; i < 50; i++ {
+		s.Run(bit.Tick{}, w)
+
+		// The same updates, one after another.
+		NewQuery2[pos, vel](serial).Each(func(_ Entity, p *pos, v *vel) {
+			p.X += v.X
+			p.Y += v.Y
+		})
+		want[0] = 0
+		NewQuery1[vel](serial).Each(func(_ Entity, v *vel) { want[0] += v.X })
+		want[1] = float64(Count[tag](serial))
+		NewQuery1[vel](serial).Each(func(_ Entity, v *vel) { v.X++ })
+		NewQuery1[pos](serial).Each(func(_ Entity, p *pos) { p.Y *= 2 })
+	}
+	if sums != want {
+		t.Errorf("sums = %v; want %v", sums, want)
+	}
+	NewQuery2[pos, vel](serial).Each(func(e Entity, p *pos, v *vel) {
+		gp, _ := Get[pos](w, e)
+		gv, _ := Get[vel](w, e)
+		if *gp != *p || *gv != *v {
+			t.Errorf("entity %v = %v, %v; want %v, %v", e, *gp, *gv, *p, *v)
+		}
+	})
+}
+
+func TestScheduleConcurrent(t *testing.T) {
+	a, b := make(chan struct{}), make(chan struct{})
+	meet := func(mine, other chan struct{}) System {
+		return func(bit.Tick, *World, *Commands) {
+			close(mine)
+			select {
+			case <-other:
+			case <-time.After(5 * time.Second):
+				t.Error("non-conflicting systems did not run concurrently")
+			}
+		}
+	}
+	s := &Schedule{Workers: 2}
+	s.Add("a", meet(a, b), Writes[pos]()).Add("b", meet(b, a), Writes[vel]())
+	s.Run(bit.Tick{}, NewWorld())
+}
+
+func TestScheduleCommands(t *testing.T) {
+	w := NewWorld()
+	var seen []int
+	counter := func(i int) System {
+		return func(_ bit.Tick, w *World, _ *Commands) {
+			seen[i] = w.Len()
+		}
+	}
+	spawner := func(delay time.Duration) System {
+		return func(_ bit.Tick, w *World, c *Commands) {
+			time.Sleep(delay)
+			c.Spawn(func(w *World, e Entity) { Add(w, e, pos{X: float64(w.Len())}) })
+		}
+	}
+	seen = make([]int, 3)
+	s := &Schedule{Workers: 4}
+	// The first spawner finishes last, but its commands still apply first.
+	s.Add("spawn1", spawner(20*time.Millisecond), Writes[pos]()).
+		Add("before", counter(0), Reads[tag]()).
+		Add("spawn2", spawner(0), Writes[vel]()).
+		Add("alone", counter(1)).
+		Add("after", counter(2), Reads[tag]())
+	if got, want := stageNames(s), [][]string{{"spawn1", "before", "spawn2"}, {"alone"}, {"after"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("stages = %v; want %v", got, want)
+	}
+	s.Run(bit.Tick{}, w)
+
+	// "before" shares the first stage with the spawners, so it sees neither
+	// entity; "alone" and "after" run in later stages and see both.
+	if want := []int{0, 2, 2}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("entities seen = %v; want %v", seen, want)
+	}
+	var xs []float64
+	NewQuery1[pos](w).Each(func(_ Entity, p *pos) { xs = append(xs, p.X) })
+	if want := []float64{1, 2}; !reflect.DeepEqual(xs, want) {
+		t.Errorf("spawned in order %v; want %v", xs, want)
+	}
+}
+
+func TestScheduleSerialCommands(t *testing.T) {
+	w := NewWorld()
+	var seen int
+	s := NewSchedule().
+		Add("spawn", func(_ bit.Tick, _ *World, c *Commands) { c.Spawn(nil) }, Writes[pos]()).
+		Add("count", func(_ bit.Tick, w *World, _ *Commands) { seen = w.Len() }, Reads[pos]())
+	s.Run(bit.Tick{}, w)
+	if seen != 1 {
+		t.Errorf("a system in the next stage saw %d entities; want 1", seen)
+	}
+}
+
+func TestSchedulePanic(t *testing.T) {
+	s := &Schedule{Workers: 2}
+	s.Add("ok", func(bit.Tick, *World, *Commands) {}, Reads[pos]()).
+		Add("boom", func(bit.Tick, *World, *Commands) { panic("boom") }, Reads[vel]())
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recovered %v; want boom", r)
+		}
+	}()
+	s.Run(bit.Tick{}, NewWorld())
+}
+
+func TestScheduleMetrics(t *testing.T) {
+	s := NewSchedule().
+		Add("a", func(bit.Tick, *World, *Commands) {}).
+		Add("b", func(bit.Tick, *World, *Commands) {})
+	s.Run(bit.Tick{}, NewWorld())
+	m := s.Metrics()
+	if len(m) != 2 || m[0].Name != "a" || m[1].Name != "b" {
+		t.Errorf("Metrics() = %+v; want a and b", m)
+	}
+}
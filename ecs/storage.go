@@ -0,0 +1,78 @@
+package ecs
+
+import "reflect"
+
+type storage interface {
+	remove(e Entity)
+	clone() storage
+	typ() reflect.Type
+}
+
+// Storage is a sparse set holding the components of one type. Components are
+// packed together in Dense order, which is the order queries visit them in.
+type Storage[T any] struct {
+	// sparse maps an entity index to its position in dense, plus one.
+	sparse []int32
+	dense  []Entity
+	data   []T
+}
+
+func (s *Storage[T]) typ() reflect.Type { return reflect.TypeOf((*T)(nil)).Elem() }
+
+// find returns the position of e in dense, or -1.
+func (s *Storage[T]) find(e Entity) int {
+	i := e.Index()
+	if int(i) >= len(s.sparse) {
+		return -1
+	}
+	d := int(s.sparse[i]) - 1
+	if d < 0 || s.dense[d] != e {
+		return -1
+	}
+	return d
+}
+
+func (s *Storage[T]) get(e Entity) (*T, bool) {
+	if d := s.find(e); d >= 0 {
+		return &s.data[d], true
+	}
+	return nil, false
+}
+
+func (s *Storage[T]) set(e Entity, v T) {
+	if p, ok := s.get(e); ok {
+		*p = v
+		return
+	}
+	i := int(e.Index())
+	for len(s.sparse) <= i {
+		s.sparse = append(s.sparse, 0)
+	}
+	// A stale entry from an earlier generation is simply overwritten.
+	s.dense = append(s.dense, e)
+	s.data = append(s.data, v)
+	s.sparse[i] = int32(len(s.dense))
+}
+
+func (s *Storage[T]) remove(e Entity) {
+	if _, ok := s.get(e); !ok {
+		return
+	}
+	d := s.sparse[e.Index()] - 1
+	last := len(s.dense) - 1
+	moved := s.dense[last]
+	s.dense[d], s.data[d] = moved, s.data[last]
+	s.sparse[moved.Index()] = d + 1
+	s.sparse[e.Index()] = 0
+	var zero T
+	s.data[last] = zero
+	s.dense, s.data = s.dense[:last], s.data[:last]
+}
+
+func (s *Storage[T]) clone() storage {
+	return &Storage[T]{
+		sparse: append([]int32(nil), s.sparse...),
+		dense:  append([]Entity(nil), s.dense...),
+		data:   append([]T(nil), s.data...),
+	}
+}
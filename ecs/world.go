@@ -0,0 +1,126 @@
+// Package ecs stores game entities as components in sparse sets, and runs
+// systems over them with typed queries.
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Entity identifies an entity. Its low 32 bits are an index, which is reused
+// once the entity is despawned, and its high 32 bits are a generation, which
+// is not. The zero Entity is never alive.
+type Entity uint64
+
+func makeEntity(index, gen uint32) Entity { return Entity(gen)<<32 | Entity(index) }
+
+func (e Entity) Index() uint32      { return uint32(e) }
+func (e Entity) Generation() uint32 { return uint32(e >> 32) }
+
+func (e Entity) String() string {
+	return fmt.Sprintf("%d.%d", e.Index(), e.Generation())
+}
+
+// World holds entities and their components.
+type World struct {
+	gens     []uint32
+	free     []uint32
+	alive    int
+	storages map[reflect.Type]storage
+	// order lists storages in the order they were created, so that
+	// despawning is deterministic.
+	order []storage
+}
+
+func NewWorld() *World {
+	return &World{storages: make(map[reflect.Type]storage)}
+}
+
+// Spawn creates an entity without components. It is safe to call while
+// iterating a query.
+func (w *World) Spawn() Entity {
+	w.alive++
+	if n := len(w.free); n > 0 {
+		i := w.free[n-1]
+		w.free = w.free[:n-1]
+		return makeEntity(i, w.gens[i])
+	}
+	w.gens = append(w.gens, 1)
+	return makeEntity(uint32(len(w.gens)-1), 1)
+}
+
+// Despawn removes an entity and all of its components.
+func (w *World) Despawn(e Entity) {
+	if !w.Alive(e) {
+		return
+	}
+	for _, s := range w.order {
+		s.remove(e)
+	}
+	w.gens[e.Index()]++
+	w.free = append(w.free, e.Index())
+	w.alive--
+}
+
+func (w *World) Alive(e Entity) bool {
+	i := e.Index()
+	return int(i) < len(w.gens) && w.gens[i] == e.Generation()
+}
+
+// Len returns the number of live entities.
+func (w *World) Len() int { return w.alive }
+
+// Clone returns a copy of the world. Components are copied by assignment, so
+// pointers and slices inside them are shared.
+func (w *World) Clone() *World {
+	c := &World{
+		gens:     append([]uint32(nil), w.gens...),
+		free:     append([]uint32(nil), w.free...),
+		alive:    w.alive,
+		storages: make(map[reflect.Type]storage, len(w.storages)),
+	}
+	for _, s := range w.order {
+		s := s.clone()
+		c.storages[s.typ()] = s
+		c.order = append(c.order, s)
+	}
+	return c
+}
+
+// Add sets the component of type T of an entity, replacing any it had.
+func Add[T any](w *World, e Entity, v T) {
+	if !w.Alive(e) {
+		panic(fmt.Sprintf("ecs: add %T to dead entity %v", v, e))
+	}
+	storageOf[T](w).set(e, v)
+}
+
+// Get returns a pointer to the component of type T of an entity. The pointer
+// is only valid until components of type T are next added or removed.
+func Get[T any](w *World, e Entity) (*T, bool) {
+	return storageOf[T](w).get(e)
+}
+
+func Has[T any](w *World, e Entity) bool {
+	_, ok := Get[T](w, e)
+	return ok
+}
+
+// Remove removes the component of type T of an entity, if it has one.
+func Remove[T any](w *World, e Entity) {
+	storageOf[T](w).remove(e)
+}
+
+// Count returns the number of entities with a component of type T.
+func Count[T any](w *World) int { return len(storageOf[T](w).dense) }
+
+func storageOf[T any](w *World) *Storage[T] {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if s, ok := w.storages[t]; ok {
+		return s.(*Storage[T])
+	}
+	s := &Storage[T]{}
+	w.storages[t] = s
+	w.order = append(w.order, s)
+	return s
+}
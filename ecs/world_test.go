@@ -0,0 +1,115 @@
+package ecs
+
+import "testing"
+
+type pos struct{ X, Y float64 }
+type vel struct{ X, Y float64 }
+type tag struct{}
+
+func TestSpawnDespawn(t *testing.T) {
+	w := NewWorld()
+	a, b := w.Spawn(), w.Spawn()
+	if a == 0 || b == 0 || a == b {
+		t.Fatalf("Spawn() = %v, %v; want distinct nonzero entities", a, b)
+	}
+	if w.Len() != 2 || !w.Alive(a) || !w.Alive(b) {
+		t.Fatalf("Len() = %d, Alive = %v, %v; want 2, true, true", w.Len(), w.Alive(a), w.Alive(b))
+	}
+	if w.Alive(0) {
+		t.Errorf("Alive(0) = true")
+	}
+
+	w.Despawn(a)
+	w.Despawn(a) // a second despawn does nothing
+	if w.Len() != 1 || w.Alive(a) {
+		t.Fatalf("after Despawn: Len() = %d, Alive(a) = %v; want 1, false", w.Len(), w.Alive(a))
+	}
+
+	c := w.Spawn()
+	if c.Index() != a.Index() || c.Generation() != a.Generation()+1 {
+		t.Errorf("Spawn() after Despawn(%v) = %v; want index reused with next generation", a, c)
+	}
+	if w.Alive(a) || !w.Alive(c) {
+		t.Errorf("Alive(stale) = %v, Alive(new) = %v; want false, true", w.Alive(a), w.Alive(c))
+	}
+	w.Despawn(a) // stale entities must not despawn their successor
+	if !w.Alive(c) {
+		t.Errorf("Despawn of a stale entity despawned %v", c)
+	}
+}
+
+func TestComponents(t *testing.T) {
+	w := NewWorld()
+	a, b := w.Spawn(), w.Spawn()
+	Add(w, a, pos{1, 2})
+	Add(w, b, pos{3, 4})
+	Add(w, b, vel{1, 0})
+
+	if p, ok := Get[pos](w, a); !ok || *p != (pos{1, 2}) {
+		t.Errorf("Get[pos](a) = %v, %v", p, ok)
+	}
+	if Has[vel](w, a) || !Has[vel](w, b) {
+		t.Errorf("Has[vel] = %v, %v; want false, true", Has[vel](w, a), Has[vel](w, b))
+	}
+	Add(w, a, pos{5, 6})
+	if p, _ := Get[pos](w, a); *p != (pos{5, 6}) || Count[pos](w) != 2 {
+		t.Errorf("Add replacing: Get = %v, Count = %d", *p, Count[pos](w))
+	}
+
+	// Removing a moves the last component into its place.
+	Remove[pos](w, a)
+	Remove[pos](w, a)
+	if Has[pos](w, a) || Count[pos](w) != 1 {
+		t.Errorf("after Remove: Has = %v, Count = %d", Has[pos](w, a), Count[pos](w))
+	}
+	if p, ok := Get[pos](w, b); !ok || *p != (pos{3, 4}) {
+		t.Errorf("Get[pos](b) after removing a = %v, %v", p, ok)
+	}
+
+	w.Despawn(b)
+	if Count[pos](w) != 0 || Count[vel](w) != 0 {
+		t.Errorf("Despawn left components: pos %d, vel %d", Count[pos](w), Count[vel](w))
+	}
+	c := w.Spawn() // reuses b's index
+	if Has[pos](w, c) || Has[vel](w, c) {
+		t.Errorf("reused entity %v has components of %v", c, b)
+	}
+	if _, ok := Get[pos](w, b); ok {
+		t.Errorf("Get on a despawned entity succeeded")
+	}
+}
+
+func TestAddDeadPanics(t *testing.T) {
+	w := NewWorld()
+	e := w.Spawn()
+	w.Despawn(e)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Add to a dead entity did not panic")
+		}
+	}()
+	Add(w, e, tag{})
+}
+
+func TestClone(t *testing.T) {
+	w := NewWorld()
+	e := w.Spawn()
+	Add(w, e, pos{1, 1})
+	c := w.Clone()
+
+	p, _ := Get[pos](w, e)
+	p.X = 9
+	w.Despawn(w.Spawn())
+	f := c.Spawn()
+	Add(c, f, vel{})
+
+	if p, ok := Get[pos](c, e); !ok || *p != (pos{1, 1}) {
+		t.Errorf("clone's component = %v, %v; want unchanged", p, ok)
+	}
+	if c.Len() != 2 || w.Len() != 1 {
+		t.Errorf("Len() = %d, clone %d; want 1, 2", w.Len(), c.Len())
+	}
+	if Count[vel](w) != 0 {
+		t.Errorf("adding to the clone changed the world")
+	}
+}
@@ -16,57 +16,266 @@ type Engine[GameState, RenderState any] struct {
 	StartDraw  func(ReadBuffer) error
 	Size       image.Point
 	Metrics    EngineMetrics
+
+	// UpdateInput, if set, is called instead of Update with a snapshot of the
+	// input received from Events.
+	UpdateInput func(Tick, Input, GameState) (GameState, RenderState)
+
+	// FixedStep, if positive, decouples Update from StartClock: real time is
+	// accumulated and Update is called zero or more times per tick, each time
+	// with a Tick advanced by exactly FixedStep.
+	FixedStep time.Duration
+	// MaxSteps caps the number of fixed steps run for a single tick.
+	// Time beyond the cap is dropped. Defaults to DefaultMaxSteps.
+	MaxSteps int
+	// Interpolate, if set, blends the render states of the last two fixed
+	// steps before they are rendered. Update must not reuse the memory of a
+	// RenderState it has returned for this to be meaningful.
+	Interpolate func(prev, curr RenderState, alpha float64) RenderState
+
+	// Events, if set, is batched with the ticks from StartClock into frames.
+	Events <-chan Event
+	// StartFrames, if set, replaces StartClock and Events as the source of
+	// frames. See Replay.StartFrames.
+	StartFrames func() (frames <-chan Frame, stop func())
+	// Recorder, if set, records every frame, along with its state hash if
+	// Hash is set.
+	Recorder *Recorder
+	// Replay, if set, replaces StartClock and Events with its recorded
+	// frames. If Hash is also set, Run fails with a *DivergenceError as soon
+	// as the game state stops matching the recording.
+	Replay *Replay
+	// Hash hashes the game state after each frame, e.g. HashJSON.
+	Hash func(GameState) (uint64, error)
+	// Control, if set, pauses, steps and scales the time of the game. It
+	// cannot be set with Recorder or Replay.
+	Control *Control
+	// Debugger, if set, lets hotkeys pause, step and rewind the game. It
+	// cannot be set with Recorder or Replay.
+	Debugger *Debugger[GameState, RenderState]
+	// Stopped, if set, is called with the last game state when Run stops.
+	// An error it returns is returned by Run.
+	Stopped func(GameState) error
+}
+
+const DefaultMaxSteps = 5
+
+// measure runs f, recording how long it took in m and w.
+func measure(m *DurationMetric, w *WindowMetric, f func()) {
+	start := time.Now()
+	f()
+	end := time.Now()
+	m.PutEvent(end.Sub(start))
+	w.PutEventAt(end, end.Sub(start))
 }
 
 func (e *Engine[GameState, RenderState]) Run(ctx context.Context, initialGameState GameState) error {
+	if (e.Recorder != nil || e.Replay != nil) && (e.Control != nil || e.Debugger != nil) {
+		return ErrControlledReplay
+	}
 	db := doublebuf.New(
 		image.NewNRGBA(image.Rectangle{Max: e.Size}),
 		image.NewNRGBA(image.Rectangle{Max: e.Size}),
 	)
+	for _, w := range []**WindowMetric{&e.Metrics.UpdateWindow, &e.Metrics.RenderWindow, &e.Metrics.LoopWindow} {
+		if *w == nil {
+			*w = NewWindowMetric(DefaultMetricWindow, 0)
+		}
+	}
 	ctx, cancel := context.WithCancel(ctx)
 	done := make(chan struct{})
 	defer func() { e.Metrics.Stop = time.Now() }()
+	var runErr error
 	go func() {
 		defer close(done)
-		ticks, stop := e.StartClock()
+		frames, stop := e.startFrames()
 		defer stop()
 		gameState := initialGameState
-		for {
+		if e.Stopped != nil {
+			defer func() {
+				if err := e.Stopped(gameState); err != nil && runErr == nil {
+					runErr = err
+				}
+			}()
+		}
+		fixed := fixedStep{Step: e.FixedStep, MaxSteps: e.MaxSteps}
+		var clock gameClock
+		var lastLoop time.Time
+		control := e.Control
+		if control == nil {
+			control = &Control{}
+		}
+		var prev, curr RenderState
+		var updates int
+		var input inputState
+		update := func(t Tick, state GameState) (GameState, RenderState) {
+			if e.UpdateInput != nil {
+				return e.UpdateInput(t, input.Take(), state)
+			}
+			return e.Update(t, state)
+		}
+		for i := 0; ; i++ {
 			select {
 			case <-ctx.Done():
+				runErr = e.flush()
 				return
-			case t, ok := <-ticks:
+			case f, ok := <-frames:
 				if !ok {
+					runErr = e.flush()
 					return
 				}
 
+				events, hotkeys := f.Events, []string(nil)
+				if e.Debugger != nil {
+					events, hotkeys = e.Debugger.hotkeys(events)
+					e.Debugger.control(hotkeys, control)
+				}
+				paused, steps, timeScale := control.take()
+				if e.Debugger != nil && (!paused || steps > 0) {
+					e.Debugger.rewind(&gameState, &curr, &input)
+				}
+				// Events are applied after rewinding, so that they are not
+				// lost with the input of the frames forgotten.
+				input.Apply(events)
+				step := func(t Tick) {
+					prev = curr
+					measure(&e.Metrics.Update, e.Metrics.UpdateWindow, func() {
+						gameState, curr = update(t, gameState)
+					})
+					updates++
+					if e.Debugger != nil {
+						e.Debugger.record(gameState, curr, input.held())
+					}
+				}
+				d := scale(f.Tick.Delta(), timeScale)
+
 				var renderState RenderState
-				WithDurationMetric(&e.Metrics.Update, func() {
-					gameState, renderState = e.Update(t, gameState)
-				})
+				switch {
+				case paused:
+					clock.pass(f.Tick)
+					if fixed.Step > 0 {
+						fixed.start(f.Tick)
+					}
+					for j := 0; j < steps; j++ {
+						if fixed.Step > 0 {
+							step(fixed.Next())
+						} else {
+							step(clock.next(f.Tick, d))
+						}
+					}
+					renderState = curr
+					if e.Debugger != nil {
+						renderState = e.Debugger.shown(curr)
+					}
+				case fixed.Step > 0:
+					n, alpha := fixed.Advance(Tick{f.Tick[0], f.Tick[1], f.Tick[1].Add(d)})
+					for j := 0; j < n; j++ {
+						step(fixed.Next())
+					}
+					renderState = curr
+					if e.Interpolate != nil && updates > 1 {
+						renderState = e.Interpolate(prev, curr, alpha)
+					}
+				default:
+					clock.pass(f.Tick)
+					step(clock.next(f.Tick, d))
+					renderState = curr
+				}
+
+				if err := e.check(i, f, gameState); err != nil {
+					e.flush()
+					runErr = err
+					return
+				}
 
 				if buf, ok := db.TryBack(); ok { // attempt to acquire the back buffer
-					WithDurationMetric(&e.Metrics.Render, func() {
+					measure(&e.Metrics.Render, e.Metrics.RenderWindow, func() {
 						e.Render(renderState, *buf)
+						if e.Debugger != nil && (paused || timeScale != 1) {
+							e.Debugger.drawStatus(*buf, paused, timeScale)
+						}
 						db.Ready()
 					})
 				}
 
 				atomic.AddUint64(&e.Metrics.LoopCount, 1)
+				now := time.Now()
+				if !lastLoop.IsZero() {
+					e.Metrics.LoopWindow.PutEventAt(now, now.Sub(lastLoop))
+				}
+				lastLoop = now
 			}
 		}
 	}()
-	err := e.StartDraw(db)
+	err := e.StartDraw(readBuffer{db, done})
 	cancel()
 	<-done
+	if err == nil {
+		err = runErr
+	}
 	return err
 }
 
+func (e *Engine[GameState, RenderState]) startFrames() (frames <-chan Frame, stop func()) {
+	switch {
+	case e.StartFrames != nil:
+		return e.StartFrames()
+	case e.Replay != nil:
+		return e.Replay.StartFrames()
+	}
+	ticks, stop := e.StartClock()
+	return FrameClock(ticks, e.Events), stop
+}
+
+// check records frame i and verifies it against the replay, if any.
+func (e *Engine[GameState, RenderState]) check(i int, f Frame, gameState GameState) error {
+	if e.Hash == nil {
+		if e.Recorder != nil {
+			return e.Recorder.Record(f, 0, false)
+		}
+		return nil
+	}
+	if e.Recorder == nil && e.Replay == nil {
+		return nil
+	}
+	h, err := e.Hash(gameState)
+	if err != nil {
+		return err
+	}
+	if e.Recorder != nil {
+		if err := e.Recorder.Record(f, h, true); err != nil {
+			return err
+		}
+	}
+	if e.Replay != nil && i < len(e.Replay.Frames) {
+		if want := e.Replay.Frames[i]; want.Hashed && want.Hash != h {
+			return &DivergenceError{Frame: i, Want: want.Hash, Got: h}
+		}
+	}
+	return nil
+}
+
+func (e *Engine[GameState, RenderState]) flush() error {
+	if e.Recorder == nil {
+		return nil
+	}
+	return e.Recorder.Flush()
+}
+
 type ReadBuffer interface {
 	Front() *image.NRGBA
 	Next() (img *image.NRGBA, changed bool)
+	// Done is closed once the engine has stopped producing frames.
+	Done() <-chan struct{}
+}
+
+type readBuffer struct {
+	*doublebuf.DoubleBuffer[*image.NRGBA]
+	done <-chan struct{}
 }
 
+func (b readBuffer) Done() <-chan struct{} { return b.done }
+
 type FPS float64
 
 func (fps FPS) Duration() time.Duration {
@@ -77,10 +286,25 @@ type EngineMetrics struct {
 	Start, Stop    time.Time
 	LoopCount      uint64
 	Update, Render DurationMetric
+	// Recent durations of updates, renders and whole loops. They are
+	// created by MakeEngineMetrics, or by Engine.Run if missing.
+	UpdateWindow, RenderWindow, LoopWindow *WindowMetric
 }
 
 func MakeEngineMetrics(now time.Time) EngineMetrics {
-	return EngineMetrics{Start: now}
+	return EngineMetrics{
+		Start:        now,
+		UpdateWindow: NewWindowMetric(DefaultMetricWindow, 0),
+		RenderWindow: NewWindowMetric(DefaultMetricWindow, 0),
+		LoopWindow:   NewWindowMetric(DefaultMetricWindow, 0),
+	}
+}
+
+// SetBudget sets the budget of the windowed metrics, e.g. to FPS.Duration().
+func (m *EngineMetrics) SetBudget(d time.Duration) {
+	m.UpdateWindow.Budget = d
+	m.RenderWindow.Budget = d
+	m.LoopWindow.Budget = d
 }
 
 func (m *EngineMetrics) Load() EngineMetrics {
@@ -91,6 +315,10 @@ func (m *EngineMetrics) Load() EngineMetrics {
 		LoopCount: atomic.LoadUint64(&m.LoopCount),
 		Update:    m.Update.Load(),
 		Render:    m.Render.Load(),
+
+		UpdateWindow: m.UpdateWindow,
+		RenderWindow: m.RenderWindow,
+		LoopWindow:   m.LoopWindow,
 	}
 }
 
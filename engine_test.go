@@ -0,0 +1,110 @@
+package bit
+
+import (
+	"context"
+	"errors"
+	"image"
+	"io"
+	"testing"
+	"time"
+)
+
+// frames returns n frames that are 10ms apart, starting at epoch.
+func frames(n int) []Frame {
+	out := make([]Frame, n)
+	tick := NewTick(epoch)
+	for i := range out {
+		tick = tick.Step(tick[2].Add(10 * time.Millisecond))
+		out[i].Tick = tick
+	}
+	return out
+}
+
+// runFrames runs e over fs, calling before with each frame's index just
+// before the engine receives it.
+func runFrames[GameState, R any](t *testing.T, e *Engine[GameState, R], state GameState, fs []Frame, before func(i int)) {
+	t.Helper()
+	e.StartFrames = func() (<-chan Frame, func()) {
+		ch := make(chan Frame)
+		go func() {
+			defer close(ch)
+			for i, f := range fs {
+				if before != nil {
+					before(i)
+				}
+				ch <- f
+			}
+		}()
+		return ch, func() {}
+	}
+	if e.Render == nil {
+		e.Render = func(R, *image.NRGBA) {}
+	}
+	e.StartDraw = Headless{}.StartDraw
+	e.Size = image.Pt(1, 1)
+	if err := e.Run(context.Background(), state); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEngineStepWhilePausedFixed(t *testing.T) {
+	var ticks []Tick
+	ctl := &Control{}
+	ctl.Step()
+	e := &Engine[int, int]{
+		FixedStep: 5 * time.Millisecond,
+		Control:   ctl,
+		Update: func(tick Tick, n int) (int, int) {
+			ticks = append(ticks, tick)
+			return n + 1, n + 1
+		},
+	}
+	runFrames(t, e, 0, frames(2), nil)
+	if len(ticks) != 1 {
+		t.Fatalf("got %d updates, want 1", len(ticks))
+	}
+	if got := ticks[0]; got.Zero() != epoch || got.Age() != 5*time.Millisecond {
+		t.Errorf("step tick = %v, want 5ms after %v", got, epoch)
+	}
+}
+
+func TestEngineControlledReplay(t *testing.T) {
+	update := func(_ Tick, n int) (int, int) { return n + 1, n }
+	for _, tt := range []struct {
+		name string
+		set  func(e *Engine[int, int])
+	}{
+		{"record with control", func(e *Engine[int, int]) {
+			e.Recorder, _ = NewRecorder(io.Discard)
+			e.Control = &Control{}
+		}},
+		{"record with debugger", func(e *Engine[int, int]) {
+			e.Recorder, _ = NewRecorder(io.Discard)
+			e.Debugger = NewDebugger[int, int](4, func(n int) int { return n })
+		}},
+		{"replay with control", func(e *Engine[int, int]) {
+			e.Replay = &Replay{Frames: []ReplayFrame{{Frame: frames(1)[0]}}}
+			e.Control = &Control{}
+		}},
+		{"replay with debugger", func(e *Engine[int, int]) {
+			e.Replay = &Replay{}
+			e.Debugger = NewDebugger[int, int](4, func(n int) int { return n })
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := false
+			e := &Engine[int, int]{
+				Update:    update,
+				Render:    func(int, *image.NRGBA) {},
+				StartDraw: func(ReadBuffer) error { ran = true; return nil },
+			}
+			tt.set(e)
+			if err := e.Run(context.Background(), 0); !errors.Is(err, ErrControlledReplay) {
+				t.Errorf("Run() = %v; want ErrControlledReplay", err)
+			}
+			if ran {
+				t.Errorf("Run() started drawing")
+			}
+		})
+	}
+}
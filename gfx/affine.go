@@ -0,0 +1,131 @@
+package gfx
+
+import (
+	"fmt"
+	"math"
+
+	"gioui.org/f32"
+)
+
+// Affine is a 2D affine transform, a 3x2 matrix mapping (x, y) to
+// (A*x + B*y + C, D*x + E*y + F). The zero value maps everything to the
+// origin; start from Identity instead.
+type Affine struct {
+	A, B, C float64
+	D, E, F float64
+}
+
+func Identity() Affine { return Affine{A: 1, E: 1} }
+
+func Translate(v Vec) Affine { return Affine{A: 1, C: v.X, E: 1, F: v.Y} }
+
+// Rotate rotates by radians around the origin, clockwise in screen
+// coordinates where y points down.
+func Rotate(radians float64) Affine {
+	sin, cos := math.Sincos(radians)
+	return Affine{A: cos, B: -sin, D: sin, E: cos}
+}
+
+func Scale(v Vec) Affine { return Affine{A: v.X, E: v.Y} }
+
+// Shear skews x by radiansX as y increases, and y by radiansY as x increases.
+func Shear(radiansX, radiansY float64) Affine {
+	return Affine{A: 1, B: math.Tan(radiansX), D: math.Tan(radiansY), E: 1}
+}
+
+// Around returns a transform that applies m with origin as its center.
+func Around(origin Vec, m Affine) Affine {
+	return Translate(origin).Mul(m).Mul(Translate(origin.Mul(-1)))
+}
+
+// Mul returns the composition of m and n, which applies n and then m.
+func (m Affine) Mul(n Affine) Affine {
+	return Affine{
+		A: m.A*n.A + m.B*n.D,
+		B: m.A*n.B + m.B*n.E,
+		C: m.A*n.C + m.B*n.F + m.C,
+		D: m.D*n.A + m.E*n.D,
+		E: m.D*n.B + m.E*n.E,
+		F: m.D*n.C + m.E*n.F + m.F,
+	}
+}
+
+// Then returns the composition that applies m and then n, so that
+// transforms read left to right: Scale(s).Then(Rotate(r)).Then(Translate(t)).
+func (m Affine) Then(n Affine) Affine { return n.Mul(m) }
+
+func (m Affine) Det() float64 { return m.A*m.E - m.B*m.D }
+
+// Invert returns the inverse transform, or false if m is not invertible.
+func (m Affine) Invert() (Affine, bool) {
+	det := m.Det()
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return Affine{}, false
+	}
+	a, b, d, e := m.E/det, -m.B/det, -m.D/det, m.A/det
+	return Affine{
+		A: a, B: b, C: -(a*m.C + b*m.F),
+		D: d, E: e, F: -(d*m.C + e*m.F),
+	}, true
+}
+
+// Apply transforms a point.
+func (m Affine) Apply(v Vec) Vec {
+	return Vec{m.A*v.X + m.B*v.Y + m.C, m.D*v.X + m.E*v.Y + m.F}
+}
+
+// ApplyVector transforms a direction, ignoring translation.
+func (m Affine) ApplyVector(v Vec) Vec {
+	return Vec{m.A*v.X + m.B*v.Y, m.D*v.X + m.E*v.Y}
+}
+
+// Corners returns the transformed corners of r, in order around it starting
+// at r.Min.
+func (m Affine) Corners(r Rect) [4]Vec {
+	return [4]Vec{
+		m.Apply(r.Min),
+		m.Apply(Vec{r.Max.X, r.Min.Y}),
+		m.Apply(r.Max),
+		m.Apply(Vec{r.Min.X, r.Max.Y}),
+	}
+}
+
+// ApplyRect returns the bounding box of the transformed rectangle.
+func (m Affine) ApplyRect(r Rect) Rect {
+	cs := m.Corners(r)
+	b := Rect{cs[0], cs[0]}
+	for _, p := range cs[1:] {
+		b.Min, b.Max = b.Min.Min(p), b.Max.Max(p)
+	}
+	return b
+}
+
+// Translation returns the offset that m applies to the origin.
+func (m Affine) Translation() Vec { return Vec{m.C, m.F} }
+
+// AxisAligned reports whether m keeps rectangles axis aligned, that is, it
+// neither rotates nor shears.
+func (m Affine) AxisAligned() bool { return m.B == 0 && m.D == 0 }
+
+// ScaleFactor returns how much m scales areas, as a length: the square root
+// of the absolute determinant.
+func (m Affine) ScaleFactor() float64 { return math.Sqrt(math.Abs(m.Det())) }
+
+func (m Affine) F32() f32.Affine2D {
+	return f32.NewAffine2D(
+		float32(m.A), float32(m.B), float32(m.C),
+		float32(m.D), float32(m.E), float32(m.F),
+	)
+}
+
+func AffineFromF32(a f32.Affine2D) Affine {
+	sx, hx, ox, hy, sy, oy := a.Elems()
+	return Affine{
+		A: float64(sx), B: float64(hx), C: float64(ox),
+		D: float64(hy), E: float64(sy), F: float64(oy),
+	}
+}
+
+func (m Affine) String() string {
+	return fmt.Sprintf("[[%g %g %g] [%g %g %g]]", m.A, m.B, m.C, m.D, m.E, m.F)
+}
@@ -0,0 +1,114 @@
+package gfx
+
+import (
+	"math"
+	"testing"
+
+	"gioui.org/f32"
+)
+
+func affineApproxEqual(m, n Affine, eps float64) bool {
+	return math.Abs(m.A-n.A) <= eps && math.Abs(m.B-n.B) <= eps && math.Abs(m.C-n.C) <= eps &&
+		math.Abs(m.D-n.D) <= eps && math.Abs(m.E-n.E) <= eps && math.Abs(m.F-n.F) <= eps
+}
+
+func TestAffineInvert(t *testing.T) {
+	for _, m := range []Affine{
+		Identity(),
+		Translate(V(3, -7)),
+		Rotate(1.1),
+		Scale(V(2, -0.5)),
+		Shear(0.3, -0.2),
+		Around(V(10, 20), Rotate(0.5).Mul(Scale(V(3, 3)))),
+	} {
+		inv, ok := m.Invert()
+		if !ok {
+			t.Errorf("%v.Invert() failed", m)
+			continue
+		}
+		if got := m.Mul(inv); !affineApproxEqual(got, Identity(), eps) {
+			t.Errorf("%v times its inverse = %v", m, got)
+		}
+		if got := inv.Apply(m.Apply(V(5, -9))); !got.ApproxEqual(V(5, -9), eps) {
+			t.Errorf("%v inverse does not undo it: %v", m, got)
+		}
+	}
+	for _, m := range []Affine{
+		{},
+		Scale(V(0, 1)),
+		{A: 1, B: 2, C: 5, D: 2, E: 4, F: 6},
+		{A: math.NaN(), E: 1},
+		{A: math.Inf(1), E: 1},
+	} {
+		if inv, ok := m.Invert(); ok {
+			t.Errorf("%v.Invert() = %v; want it to fail", m, inv)
+		}
+	}
+}
+
+func TestAffineOrder(t *testing.T) {
+	s, r, tr := Scale(V(2, 2)), Rotate(math.Pi/2), Translate(V(10, 0))
+	p := V(1, 0)
+	// Scale to (2, 0), rotate to (0, 2), then translate to (10, 2).
+	if got := s.Then(r).Then(tr).Apply(p); !got.ApproxEqual(V(10, 2), eps) {
+		t.Errorf("Then applies in the wrong order: %v", got)
+	}
+	if got := tr.Mul(r).Mul(s).Apply(p); !got.ApproxEqual(V(10, 2), eps) {
+		t.Errorf("Mul applies in the wrong order: %v", got)
+	}
+	// Translate to (11, 0), rotate to (0, 11), then scale to (0, 22).
+	if got := s.Mul(r).Mul(tr).Apply(p); !got.ApproxEqual(V(0, 22), eps) {
+		t.Errorf("Mul applies in the wrong order: %v", got)
+	}
+	for _, pair := range [][2]Affine{{s, r}, {r, tr}, {tr, Shear(0.4, 0)}} {
+		m, n := pair[0], pair[1]
+		if got, want := m.Then(n).Apply(p), n.Apply(m.Apply(p)); !got.ApproxEqual(want, eps) {
+			t.Errorf("%v.Then(%v) maps %v to %v; want %v", m, n, p, got, want)
+		}
+		if got, want := m.Mul(n).Apply(p), m.Apply(n.Apply(p)); !got.ApproxEqual(want, eps) {
+			t.Errorf("%v.Mul(%v) maps %v to %v; want %v", m, n, p, got, want)
+		}
+	}
+}
+
+func TestAffineApplyRect(t *testing.T) {
+	r := R(0, 0, 4, 2)
+	for _, tc := range []struct {
+		name string
+		m    Affine
+		want Rect
+	}{
+		{"identity", Identity(), r},
+		{"translate", Translate(V(1, -1)), R(1, -1, 5, 1)},
+		{"flip", Scale(V(-1, 1)), R(-4, 0, 0, 2)},
+		{"quarter turn", Rotate(math.Pi / 2), R(-2, 0, 0, 4)},
+		{"eighth turn", Rotate(math.Pi / 4), R(-math.Sqrt2, 0, 2*math.Sqrt2, 3*math.Sqrt2)},
+		{"around center", Around(V(2, 1), Rotate(math.Pi)), r},
+	} {
+		got := tc.m.ApplyRect(r)
+		if !got.Min.ApproxEqual(tc.want.Min, eps) || !got.Max.ApproxEqual(tc.want.Max, eps) {
+			t.Errorf("%s: ApplyRect(%v) = %v; want %v", tc.name, r, got, tc.want)
+		}
+		for _, c := range tc.m.Corners(r) {
+			if !got.Inset(-eps).Contains(c) {
+				t.Errorf("%s: corner %v outside of %v", tc.name, c, got)
+			}
+		}
+	}
+}
+
+func TestAffineF32(t *testing.T) {
+	m := Affine{A: 1.5, B: -2, C: 30, D: 0.25, E: 4, F: -6}
+	if got := AffineFromF32(m.F32()); got != m {
+		t.Errorf("AffineFromF32(%v.F32()) = %v", m, got)
+	}
+	a := f32.Affine2D{}.Rotate(f32.Pt(3, 4), 0.5).Offset(f32.Pt(7, 8))
+	if got := AffineFromF32(a).F32(); got != a {
+		t.Errorf("AffineFromF32(%v).F32() = %v", a, got)
+	}
+	p := f32.Pt(2, -5)
+	want := a.Transform(p)
+	if got := AffineFromF32(a).Apply(V(float64(p.X), float64(p.Y))); !got.ApproxEqual(V(float64(want.X), float64(want.Y)), 1e-5) {
+		t.Errorf("AffineFromF32(%v) maps %v to %v; want %v", a, p, got, want)
+	}
+}
@@ -0,0 +1,378 @@
+package gfx
+
+import "math"
+
+// Manifold describes how two overlapping shapes touch.
+type Manifold struct {
+	// Normal is the unit direction from the first shape towards the second
+	// along which they should be pushed apart.
+	Normal Vec
+	// Depth is how far the shapes overlap along Normal.
+	Depth float64
+	// Points holds Count contact points, midway between the two surfaces.
+	Points [2]Vec
+	Count  int
+}
+
+// Collide reports whether a and b overlap and, if so, how. Shapes that only
+// touch do not collide.
+func Collide(a, b Shape) (Manifold, bool) {
+	return collide(a.hull(), b.hull())
+}
+
+// Intersects reports whether a and b overlap.
+func Intersects(a, b Shape) bool {
+	if ra, ok := a.(Rect); ok {
+		if rb, ok := b.(Rect); ok {
+			return ra.Overlaps(rb)
+		}
+	}
+	_, ok := Collide(a, b)
+	return ok
+}
+
+// SweepRect moves r by delta and returns the fraction of delta at which it
+// first touches obstacle, along with the obstacle's normal at that point. If
+// r already overlaps obstacle, t is 0 and the normal is zero. Sliding along
+// an edge that r already touches is not a hit.
+func SweepRect(r Rect, delta Vec, obstacle Rect) (t float64, normal Vec, ok bool) {
+	half := r.Size().Mul(0.5)
+	e := Rect{obstacle.Min.Sub(half), obstacle.Max.Add(half)}
+	o := r.Center()
+	if e.Min.X < o.X && o.X < e.Max.X && e.Min.Y < o.Y && o.Y < e.Max.Y {
+		return 0, Vec{}, true
+	}
+	enter, exit := math.Inf(-1), math.Inf(1)
+	axis := func(o, d, min, max float64, neg, pos Vec) bool {
+		if d == 0 {
+			return min < o && o < max
+		}
+		t0, t1, n := (min-o)/d, (max-o)/d, neg
+		if d < 0 {
+			t0, t1, n = t1, t0, pos
+		}
+		if t0 > enter {
+			enter, normal = t0, n
+		}
+		exit = math.Min(exit, t1)
+		return true
+	}
+	if !axis(o.X, delta.X, e.Min.X, e.Max.X, Vec{-1, 0}, Vec{1, 0}) ||
+		!axis(o.Y, delta.Y, e.Min.Y, e.Max.Y, Vec{0, -1}, Vec{0, 1}) {
+		return 0, Vec{}, false
+	}
+	if enter >= exit || exit <= 0 || enter < 0 || enter > 1 {
+		return 0, Vec{}, false
+	}
+	return enter, normal, true
+}
+
+// hull is a shape expressed as a convex core, rounded by a radius. The core
+// is a point, a segment or a polygon whose edge normals (e.Y, -e.X) point
+// outwards.
+type hull struct {
+	pts []Vec
+	r   float64
+}
+
+// face is a side of a core with its outward unit normal. A face with v1 equal
+// to v2 is the end cap of a segment.
+type face struct {
+	v1, v2, n Vec
+}
+
+func (h hull) faces() []face {
+	if len(h.pts) == 2 {
+		a, b := h.pts[0], h.pts[1]
+		d := b.Sub(a).Unit()
+		if d == (Vec{}) {
+			return nil
+		}
+		n := Vec{d.Y, -d.X}
+		return []face{{a, b, n}, {b, a, n.Neg()}, {b, b, d}, {a, a, d.Neg()}}
+	}
+	var fs []face
+	if len(h.pts) > 2 {
+		for i, a := range h.pts {
+			b := h.pts[(i+1)%len(h.pts)]
+			if e := b.Sub(a).Unit(); e != (Vec{}) {
+				fs = append(fs, face{a, b, Vec{e.Y, -e.X}})
+			}
+		}
+	}
+	return fs
+}
+
+// edges calls f with every edge of the core. A point is a single edge of
+// zero length.
+func edges(pts []Vec, f func(a, b Vec)) {
+	switch len(pts) {
+	case 0:
+	case 1:
+		f(pts[0], pts[0])
+	case 2:
+		f(pts[0], pts[1])
+	default:
+		for i, a := range pts {
+			f(a, pts[(i+1)%len(pts)])
+		}
+	}
+}
+
+func (h hull) bounds() Rect {
+	if len(h.pts) == 0 {
+		return Rect{}
+	}
+	b := Rect{h.pts[0], h.pts[0]}
+	for _, p := range h.pts[1:] {
+		b.Min, b.Max = b.Min.Min(p), b.Max.Max(p)
+	}
+	return b.Inset(-h.r)
+}
+
+// coreContains reports whether p is inside the core, which for points and
+// segments means on it.
+func (h hull) coreContains(p Vec) bool {
+	if len(h.pts) < 3 {
+		return false
+	}
+	for _, f := range h.faces() {
+		if f.n.Dot(p.Sub(f.v1)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// closestOnCore returns the point of the core's boundary closest to p.
+func (h hull) closestOnCore(p Vec) Vec {
+	var best Vec
+	dist := math.Inf(1)
+	edges(h.pts, func(a, b Vec) {
+		q := closestOnSegment(p, a, b)
+		if d := q.DistSquared(p); d < dist {
+			best, dist = q, d
+		}
+	})
+	return best
+}
+
+func (h hull) contains(p Vec) bool {
+	return h.coreContains(p) || h.closestOnCore(p).DistSquared(p) <= h.r*h.r
+}
+
+func (h hull) closestPoint(p Vec) Vec {
+	if h.contains(p) {
+		return p
+	}
+	q := h.closestOnCore(p)
+	return q.Add(p.Sub(q).Unit().Mul(h.r))
+}
+
+func (h hull) raycast(r Ray) (RayHit, bool) {
+	if h.contains(r.Origin) {
+		return RayHit{Point: r.Origin}, true
+	}
+	best := RayHit{T: math.Inf(1)}
+	for _, f := range h.faces() {
+		if f.v1 == f.v2 || r.Dir.Dot(f.n) >= 0 {
+			continue
+		}
+		off := f.n.Mul(h.r)
+		if t, ok := raySegment(r, f.v1.Add(off), f.v2.Add(off)); ok && t < best.T {
+			best = RayHit{T: t, Normal: f.n}
+		}
+	}
+	if h.r > 0 {
+		for _, p := range h.pts {
+			if t, ok := rayCircle(r, p, h.r); ok && t < best.T {
+				best = RayHit{T: t, Normal: r.At(t).Sub(p).Div(h.r)}
+			}
+		}
+	}
+	if math.IsInf(best.T, 1) {
+		return RayHit{}, false
+	}
+	best.Point = r.At(best.T)
+	return best, true
+}
+
+func raySegment(r Ray, a, b Vec) (float64, bool) {
+	ab := b.Sub(a)
+	den := r.Dir.Cross(ab)
+	if den == 0 {
+		return 0, false
+	}
+	ao := a.Sub(r.Origin)
+	t, s := ao.Cross(ab)/den, ao.Cross(r.Dir)/den
+	return t, t >= 0 && s >= 0 && s <= 1
+}
+
+func rayCircle(r Ray, c Vec, radius float64) (float64, bool) {
+	m := r.Origin.Sub(c)
+	a, b, cc := r.Dir.LenSquared(), m.Dot(r.Dir), m.LenSquared()-radius*radius
+	disc := b*b - a*cc
+	if a == 0 || disc < 0 {
+		return 0, false
+	}
+	t := (-b - math.Sqrt(disc)) / a
+	return t, t >= 0
+}
+
+// separation returns the face of fs that separates the core pts from it the
+// most, and by how much. It returns -1 if there are no faces.
+func separation(fs []face, pts []Vec) (sep float64, best int) {
+	sep, best = math.Inf(-1), -1
+	for i, f := range fs {
+		s := math.Inf(1)
+		for _, p := range pts {
+			s = math.Min(s, f.n.Dot(p.Sub(f.v1)))
+		}
+		if s > sep {
+			sep, best = s, i
+		}
+	}
+	return sep, best
+}
+
+// closestCores returns the closest points of two disjoint cores.
+func closestCores(a, b []Vec) (pa, pb Vec, dist float64) {
+	dist = math.Inf(1)
+	for _, p := range a {
+		edges(b, func(q1, q2 Vec) {
+			if q := closestOnSegment(p, q1, q2); q.DistSquared(p) < dist {
+				pa, pb, dist = p, q, q.DistSquared(p)
+			}
+		})
+	}
+	for _, p := range b {
+		edges(a, func(q1, q2 Vec) {
+			if q := closestOnSegment(p, q1, q2); q.DistSquared(p) < dist {
+				pa, pb, dist = q, p, q.DistSquared(p)
+			}
+		})
+	}
+	return pa, pb, math.Sqrt(dist)
+}
+
+func collide(a, b hull) (Manifold, bool) {
+	total := a.r + b.r
+	fa, fb := a.faces(), b.faces()
+	sa, ia := separation(fa, b.pts)
+	sb, ib := separation(fb, a.pts)
+	sep := math.Max(sa, sb)
+	if sep >= total {
+		return Manifold{}, false
+	}
+	if ia < 0 && ib < 0 || sep > 0 {
+		// The cores are apart, so only their rounding can overlap.
+		pa, pb, dist := closestCores(a.pts, b.pts)
+		if dist >= total {
+			return Manifold{}, false
+		}
+		if sep > 0 && sep >= dist-1e-9*(1+dist) {
+			// The closest features are a face and a vertex, so there may
+			// be a second contact point along the face.
+			return clipFaces(a, b, fa, fb, sa, sb, ia, ib)
+		}
+		n := Vec{1, 0}
+		if dist > 0 {
+			n = pb.Sub(pa).Div(dist)
+		}
+		m := Manifold{Normal: n, Depth: total - dist, Count: 1}
+		m.Points[0] = pa.Add(n.Mul(a.r)).Add(pb.Sub(n.Mul(b.r))).Mul(0.5)
+		return m, true
+	}
+	return clipFaces(a, b, fa, fb, sa, sb, ia, ib)
+}
+
+// clipFaces builds a manifold from the most separating face, clipping the
+// other core's most opposed edge against it.
+func clipFaces(a, b hull, fa, fb []face, sa, sb float64, ia, ib int) (Manifold, bool) {
+	ref, inc, f, flip := a, b, face{}, false
+	switch {
+	case ia < 0:
+		flip = true
+	case ib < 0:
+	case sb > sa+1e-9:
+		flip = true
+	case sb > sa-1e-9:
+		// Prefer a real face to the end cap of a segment.
+		flip = fa[ia].v1 == fa[ia].v2 && fb[ib].v1 != fb[ib].v2
+	}
+	if flip {
+		ref, inc, f = b, a, fb[ib]
+	} else {
+		f = fa[ia]
+	}
+	total := a.r + b.r
+
+	i1, i2 := inc.pts[0], inc.pts[0]
+	switch {
+	case len(inc.pts) == 2:
+		i1, i2 = inc.pts[0], inc.pts[1]
+	case len(inc.pts) > 2:
+		dot := math.Inf(1)
+		for _, g := range inc.faces() {
+			if d := g.n.Dot(f.n); d < dot {
+				i1, i2, dot = g.v1, g.v2, d
+			}
+		}
+	}
+
+	var pts []Vec
+	t := f.v2.Sub(f.v1)
+	switch l := t.Len(); {
+	case l == 0:
+		// The face is a segment's end cap, so its only point is v1.
+		pts = []Vec{closestOnSegment(f.v1, i1, i2)}
+	case i1 == i2:
+		pts = []Vec{i1}
+	default:
+		t = t.Div(l)
+		u1, u2 := t.Dot(i1.Sub(f.v1)), t.Dot(i2.Sub(f.v1))
+		if math.Abs(u2-u1) < 1e-9 {
+			if u1 >= 0 && u1 <= l {
+				pts = []Vec{i1, i2}
+			}
+			break
+		}
+		at := func(u float64) Vec { return i1.Lerp(i2, (u-u1)/(u2-u1)) }
+		if lo, hi := math.Max(0, math.Min(u1, u2)), math.Min(l, math.Max(u1, u2)); lo <= hi {
+			pts = []Vec{at(lo), at(hi)}
+		}
+	}
+
+	var m Manifold
+	minSep := math.Inf(1)
+	add := func(v Vec) {
+		s := f.n.Dot(v.Sub(f.v1))
+		if s >= total || m.Count == len(m.Points) {
+			return
+		}
+		minSep = math.Min(minSep, s)
+		cRef := v.Add(f.n.Mul(ref.r - s))
+		cInc := v.Sub(f.n.Mul(inc.r))
+		m.Points[m.Count] = cRef.Add(cInc).Mul(0.5)
+		m.Count++
+	}
+	for _, v := range pts {
+		add(v)
+	}
+	if m.Count == 0 {
+		// Clipping removed every point, which can happen when rounded
+		// corners touch. Fall back to the deepest point of the edge.
+		if f.n.Dot(i2.Sub(i1)) < 0 {
+			i1 = i2
+		}
+		add(i1)
+	}
+	if m.Count == 0 {
+		return Manifold{}, false
+	}
+	m.Normal, m.Depth = f.n, total-minSep
+	if flip {
+		m.Normal = m.Normal.Neg()
+	}
+	return m, true
+}
@@ -0,0 +1,232 @@
+package gfx
+
+import (
+	"math"
+	"testing"
+)
+
+const sqrt2 = math.Sqrt2
+
+func TestCollide(t *testing.T) {
+	diamond := Box(V(0, 0), V(2, 2), math.Pi/4)
+	for _, tc := range []struct {
+		name   string
+		a, b   Shape
+		ok     bool
+		normal Vec
+		depth  float64
+		points []Vec
+	}{
+		{"circles apart", Circle{V(0, 0), 1}, Circle{V(3, 0), 1}, false, Vec{}, 0, nil},
+		{"circles touching", Circle{V(0, 0), 1}, Circle{V(2, 0), 1}, false, Vec{}, 0, nil},
+		{"circles overlapping", Circle{V(0, 0), 1}, Circle{V(1.5, 0), 1}, true, V(1, 0), 0.5, []Vec{V(0.75, 0)}},
+
+		{"circle and box apart", Circle{V(0, 0), 1}, R(2, -1, 4, 1), false, Vec{}, 0, nil},
+		{"circle and box touching", Circle{V(0, -2), 1}, R(-1, -1, 1, 1), false, Vec{}, 0, nil},
+		{"circle and box overlapping", Circle{V(0, 0), 1}, R(0.5, -1, 3, 1), true, V(1, 0), 0.5, []Vec{V(0.75, 0)}},
+		{"circle inside box", Circle{V(1, 0), 1}, R(0.5, -1, 3, 1), true, V(1, 0), 1.5, []Vec{V(1.25, 0)}},
+
+		{"boxes apart", R(0, 0, 2, 2), R(3, 0, 5, 2), false, Vec{}, 0, nil},
+		{"boxes touching", R(0, 0, 2, 2), R(2, 0, 4, 2), false, Vec{}, 0, nil},
+		{"boxes overlapping", R(0, 0, 2, 2), R(1.5, 0.5, 3.5, 1.5), true, V(1, 0), 0.5, []Vec{V(1.75, 0.5), V(1.75, 1.5)}},
+		{"boxes stacked", R(0, 0, 4, 2), R(1, 1.75, 3, 3), true, V(0, 1), 0.25, []Vec{V(1, 1.875), V(3, 1.875)}},
+
+		{"polygon and box apart", diamond, R(2, -1, 4, 1), false, Vec{}, 0, nil},
+		{"polygon and box touching", diamond, R(sqrt2, -1, 4, 1), false, Vec{}, 0, nil},
+		{"polygon corner in box", diamond, R(1, -1, 3, 1), true, V(1, 0), sqrt2 - 1, []Vec{V((1+sqrt2)/2, 0)}},
+		{"polygons edge to edge", Box(V(0, 0), V(2, 2), 0), Box(V(1.5, 0), V(2, 2), 0), true, V(1, 0), 0.5, []Vec{V(0.75, -1), V(0.75, 1)}},
+		{"circle and polygon", Circle{V(0, 0), 1}, Polygon{[]Vec{V(0.5, -1), V(3, 0), V(0.5, 1)}}, true, V(1, 0), 0.5, []Vec{V(0.75, 0)}},
+
+		{"capsule on box", Capsule{V(0, 0), V(4, 0), 0.5}, R(1, 0.25, 3, 2), true, V(0, 1), 0.25, []Vec{V(1, 0.375), V(3, 0.375)}},
+		{"segment through box", Segment{V(-1, 1), V(3, 1)}, R(0, 0, 2, 4), true, Vec{}, 0, nil},
+	} {
+		m, ok := Collide(tc.a, tc.b)
+		if ok != tc.ok {
+			t.Errorf("%s: Collide = %v, want %v", tc.name, ok, tc.ok)
+			continue
+		}
+		if Intersects(tc.a, tc.b) != ok {
+			t.Errorf("%s: Intersects disagrees with Collide", tc.name)
+		}
+		if !ok {
+			continue
+		}
+		if math.Abs(m.Normal.Len()-1) > eps {
+			t.Errorf("%s: normal %v is not a unit vector", tc.name, m.Normal)
+		}
+		if tc.points == nil {
+			// Only check that the shapes are pushed apart along the normal.
+			if m.Depth <= 0 || m.Count == 0 {
+				t.Errorf("%s: manifold %+v", tc.name, m)
+			}
+			continue
+		}
+		if !m.Normal.ApproxEqual(tc.normal, eps) || math.Abs(m.Depth-tc.depth) > eps {
+			t.Errorf("%s: normal %v, depth %g, want %v, %g", tc.name, m.Normal, m.Depth, tc.normal, tc.depth)
+		}
+		if !samePoints(m.Points[:m.Count], tc.points) {
+			t.Errorf("%s: points %v, want %v", tc.name, m.Points[:m.Count], tc.points)
+		}
+
+		// Swapping the shapes flips the normal but not the contact.
+		r, ok := Collide(tc.b, tc.a)
+		if !ok || !r.Normal.ApproxEqual(tc.normal.Neg(), eps) || math.Abs(r.Depth-tc.depth) > eps {
+			t.Errorf("%s swapped: %+v, %v", tc.name, r, ok)
+		} else if !samePoints(r.Points[:r.Count], tc.points) {
+			t.Errorf("%s swapped: points %v, want %v", tc.name, r.Points[:r.Count], tc.points)
+		}
+
+		// Moving b by the depth along the normal separates the shapes.
+		if _, ok := Collide(tc.a, Move(tc.b, m.Normal.Mul(m.Depth+1e-6))); ok {
+			t.Errorf("%s: still colliding after resolving", tc.name)
+		}
+	}
+}
+
+func TestCollideConcentric(t *testing.T) {
+	// Any direction separates circles with the same center, but the depth
+	// and contact are still known.
+	m, ok := Collide(Circle{V(1, 1), 1}, Circle{V(1, 1), 2})
+	if !ok || math.Abs(m.Normal.Len()-1) > eps || m.Depth != 3 || m.Count != 1 {
+		t.Fatalf("Collide = %+v, %v", m, ok)
+	}
+	// Midway between the surfaces along the normal.
+	if want := V(1, 1).Add(m.Normal.Mul(-0.5)); !m.Points[0].ApproxEqual(want, eps) {
+		t.Errorf("contact %v, want %v", m.Points[0], want)
+	}
+}
+
+// samePoints reports whether got and want hold the same points in any order.
+func samePoints(got, want []Vec) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	used := make([]bool, len(want))
+next:
+	for _, g := range got {
+		for i, w := range want {
+			if !used[i] && g.ApproxEqual(w, eps) {
+				used[i] = true
+				continue next
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func TestSweepRect(t *testing.T) {
+	r := R(0, 0, 1, 1)
+	for _, tc := range []struct {
+		name     string
+		delta    Vec
+		obstacle Rect
+		ok       bool
+		t        float64
+		normal   Vec
+	}{
+		{"hit", V(4, 0), R(3, 0, 4, 1), true, 0.5, V(-1, 0)},
+		{"hit from below", V(0, -4), R(0, -3, 1, -2), true, 0.5, V(0, 1)},
+		{"diagonal", V(4, 4), R(3, 2, 5, 6), true, 0.5, V(-1, 0)},
+		{"moving away", V(-4, 0), R(3, 0, 4, 1), false, 0, Vec{}},
+		{"too short", V(1, 0), R(3, 0, 4, 1), false, 0, Vec{}},
+		{"passing by", V(4, 0), R(3, 2, 4, 3), false, 0, Vec{}},
+		{"sliding along an edge", V(4, 0), R(0, 1, 5, 2), false, 0, Vec{}},
+		{"touching and moving in", V(1, 0), R(1, 0, 2, 1), true, 0, V(-1, 0)},
+		{"touching and moving out", V(-1, 0), R(1, 0, 2, 1), false, 0, Vec{}},
+		{"already overlapping", V(4, 0), R(0.5, 0.5, 2, 2), true, 0, Vec{}},
+		{"already overlapping and still", V(0, 0), R(0.5, 0.5, 2, 2), true, 0, Vec{}},
+		{"inside a bigger obstacle", V(-1, 3), R(-5, -5, 5, 5), true, 0, Vec{}},
+	} {
+		got, n, ok := SweepRect(r, tc.delta, tc.obstacle)
+		if ok != tc.ok || math.Abs(got-tc.t) > eps || n != tc.normal {
+			t.Errorf("%s: SweepRect = %g, %v, %v, want %g, %v, %v", tc.name, got, n, ok, tc.t, tc.normal, tc.ok)
+		}
+	}
+}
+
+func TestRaycast(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		s      Shape
+		ray    Ray
+		ok     bool
+		t      float64
+		normal Vec
+	}{
+		{"box", R(0, 0, 2, 2), Ray{V(-5, 0.5), V(1, 0)}, true, 5, V(-1, 0)},
+		{"box behind", R(0, 0, 2, 2), Ray{V(-5, 0.5), V(-1, 0)}, false, 0, Vec{}},
+		{"box from inside", R(0, 0, 2, 2), Ray{V(1, 1), V(1, 0)}, true, 0, Vec{}},
+		{"circle", Circle{V(0, 0), 1}, Ray{V(-5, 0), V(2, 0)}, true, 2, V(-1, 0)},
+		{"circle missed", Circle{V(0, 0), 1}, Ray{V(-5, 2), V(1, 0)}, false, 0, Vec{}},
+		{"polygon", Box(V(0, 0), V(2, 2), math.Pi/4), Ray{V(0, -5), V(0, 1)}, true, 5 - sqrt2, V(0, -1).Rotate(math.Pi / 4)},
+		{"capsule end", Capsule{V(0, 0), V(4, 0), 1}, Ray{V(-3, 0), V(1, 0)}, true, 2, V(-1, 0)},
+	} {
+		hit, ok := tc.s.Raycast(tc.ray)
+		if ok != tc.ok {
+			t.Errorf("%s: Raycast = %v, want %v", tc.name, ok, tc.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if math.Abs(hit.T-tc.t) > eps || !hit.Point.ApproxEqual(tc.ray.At(tc.t), eps) {
+			t.Errorf("%s: hit at %g %v, want %g", tc.name, hit.T, hit.Point, tc.t)
+		}
+		// The normal of a corner hit may be either face's.
+		if !hit.Normal.ApproxEqual(tc.normal, eps) && tc.name != "polygon" {
+			t.Errorf("%s: normal %v, want %v", tc.name, hit.Normal, tc.normal)
+		}
+	}
+}
+
+func TestShapeClosestPoint(t *testing.T) {
+	for _, tc := range []struct {
+		s       Shape
+		p, want Vec
+	}{
+		{Circle{V(0, 0), 2}, V(4, 0), V(2, 0)},
+		{Circle{V(0, 0), 2}, V(1, 1), V(1, 1)},
+		{R(0, 0, 2, 2), V(3, -1), V(2, 0)},
+		{Segment{V(0, 0), V(4, 0)}, V(2, 3), V(2, 0)},
+		{Capsule{V(0, 0), V(4, 0), 1}, V(6, 0), V(5, 0)},
+		{Box(V(0, 0), V(2, 2), 0), V(0, 5), V(0, 1)},
+	} {
+		if got := tc.s.ClosestPoint(tc.p); !got.ApproxEqual(tc.want, eps) {
+			t.Errorf("%T.ClosestPoint(%v) = %v, want %v", tc.s, tc.p, got, tc.want)
+		}
+	}
+}
+
+var sinkManifold Manifold
+
+func BenchmarkCollideCircles(b *testing.B) {
+	c1, c2 := Circle{V(0, 0), 1}, Circle{V(1.5, 0), 1}
+	for i := 0; i < b.N; i++ {
+		sinkManifold, _ = Collide(c1, c2)
+	}
+}
+
+func BenchmarkCollideBoxes(b *testing.B) {
+	r1, r2 := R(0, 0, 2, 2), R(1.5, 0.5, 3.5, 1.5)
+	for i := 0; i < b.N; i++ {
+		sinkManifold, _ = Collide(r1, r2)
+	}
+}
+
+func BenchmarkCollidePolygons(b *testing.B) {
+	p1, p2 := Box(V(0, 0), V(2, 2), 0.3), Box(V(1.5, 0.2), V(2, 2), -0.2)
+	for i := 0; i < b.N; i++ {
+		sinkManifold, _ = Collide(p1, p2)
+	}
+}
+
+func BenchmarkSweepRect(b *testing.B) {
+	r, o := R(0, 0, 1, 1), R(3, 2, 5, 6)
+	var n float64
+	for i := 0; i < b.N; i++ {
+		t, _, _ := SweepRect(r, V(4, 4), o)
+		n += t
+	}
+	sinkManifold.Depth = n
+}
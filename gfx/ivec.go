@@ -0,0 +1,127 @@
+package gfx
+
+import "image"
+
+// IVec is an integer vector, for pixel and grid coordinates.
+type IVec struct{ X, Y int }
+
+func IV(x, y int) IVec { return IVec{x, y} }
+
+func FromPoint(p image.Point) IVec { return IVec{p.X, p.Y} }
+
+func (v IVec) Add(v2 IVec) IVec         { return IVec{v.X + v2.X, v.Y + v2.Y} }
+func (v IVec) Sub(v2 IVec) IVec         { return IVec{v.X - v2.X, v.Y - v2.Y} }
+func (v IVec) Mul(s int) IVec           { return IVec{v.X * s, v.Y * s} }
+func (v IVec) Div(s int) IVec           { return IVec{v.X / s, v.Y / s} }
+func (v IVec) Neg() IVec                { return IVec{-v.X, -v.Y} }
+func (v IVec) Dot(v2 IVec) int          { return v.X*v2.X + v.Y*v2.Y }
+func (v IVec) Cross(v2 IVec) int        { return v.X*v2.Y - v.Y*v2.X }
+func (v IVec) LenSquared() int          { return v.X*v.X + v.Y*v.Y }
+func (v IVec) Point() image.Point       { return image.Point{v.X, v.Y} }
+func (v IVec) Vec() Vec                 { return Vec{float64(v.X), float64(v.Y)} }
+func (v IVec) In(r IRect) bool          { return r.Contains(v) }
+func (v IVec) Min(v2 IVec) IVec         { return IVec{imin(v.X, v2.X), imin(v.Y, v2.Y)} }
+func (v IVec) Max(v2 IVec) IVec         { return IVec{imax(v.X, v2.X), imax(v.Y, v2.Y)} }
+func (v IVec) Abs() IVec                { return IVec{iabs(v.X), iabs(v.Y)} }
+func (v IVec) Clamp(min, max IVec) IVec { return v.Max(min).Min(max) }
+
+// IRect is an integer rectangle. Like image.Rectangle, it contains the points
+// with Min <= p < Max.
+type IRect struct {
+	Min, Max IVec
+}
+
+// IR returns the rectangle with corners (x0, y0) and (x1, y1), canonicalized.
+func IR(x0, y0, x1, y1 int) IRect {
+	return IRect{IVec{x0, y0}, IVec{x1, y1}}.Canon()
+}
+
+func FromRectangle(r image.Rectangle) IRect {
+	return IRect{FromPoint(r.Min), FromPoint(r.Max)}
+}
+
+func (r IRect) Rectangle() image.Rectangle { return image.Rectangle{r.Min.Point(), r.Max.Point()} }
+func (r IRect) Rect() Rect                 { return Rect{r.Min.Vec(), r.Max.Vec()} }
+func (r IRect) Size() IVec                 { return r.Max.Sub(r.Min) }
+func (r IRect) Dx() int                    { return r.Max.X - r.Min.X }
+func (r IRect) Dy() int                    { return r.Max.Y - r.Min.Y }
+func (r IRect) Area() int                  { return r.Dx() * r.Dy() }
+func (r IRect) Add(v IVec) IRect           { return IRect{r.Min.Add(v), r.Max.Add(v)} }
+func (r IRect) Sub(v IVec) IRect           { return IRect{r.Min.Sub(v), r.Max.Sub(v)} }
+func (r IRect) Canon() IRect               { return IRect{r.Min.Min(r.Max), r.Min.Max(r.Max)} }
+func (r IRect) Empty() bool                { return r.Min.X >= r.Max.X || r.Min.Y >= r.Max.Y }
+
+// Center returns the middle of r, rounded down.
+func (r IRect) Center() IVec { return r.Min.Add(r.Size().Div(2)) }
+
+// Inset shrinks r by n on every side, or grows it if n is negative. If r is
+// too small to shrink, the result is an empty rectangle at its center.
+func (r IRect) Inset(n int) IRect {
+	out := IRect{r.Min.Add(IVec{n, n}), r.Max.Sub(IVec{n, n})}
+	if out.Min.X > out.Max.X {
+		out.Min.X = (r.Min.X + r.Max.X) / 2
+		out.Max.X = out.Min.X
+	}
+	if out.Min.Y > out.Max.Y {
+		out.Min.Y = (r.Min.Y + r.Max.Y) / 2
+		out.Max.Y = out.Min.Y
+	}
+	return out
+}
+
+// Intersect returns the largest rectangle inside both r and s, or the zero
+// rectangle if they do not overlap.
+func (r IRect) Intersect(s IRect) IRect {
+	out := IRect{r.Min.Max(s.Min), r.Max.Min(s.Max)}
+	if out.Empty() {
+		return IRect{}
+	}
+	return out
+}
+
+// Union returns the smallest rectangle containing both r and s. Empty
+// rectangles are ignored.
+func (r IRect) Union(s IRect) IRect {
+	if r.Empty() {
+		return s
+	}
+	if s.Empty() {
+		return r
+	}
+	return IRect{r.Min.Min(s.Min), r.Max.Max(s.Max)}
+}
+
+func (r IRect) Overlaps(s IRect) bool { return !r.Intersect(s).Empty() }
+
+func (r IRect) Contains(p IVec) bool {
+	return r.Min.X <= p.X && p.X < r.Max.X && r.Min.Y <= p.Y && p.Y < r.Max.Y
+}
+
+// In reports whether every point of r is in s.
+func (r IRect) In(s IRect) bool {
+	if r.Empty() {
+		return true
+	}
+	return s.Min.X <= r.Min.X && r.Max.X <= s.Max.X && s.Min.Y <= r.Min.Y && r.Max.Y <= s.Max.Y
+}
+
+func imin(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func imax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func iabs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
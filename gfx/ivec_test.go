@@ -0,0 +1,84 @@
+package gfx
+
+import (
+	"image"
+	"testing"
+)
+
+func TestIRectIntersect(t *testing.T) {
+	for _, tc := range []struct{ r, s, want IRect }{
+		{IR(0, 0, 4, 4), IR(2, 2, 6, 6), IR(2, 2, 4, 4)},
+		{IR(0, 0, 4, 4), IR(4, 0, 8, 4), IRect{}},
+		{IR(0, 0, 4, 4), IRect{IV(3, 3), IV(1, 1)}, IRect{}},
+		{IR(-4, -4, 0, 0), IR(-2, -6, 2, -2), IR(-2, -4, 0, -2)},
+	} {
+		if got := tc.r.Intersect(tc.s); got != tc.want {
+			t.Errorf("%v.Intersect(%v) = %v, want %v", tc.r, tc.s, got, tc.want)
+		}
+		if got := tc.r.Overlaps(tc.s); got != !tc.want.Empty() {
+			t.Errorf("%v.Overlaps(%v) = %v", tc.r, tc.s, got)
+		}
+		// IRect agrees with image.Rectangle.
+		want := tc.r.Rectangle().Intersect(tc.s.Rectangle())
+		if got := tc.r.Intersect(tc.s).Rectangle(); got != want {
+			t.Errorf("%v.Intersect(%v) = %v, image.Rectangle says %v", tc.r, tc.s, got, want)
+		}
+	}
+}
+
+func TestIRectUnion(t *testing.T) {
+	for _, tc := range []struct{ r, s, want IRect }{
+		{IR(0, 0, 1, 1), IR(2, 3, 4, 5), IR(0, 0, 4, 5)},
+		{IR(0, 0, 1, 1), IR(7, 7, 7, 9), IR(0, 0, 1, 1)},
+		{IRect{IV(3, 3), IV(-3, -3)}, IR(0, 0, 1, 1), IR(0, 0, 1, 1)},
+		{IRect{}, IRect{}, IRect{}},
+	} {
+		if got := tc.r.Union(tc.s); got != tc.want {
+			t.Errorf("%v.Union(%v) = %v, want %v", tc.r, tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestIRect(t *testing.T) {
+	r := IR(1, 2, 5, 8)
+	if r.Dx() != 4 || r.Dy() != 6 || r.Area() != 24 || r.Size() != IV(4, 6) || r.Center() != IV(3, 5) {
+		t.Errorf("%v: size %v, area %d, center %v", r, r.Size(), r.Area(), r.Center())
+	}
+	if got := r.Inset(3); got != (IRect{IV(3, 5), IV(3, 5)}) {
+		t.Errorf("Inset = %v", got)
+	}
+	if got := (IRect{IV(5, 8), IV(1, 2)}).Canon(); got != r {
+		t.Errorf("Canon = %v", got)
+	}
+	if !IV(1, 2).In(r) || IV(5, 2).In(r) || IV(1, 8).In(r) {
+		t.Error("In is wrong at the edges")
+	}
+	if FromRectangle(image.Rect(5, 8, 1, 2)) != r {
+		t.Error("FromRectangle does not canonicalize like image.Rect")
+	}
+	if got := IV(-3, 7).Clamp(IV(0, 0), IV(5, 5)); got != IV(0, 5) {
+		t.Errorf("Clamp = %v", got)
+	}
+	if got := IV(-3, 7).Abs(); got != IV(3, 7) {
+		t.Errorf("Abs = %v", got)
+	}
+	if got := R(-1.5, 0.5, 2.5, 3.9).IRect(); got != IR(-1, 0, 2, 3) {
+		t.Errorf("IRect = %v, want truncation towards zero", got)
+	}
+}
+
+var sinkIRect IRect
+
+func BenchmarkIRectIntersect(b *testing.B) {
+	r, s := IR(0, 0, 4, 4), IR(2, 2, 6, 6)
+	for i := 0; i < b.N; i++ {
+		sinkIRect = r.Intersect(s)
+	}
+}
+
+func BenchmarkIRectUnion(b *testing.B) {
+	r, s := IR(0, 0, 4, 4), IR(2, 2, 6, 6)
+	for i := 0; i < b.N; i++ {
+		sinkIRect = r.Union(s)
+	}
+}
@@ -21,3 +21,102 @@ func (r Rect) Add(v Vec) Rect {
 func (r Rect) Rectangle() image.Rectangle {
 	return image.Rectangle{r.Min.Point(), r.Max.Point()}
 }
+
+func (r Rect) Round() Rect {
+	return Rect{r.Min.Round(), r.Max.Round()}
+}
+
+// R returns the rectangle with corners (x0, y0) and (x1, y1), canonicalized.
+func R(x0, y0, x1, y1 float64) Rect {
+	return Rect{Vec{x0, y0}, Vec{x1, y1}}.Canon()
+}
+
+// FromCenter returns the rectangle of the given size centered on center.
+func FromCenter(center, size Vec) Rect {
+	half := size.Mul(0.5)
+	return Rect{center.Sub(half), center.Add(half)}
+}
+
+func FromImage(r image.Rectangle) Rect {
+	return Rect{
+		Vec{float64(r.Min.X), float64(r.Min.Y)},
+		Vec{float64(r.Max.X), float64(r.Max.Y)},
+	}
+}
+
+func (r Rect) Sub(v Vec) Rect { return Rect{r.Min.Sub(v), r.Max.Sub(v)} }
+
+func (r Rect) Area() float64 {
+	s := r.Size()
+	return s.X * s.Y
+}
+
+// Canon returns r with Min and Max swapped where needed so that Min <= Max.
+func (r Rect) Canon() Rect { return Rect{r.Min.Min(r.Max), r.Min.Max(r.Max)} }
+
+// Empty reports whether r contains no points.
+func (r Rect) Empty() bool { return r.Min.X >= r.Max.X || r.Min.Y >= r.Max.Y }
+
+// Inset shrinks r by n on every side, or grows it if n is negative. If r is
+// too small to shrink, the result is the empty rectangle at its center.
+func (r Rect) Inset(n float64) Rect {
+	out := Rect{r.Min.Add(Vec{n, n}), r.Max.Sub(Vec{n, n})}
+	if out.Min.X > out.Max.X {
+		out.Min.X = (r.Min.X + r.Max.X) / 2
+		out.Max.X = out.Min.X
+	}
+	if out.Min.Y > out.Max.Y {
+		out.Min.Y = (r.Min.Y + r.Max.Y) / 2
+		out.Max.Y = out.Min.Y
+	}
+	return out
+}
+
+// Intersect returns the largest rectangle inside both r and s, or the zero
+// rectangle if they do not overlap.
+func (r Rect) Intersect(s Rect) Rect {
+	out := Rect{r.Min.Max(s.Min), r.Max.Min(s.Max)}
+	if out.Empty() {
+		return Rect{}
+	}
+	return out
+}
+
+// Union returns the smallest rectangle containing both r and s. Empty
+// rectangles are ignored.
+func (r Rect) Union(s Rect) Rect {
+	if r.Empty() {
+		return s
+	}
+	if s.Empty() {
+		return r
+	}
+	return Rect{r.Min.Min(s.Min), r.Max.Max(s.Max)}
+}
+
+// Overlaps reports whether r and s have a non-empty intersection.
+func (r Rect) Overlaps(s Rect) bool {
+	return !r.Empty() && !s.Empty() &&
+		r.Min.X < s.Max.X && s.Min.X < r.Max.X &&
+		r.Min.Y < s.Max.Y && s.Min.Y < r.Max.Y
+}
+
+// Contains reports whether p is inside r. Like image.Rectangle, Min is
+// inside and Max is not.
+func (r Rect) Contains(p Vec) bool {
+	return r.Min.X <= p.X && p.X < r.Max.X && r.Min.Y <= p.Y && p.Y < r.Max.Y
+}
+
+// In reports whether every point of r is in s.
+func (r Rect) In(s Rect) bool {
+	if r.Empty() {
+		return true
+	}
+	return s.Min.X <= r.Min.X && r.Max.X <= s.Max.X && s.Min.Y <= r.Min.Y && r.Max.Y <= s.Max.Y
+}
+
+// Clamp returns the point of r closest to p, treating Max as inside.
+func (r Rect) Clamp(p Vec) Vec { return p.Clamp(r.Min, r.Max) }
+
+// IRect truncates r towards zero, like Rectangle.
+func (r Rect) IRect() IRect { return IRect{r.Min.IVec(), r.Max.IVec()} }
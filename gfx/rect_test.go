@@ -0,0 +1,125 @@
+package gfx
+
+import "testing"
+
+func TestRectIntersect(t *testing.T) {
+	for _, tc := range []struct{ r, s, want Rect }{
+		{R(0, 0, 4, 4), R(2, 2, 6, 6), R(2, 2, 4, 4)},
+		{R(0, 0, 4, 4), R(1, 1, 2, 2), R(1, 1, 2, 2)},
+		// Touching edges and disjoint rectangles do not intersect.
+		{R(0, 0, 4, 4), R(4, 0, 8, 4), Rect{}},
+		{R(0, 0, 4, 4), R(5, 5, 6, 6), Rect{}},
+		// Inverted rectangles are empty.
+		{R(0, 0, 4, 4), Rect{V(3, 3), V(1, 1)}, Rect{}},
+		{Rect{}, R(-1, -1, 1, 1), Rect{}},
+	} {
+		if got := tc.r.Intersect(tc.s); got != tc.want {
+			t.Errorf("%v.Intersect(%v) = %v, want %v", tc.r, tc.s, got, tc.want)
+		}
+		if got := tc.s.Intersect(tc.r); got != tc.want {
+			t.Errorf("%v.Intersect(%v) = %v, want %v", tc.s, tc.r, got, tc.want)
+		}
+		if got := tc.r.Overlaps(tc.s); got != !tc.want.Empty() {
+			t.Errorf("%v.Overlaps(%v) = %v", tc.r, tc.s, got)
+		}
+	}
+}
+
+func TestRectUnion(t *testing.T) {
+	for _, tc := range []struct{ r, s, want Rect }{
+		{R(0, 0, 1, 1), R(2, 3, 4, 5), R(0, 0, 4, 5)},
+		{R(0, 0, 4, 4), R(1, 1, 2, 2), R(0, 0, 4, 4)},
+		// Empty and inverted rectangles are ignored, wherever they are.
+		{R(0, 0, 1, 1), Rect{V(9, 9), V(9, 9)}, R(0, 0, 1, 1)},
+		{Rect{V(3, 3), V(-3, -3)}, R(0, 0, 1, 1), R(0, 0, 1, 1)},
+		{Rect{}, Rect{}, Rect{}},
+	} {
+		if got := tc.r.Union(tc.s); got != tc.want {
+			t.Errorf("%v.Union(%v) = %v, want %v", tc.r, tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestRectEmpty(t *testing.T) {
+	inverted := Rect{V(4, 4), V(0, 0)}
+	if !inverted.Empty() || !R(0, 0, 0, 4).Empty() || R(0, 0, 1, 1).Empty() {
+		t.Error("Empty is wrong for inverted, zero width or unit rectangles")
+	}
+	if got := inverted.Canon(); got != R(0, 0, 4, 4) {
+		t.Errorf("Canon = %v", got)
+	}
+	if !inverted.In(R(10, 10, 11, 11)) {
+		t.Error("an empty rectangle is in every rectangle")
+	}
+	if inverted.Contains(V(2, 2)) {
+		t.Error("an inverted rectangle contains no points")
+	}
+}
+
+func TestRectInset(t *testing.T) {
+	for _, tc := range []struct {
+		r    Rect
+		n    float64
+		want Rect
+	}{
+		{R(0, 0, 10, 4), 1, R(1, 1, 9, 3)},
+		{R(0, 0, 10, 4), -1, R(-1, -1, 11, 5)},
+		{R(0, 0, 10, 4), 3, Rect{V(3, 2), V(7, 2)}},
+		{R(0, 0, 10, 4), 6, Rect{V(5, 2), V(5, 2)}},
+	} {
+		if got := tc.r.Inset(tc.n); got != tc.want {
+			t.Errorf("%v.Inset(%g) = %v, want %v", tc.r, tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestRectContains(t *testing.T) {
+	r := R(0, 0, 2, 2)
+	for _, tc := range []struct {
+		p    Vec
+		want bool
+	}{
+		{V(0, 0), true},
+		{V(1.999, 1), true},
+		{V(2, 1), false},
+		{V(1, 2), false},
+		{V(-0.001, 1), false},
+	} {
+		if got := r.Contains(tc.p); got != tc.want {
+			t.Errorf("Contains(%v) = %v, want %v", tc.p, got, tc.want)
+		}
+	}
+	if got := r.Clamp(V(5, -1)); got != V(2, 0) {
+		t.Errorf("Clamp = %v, want (2, 0)", got)
+	}
+	if !R(0, 0, 2, 2).In(r) || R(0, 0, 2, 3).In(r) {
+		t.Error("In is wrong at the edges")
+	}
+}
+
+var sinkRect Rect
+
+func BenchmarkRectIntersect(b *testing.B) {
+	r, s := R(0, 0, 4, 4), R(2, 2, 6, 6)
+	for i := 0; i < b.N; i++ {
+		sinkRect = r.Intersect(s)
+	}
+}
+
+func BenchmarkRectUnion(b *testing.B) {
+	r, s := R(0, 0, 4, 4), R(2, 2, 6, 6)
+	for i := 0; i < b.N; i++ {
+		sinkRect = r.Union(s)
+	}
+}
+
+func BenchmarkRectOverlaps(b *testing.B) {
+	r, s := R(0, 0, 4, 4), R(2, 2, 6, 6)
+	n := 0
+	for i := 0; i < b.N; i++ {
+		if r.Overlaps(s) {
+			n++
+		}
+	}
+	sinkRect.Min.X = float64(n)
+}
@@ -0,0 +1,153 @@
+package gfx
+
+import "math"
+
+// Shape is a convex shape that can be tested for collision with any other
+// shape. It is implemented by Rect, Circle, Segment, Capsule and Polygon.
+type Shape interface {
+	Bounds() Rect
+	Contains(p Vec) bool
+	// ClosestPoint returns the point of the shape closest to p, which is p
+	// itself if the shape contains it.
+	ClosestPoint(p Vec) Vec
+	Raycast(r Ray) (RayHit, bool)
+	translate(d Vec) Shape
+	hull() hull
+}
+
+// Move returns s translated by d.
+func Move(s Shape, d Vec) Shape { return s.translate(d) }
+
+type Circle struct {
+	Center Vec
+	Radius float64
+}
+
+func (c Circle) Bounds() Rect                 { return c.hull().bounds() }
+func (c Circle) Contains(p Vec) bool          { return c.Center.DistSquared(p) <= c.Radius*c.Radius }
+func (c Circle) ClosestPoint(p Vec) Vec       { return c.hull().closestPoint(p) }
+func (c Circle) Raycast(r Ray) (RayHit, bool) { return c.hull().raycast(r) }
+func (c Circle) translate(d Vec) Shape        { return Circle{c.Center.Add(d), c.Radius} }
+func (c Circle) hull() hull                   { return hull{[]Vec{c.Center}, c.Radius} }
+
+// Segment is the line segment between A and B.
+type Segment struct {
+	A, B Vec
+}
+
+func (s Segment) Bounds() Rect                 { return s.hull().bounds() }
+func (s Segment) Contains(p Vec) bool          { return s.hull().contains(p) }
+func (s Segment) ClosestPoint(p Vec) Vec       { return closestOnSegment(p, s.A, s.B) }
+func (s Segment) Raycast(r Ray) (RayHit, bool) { return s.hull().raycast(r) }
+func (s Segment) translate(d Vec) Shape        { return Segment{s.A.Add(d), s.B.Add(d)} }
+func (s Segment) hull() hull                   { return hull{[]Vec{s.A, s.B}, 0} }
+
+// Ray returns the ray from A through B, for which a hit with T <= 1 lies on
+// the segment.
+func (s Segment) Ray() Ray { return Ray{s.A, s.B.Sub(s.A)} }
+
+// Capsule is the set of points within Radius of the segment from A to B.
+type Capsule struct {
+	A, B   Vec
+	Radius float64
+}
+
+func (c Capsule) Bounds() Rect                 { return c.hull().bounds() }
+func (c Capsule) Contains(p Vec) bool          { return c.hull().contains(p) }
+func (c Capsule) ClosestPoint(p Vec) Vec       { return c.hull().closestPoint(p) }
+func (c Capsule) Raycast(r Ray) (RayHit, bool) { return c.hull().raycast(r) }
+func (c Capsule) translate(d Vec) Shape        { return Capsule{c.A.Add(d), c.B.Add(d), c.Radius} }
+func (c Capsule) hull() hull                   { return hull{[]Vec{c.A, c.B}, c.Radius} }
+
+// Polygon is a convex polygon. Its points may be in either winding order.
+type Polygon struct {
+	Points []Vec
+}
+
+// Box returns the rectangle of the given size centered on center and rotated
+// by radians.
+func Box(center, size Vec, radians float64) Polygon {
+	m := Rotate(radians).Then(Translate(center))
+	cs := m.Corners(FromCenter(Vec{}, size))
+	return Polygon{cs[:]}
+}
+
+func (p Polygon) Bounds() Rect                 { return p.hull().bounds() }
+func (p Polygon) Contains(v Vec) bool          { return p.hull().contains(v) }
+func (p Polygon) ClosestPoint(v Vec) Vec       { return p.hull().closestPoint(v) }
+func (p Polygon) Raycast(r Ray) (RayHit, bool) { return p.hull().raycast(r) }
+
+func (p Polygon) translate(d Vec) Shape {
+	pts := make([]Vec, len(p.Points))
+	for i, v := range p.Points {
+		pts[i] = v.Add(d)
+	}
+	return Polygon{pts}
+}
+
+// Transform returns the polygon with every point transformed by m.
+func (p Polygon) Transform(m Affine) Polygon {
+	pts := make([]Vec, len(p.Points))
+	for i, v := range p.Points {
+		pts[i] = m.Apply(v)
+	}
+	return Polygon{pts}
+}
+
+func (p Polygon) hull() hull {
+	var area float64
+	for i, a := range p.Points {
+		area += a.Cross(p.Points[(i+1)%len(p.Points)])
+	}
+	if area >= 0 {
+		return hull{p.Points, 0}
+	}
+	pts := make([]Vec, len(p.Points))
+	for i, v := range p.Points {
+		pts[len(pts)-1-i] = v
+	}
+	return hull{pts, 0}
+}
+
+func (r Rect) Bounds() Rect { return r }
+
+func (r Rect) ClosestPoint(p Vec) Vec { return r.Clamp(p) }
+
+func (r Rect) Raycast(ray Ray) (RayHit, bool) { return r.hull().raycast(ray) }
+
+func (r Rect) translate(d Vec) Shape { return r.Add(d) }
+
+func (r Rect) hull() hull {
+	r = r.Canon()
+	cs := Identity().Corners(r)
+	return hull{cs[:], 0}
+}
+
+// Ray is a half line from Origin in the direction Dir, which need not be a
+// unit vector.
+type Ray struct {
+	Origin, Dir Vec
+}
+
+// At returns the point t lengths of Dir along the ray.
+func (r Ray) At(t float64) Vec { return r.Origin.Add(r.Dir.Mul(t)) }
+
+// RayHit is where a ray enters a shape. A ray starting inside a shape hits
+// it at T 0 with a zero Normal.
+type RayHit struct {
+	// T is the distance along the ray in lengths of its Dir.
+	T      float64
+	Point  Vec
+	Normal Vec
+}
+
+// closestOnSegment returns the point of the segment from a to b closest to p.
+func closestOnSegment(p, a, b Vec) Vec {
+	ab := b.Sub(a)
+	l := ab.LenSquared()
+	if l == 0 {
+		return a
+	}
+	t := math.Max(0, math.Min(1, p.Sub(a).Dot(ab)/l))
+	return a.Add(ab.Mul(t))
+}
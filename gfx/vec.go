@@ -15,7 +15,7 @@ func (v Vec) Sub(v2 Vec) Vec                  { return Vec{v.X - v2.X, v.Y - v2.
 func (v Vec) Mul(s float64) Vec               { return Vec{v.X * s, v.Y * s} }
 func (v Vec) Div(s float64) Vec               { return Vec{v.X / s, v.Y / s} }
 func (v Vec) LenSquared() float64             { return v.X*v.X + v.Y*v.Y }
-func (v Vec) Len() float64                    { return float64(math.Sqrt(float64(v.LenSquared()))) }
+func (v Vec) Len() float64                    { return math.Sqrt(v.X*v.X + v.Y*v.Y) }
 
 func (v Vec) Unit() Vec {
 	if len := v.Len(); len != 0 {
@@ -31,3 +31,66 @@ func (v Vec) Ceil() Vec          { return Vec{math.Ceil(v.X), math.Ceil(v.Y)} }
 func (v Vec) Abs() Vec           { return Vec{math.Abs(v.X), math.Abs(v.Y)} }
 func (v Vec) Min(v2 Vec) Vec     { return Vec{math.Min(v.X, v2.X), math.Min(v.Y, v2.Y)} }
 func (v Vec) Max(v2 Vec) Vec     { return Vec{math.Max(v.X, v2.X), math.Max(v.Y, v2.Y)} }
+
+// Polar returns the vector of length r at angle radians from the x axis.
+func Polar(r, radians float64) Vec {
+	sin, cos := math.Sincos(radians)
+	return Vec{r * cos, r * sin}
+}
+
+func (v Vec) Neg() Vec                   { return Vec{-v.X, -v.Y} }
+func (v Vec) Scale(v2 Vec) Vec           { return Vec{v.X * v2.X, v.Y * v2.Y} }
+func (v Vec) Dot(v2 Vec) float64         { return v.X*v2.X + v.Y*v2.Y }
+func (v Vec) Cross(v2 Vec) float64       { return v.X*v2.Y - v.Y*v2.X }
+func (v Vec) Dist(v2 Vec) float64        { return v2.Sub(v).Len() }
+func (v Vec) DistSquared(v2 Vec) float64 { return v2.Sub(v).LenSquared() }
+
+// Perp returns v rotated a quarter turn, clockwise on screen.
+func (v Vec) Perp() Vec { return Vec{-v.Y, v.X} }
+
+// Angle returns the angle of v from the x axis, in (-Pi, Pi].
+func (v Vec) Angle() float64 { return math.Atan2(v.Y, v.X) }
+
+// AngleTo returns the signed angle from v to v2, in (-Pi, Pi].
+func (v Vec) AngleTo(v2 Vec) float64 { return math.Atan2(v.Cross(v2), v.Dot(v2)) }
+
+// Rotate rotates v by radians, like Rotate(radians).Apply(v).
+func (v Vec) Rotate(radians float64) Vec {
+	sin, cos := math.Sincos(radians)
+	return Vec{v.X*cos - v.Y*sin, v.X*sin + v.Y*cos}
+}
+
+// Lerp interpolates linearly from v at t=0 to v2 at t=1.
+func (v Vec) Lerp(v2 Vec, t float64) Vec { return v.Add(v2.Sub(v).Mul(t)) }
+
+// Project returns the projection of v onto the line through the origin
+// along onto.
+func (v Vec) Project(onto Vec) Vec {
+	if d := onto.LenSquared(); d != 0 {
+		return onto.Mul(v.Dot(onto) / d)
+	}
+	return Vec{}
+}
+
+// Reflect mirrors v off a surface with the given normal, which need not be a
+// unit vector.
+func (v Vec) Reflect(normal Vec) Vec { return v.Sub(v.Project(normal).Mul(2)) }
+
+// Clamp limits each component of v to the range between min and max.
+func (v Vec) Clamp(min, max Vec) Vec { return v.Max(min).Min(max) }
+
+// ClampLen shortens v to at most max.
+func (v Vec) ClampLen(max float64) Vec {
+	if l := v.LenSquared(); l > max*max {
+		return v.Mul(max / math.Sqrt(l))
+	}
+	return v
+}
+
+// ApproxEqual reports whether each component of v is within eps of v2.
+func (v Vec) ApproxEqual(v2 Vec, eps float64) bool {
+	return math.Abs(v.X-v2.X) <= eps && math.Abs(v.Y-v2.Y) <= eps
+}
+
+// IVec truncates v towards zero, like Point.
+func (v Vec) IVec() IVec { return IVec{int(v.X), int(v.Y)} }
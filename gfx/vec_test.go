@@ -0,0 +1,125 @@
+package gfx
+
+import (
+	"math"
+	"testing"
+)
+
+const eps = 1e-9
+
+func TestVecUnit(t *testing.T) {
+	for _, tc := range []struct{ v, want Vec }{
+		{V(3, 4), V(0.6, 0.8)},
+		{V(-2, 0), V(-1, 0)},
+		{V(0, 0), V(0, 0)},
+		{V(1e-100, 0), V(1, 0)},
+	} {
+		if got := tc.v.Unit(); !got.ApproxEqual(tc.want, eps) {
+			t.Errorf("%v.Unit() = %v, want %v", tc.v, got, tc.want)
+		}
+	}
+}
+
+func TestVecProject(t *testing.T) {
+	for _, tc := range []struct{ v, onto, want Vec }{
+		{V(3, 4), V(2, 0), V(3, 0)},
+		{V(3, 4), V(0, -1), V(0, 4)},
+		{V(1, 1), V(1, -1), V(0, 0)},
+		{V(3, 4), V(0, 0), V(0, 0)},
+	} {
+		if got := tc.v.Project(tc.onto); !got.ApproxEqual(tc.want, eps) {
+			t.Errorf("%v.Project(%v) = %v, want %v", tc.v, tc.onto, got, tc.want)
+		}
+	}
+	if got := V(1, -1).Reflect(V(0, 2)); !got.ApproxEqual(V(1, 1), eps) {
+		t.Errorf("Reflect = %v, want (1, 1)", got)
+	}
+	if got := V(1, -1).Reflect(V(0, 0)); got != V(1, -1) {
+		t.Errorf("Reflect off zero normal = %v, want (1, -1)", got)
+	}
+}
+
+func TestVecClampLen(t *testing.T) {
+	for _, tc := range []struct {
+		v    Vec
+		max  float64
+		want Vec
+	}{
+		{V(3, 4), 10, V(3, 4)},
+		{V(3, 4), 5, V(3, 4)},
+		{V(3, 4), 2.5, V(1.5, 2)},
+		{V(3, 4), 0, V(0, 0)},
+		{V(0, 0), 1, V(0, 0)},
+	} {
+		if got := tc.v.ClampLen(tc.max); !got.ApproxEqual(tc.want, eps) {
+			t.Errorf("%v.ClampLen(%g) = %v, want %v", tc.v, tc.max, got, tc.want)
+		}
+	}
+}
+
+func TestVecAngles(t *testing.T) {
+	if got := V(1, 0).Rotate(math.Pi / 2); !got.ApproxEqual(V(0, 1), eps) {
+		t.Errorf("Rotate = %v, want (0, 1)", got)
+	}
+	if got := V(1, 0).Perp(); got != V(0, 1) {
+		t.Errorf("Perp = %v, want (0, 1)", got)
+	}
+	if got := V(0, 1).AngleTo(V(1, 0)); math.Abs(got+math.Pi/2) > eps {
+		t.Errorf("AngleTo = %g, want -Pi/2", got)
+	}
+	if got := V(-1, 0).Angle(); got != math.Pi {
+		t.Errorf("Angle = %g, want Pi", got)
+	}
+	if got := Polar(2, math.Pi); !got.ApproxEqual(V(-2, 0), eps) {
+		t.Errorf("Polar = %v, want (-2, 0)", got)
+	}
+	if got := V(0, 0).AngleTo(V(1, 1)); got != 0 {
+		t.Errorf("AngleTo from zero = %g, want 0", got)
+	}
+}
+
+func TestVecConversions(t *testing.T) {
+	v := V(-1.5, 2.5)
+	if got := v.IVec(); got != IV(-1, 2) {
+		t.Errorf("IVec = %v, want (-1, 2)", got)
+	}
+	if got := v.Point(); got.X != -1 || got.Y != 2 {
+		t.Errorf("Point = %v, want (-1, 2)", got)
+	}
+	if got := v.Floor(); got != V(-2, 2) {
+		t.Errorf("Floor = %v", got)
+	}
+	if got := v.Round(); got != V(-2, 3) {
+		t.Errorf("Round = %v", got)
+	}
+	if got := V(5, -5).Clamp(V(0, 0), V(1, 1)); got != V(1, 0) {
+		t.Errorf("Clamp = %v", got)
+	}
+	if got := V(0, 0).Lerp(V(4, -2), 0.25); got != V(1, -0.5) {
+		t.Errorf("Lerp = %v", got)
+	}
+}
+
+var sinkVec Vec
+
+func BenchmarkVecAdd(b *testing.B) {
+	v, d := V(1, 2), V(0.5, -0.25)
+	for i := 0; i < b.N; i++ {
+		v = v.Add(d)
+	}
+	sinkVec = v
+}
+
+func BenchmarkVecUnit(b *testing.B) {
+	v := V(3, 4)
+	for i := 0; i < b.N; i++ {
+		sinkVec = v.Unit()
+	}
+}
+
+func BenchmarkVecRotate(b *testing.B) {
+	v := V(3, 4)
+	for i := 0; i < b.N; i++ {
+		sinkVec = v.Rotate(0.1)
+	}
+}
@@ -19,7 +19,7 @@ require (
 	github.com/jncornett/doublebuf v0.2.0
 	golang.org/x/exp v0.0.0-20221012211006-4de253d81b95 // indirect
 	golang.org/x/exp/shiny v0.0.0-20220827204233-334a2380cb91 // indirect
-	golang.org/x/image v0.5.0 // indirect
+	golang.org/x/image v0.5.0
 	golang.org/x/sys v0.0.0-20220825204002-c680a09ffe64 // indirect
 	golang.org/x/text v0.7.0 // indirect
 )
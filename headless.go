@@ -0,0 +1,67 @@
+package bit
+
+import (
+	"image"
+	"time"
+)
+
+// Headless is a StartDraw backend that consumes frames without opening a
+// window. It returns once the engine stops, or once either of its limits is
+// reached.
+type Headless struct {
+	// Frames, if positive, is the number of frames to draw before stopping.
+	Frames int
+	// Duration, if positive, is how long to run before stopping.
+	Duration time.Duration
+	// OnFrame, if set, is called with each new frame. The image is only
+	// valid until OnFrame returns. A non-nil error stops the backend and is
+	// returned from StartDraw.
+	OnFrame func(frame int, img *image.NRGBA) error
+	// Poll is how often to check for a new frame. Defaults to DefaultHeadlessPoll.
+	Poll time.Duration
+}
+
+const DefaultHeadlessPoll = time.Millisecond
+
+func (h Headless) StartDraw(buf ReadBuffer) error {
+	poll := h.Poll
+	if poll <= 0 {
+		poll = DefaultHeadlessPoll
+	}
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	var deadline <-chan time.Time
+	if h.Duration > 0 {
+		timer := time.NewTimer(h.Duration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	var frames int
+	next := func() (stop bool, err error) {
+		img, changed := buf.Next()
+		if !changed {
+			return false, nil
+		}
+		frames++
+		if h.OnFrame != nil {
+			if err := h.OnFrame(frames, img); err != nil {
+				return true, err
+			}
+		}
+		return h.Frames > 0 && frames >= h.Frames, nil
+	}
+	for {
+		select {
+		case <-deadline:
+			return nil
+		case <-buf.Done():
+			// Pick up the last frame, if there is one.
+			_, err := next()
+			return err
+		case <-ticker.C:
+			if stop, err := next(); stop || err != nil {
+				return err
+			}
+		}
+	}
+}
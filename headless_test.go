@@ -0,0 +1,62 @@
+package bit
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+)
+
+func TestHeadlessFrames(t *testing.T) {
+	var updates int
+	var drawn []int
+	app := NewDrawApp(image.Pt(4, 4), 0, func(_ Tick, n int) (int, DrawList) {
+		updates++
+		return n + 1, DrawList{Clear{color.NRGBA{uint8(n + 1), 0, 0, 255}}}
+	}).
+		Clock(MakeVirtualClock(epoch, time.Millisecond, 0)).
+		Headless(Headless{Frames: 5, OnFrame: func(frame int, img *image.NRGBA) error {
+			drawn = append(drawn, frame)
+			if img.NRGBAAt(0, 0).R == 0 {
+				t.Errorf("frame %d was not drawn", frame)
+			}
+			return nil
+		}})
+	if err := app.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(drawn) != 5 || drawn[0] != 1 || drawn[4] != 5 {
+		t.Errorf("frames = %v, want 1 to 5", drawn)
+	}
+	if updates < 5 {
+		t.Errorf("updates = %d, want at least 5", updates)
+	}
+}
+
+func TestHeadlessError(t *testing.T) {
+	errStop := errors.New("stop")
+	app := NewDrawApp(image.Pt(4, 4), 0, func(_ Tick, n int) (int, DrawList) { return n, nil }).
+		Clock(MakeVirtualClock(epoch, time.Millisecond, 0)).
+		Headless(Headless{OnFrame: func(int, *image.NRGBA) error { return errStop }})
+	if err := app.Run(context.Background()); !errors.Is(err, errStop) {
+		t.Errorf("Run = %v, want %v", err, errStop)
+	}
+}
+
+func TestHeadlessClockClosed(t *testing.T) {
+	var updates int
+	app := NewDrawApp(image.Pt(4, 4), 0, func(_ Tick, n int) (int, DrawList) {
+		updates++
+		return n, nil
+	}).
+		Clock(MakeVirtualClock(epoch, time.Millisecond, 10)).
+		Headless(Headless{})
+	if err := app.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if updates != 10 {
+		t.Errorf("updates = %d, want 10", updates)
+	}
+}
@@ -0,0 +1,210 @@
+package bit
+
+import (
+	"sort"
+
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"github.com/jncornett/bit/gfx"
+)
+
+type EventKind uint8
+
+const (
+	EventKey EventKind = iota + 1
+	EventPointer
+)
+
+// Event is an input event received from the window.
+type Event struct {
+	Kind    EventKind
+	Key     KeyEvent
+	Pointer PointerEvent
+}
+
+type KeyEvent struct {
+	Name      string
+	Modifiers key.Modifiers
+	State     key.State
+}
+
+// PointerEvent is a pointer event in image pixel coordinates.
+type PointerEvent struct {
+	Type     pointer.Type
+	Position gfx.Vec
+	Buttons  pointer.Buttons
+	Scroll   gfx.Vec
+}
+
+// Frame is a tick together with the input events received since the previous one.
+type Frame struct {
+	Tick   Tick
+	Events []Event
+}
+
+// FrameClock batches events into frames, one frame per tick. Events keep
+// being added to a frame until it is received, but no new tick is read until
+// then, so a slow consumer applies backpressure to the clock. The returned
+// channel is closed when ticks is closed.
+func FrameClock(ticks <-chan Tick, events <-chan Event) <-chan Frame {
+	out := make(chan Frame)
+	go func() {
+		defer close(out)
+		for {
+			var frame Frame
+		Receive:
+			for {
+				select {
+				case e, ok := <-events:
+					if !ok {
+						events = nil
+						continue
+					}
+					frame.Events = append(frame.Events, e)
+				case t, ok := <-ticks:
+					if !ok {
+						return
+					}
+					frame.Tick = t
+					break Receive
+				}
+			}
+		Send:
+			for {
+				select {
+				case e, ok := <-events:
+					if !ok {
+						events = nil
+						continue
+					}
+					frame.Events = append(frame.Events, e)
+				case out <- frame:
+					break Send
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// Input is a snapshot of the input state passed to Update.
+// Edges (presses, releases and scrolling) are reported by the first Update
+// after they happen, even if several fixed steps run in the same frame.
+type Input struct {
+	down              map[string]bool
+	pressed, released map[string]bool
+
+	// Pointer is the last known pointer position.
+	Pointer gfx.Vec
+	// Buttons are the pointer buttons currently held down.
+	Buttons pointer.Buttons
+	// ButtonsPressed and ButtonsReleased are the buttons that changed state
+	// since the previous Update.
+	ButtonsPressed, ButtonsReleased pointer.Buttons
+	// Scroll is the scroll distance accumulated since the previous Update.
+	Scroll gfx.Vec
+}
+
+// Down reports whether the named key is held down. Names are those of key.Event.
+func (in Input) Down(name string) bool { return in.down[name] }
+
+// Pressed reports whether the named key went down since the previous Update.
+func (in Input) Pressed(name string) bool { return in.pressed[name] }
+
+// Released reports whether the named key went up since the previous Update.
+func (in Input) Released(name string) bool { return in.released[name] }
+
+// PressedKeys returns the names of the keys that went down since the previous
+// Update, sorted.
+func (in Input) PressedKeys() []string {
+	keys := make([]string, 0, len(in.pressed))
+	for k := range in.pressed {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// inputState accumulates events into Input snapshots.
+type inputState struct {
+	in Input
+	// shared is set when in.down has been handed out in a snapshot and must
+	// be copied before it is modified.
+	shared bool
+}
+
+func (s *inputState) Apply(events []Event) {
+	for _, e := range events {
+		switch e.Kind {
+		case EventKey:
+			s.applyKey(e.Key)
+		case EventPointer:
+			s.applyPointer(e.Pointer)
+		}
+	}
+}
+
+func (s *inputState) applyKey(e KeyEvent) {
+	down := e.State == key.Press
+	if s.in.down[e.Name] == down {
+		return // key repeat
+	}
+	if s.shared || s.in.down == nil {
+		m := make(map[string]bool, len(s.in.down)+1)
+		for k, v := range s.in.down {
+			m[k] = v
+		}
+		s.in.down, s.shared = m, false
+	}
+	if down {
+		s.in.down[e.Name] = true
+		s.in.pressed = setKey(s.in.pressed, e.Name)
+	} else {
+		delete(s.in.down, e.Name)
+		s.in.released = setKey(s.in.released, e.Name)
+	}
+}
+
+func setKey(m map[string]bool, name string) map[string]bool {
+	if m == nil {
+		m = make(map[string]bool)
+	}
+	m[name] = true
+	return m
+}
+
+func (s *inputState) applyPointer(e PointerEvent) {
+	// pointer.Cancel is zero, so it cannot be tested for as a bit.
+	cancel := e.Type == pointer.Cancel
+	if !cancel && e.Type&pointer.Leave == 0 {
+		s.in.Pointer = e.Position
+	}
+	if cancel || e.Type&(pointer.Press|pointer.Release|pointer.Move|pointer.Drag) != 0 {
+		buttons := e.Buttons
+		if cancel {
+			buttons = 0
+		}
+		s.in.ButtonsPressed |= buttons &^ s.in.Buttons
+		s.in.ButtonsReleased |= s.in.Buttons &^ buttons
+		s.in.Buttons = buttons
+	}
+	s.in.Scroll = s.in.Scroll.Add(e.Scroll)
+}
+
+// Take returns the current snapshot and clears its edges.
+func (s *inputState) Take() Input {
+	in := s.in
+	s.in = s.held()
+	return in
+}
+
+// held returns the current snapshot without its edges. Later events do not
+// change it.
+func (s *inputState) held() Input {
+	in := s.in
+	s.shared = true
+	in.pressed, in.released = nil, nil
+	in.ButtonsPressed, in.ButtonsReleased = 0, 0
+	in.Scroll = gfx.Vec{}
+	return in
+}
@@ -0,0 +1,126 @@
+package bit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"github.com/jncornett/bit/gfx"
+)
+
+func TestFrameClock(t *testing.T) {
+	ticks := make(chan Tick)
+	events := make(chan Event)
+	frames := FrameClock(ticks, events)
+	key := func(name string) Event { return Event{Kind: EventKey, Key: KeyEvent{Name: name}} }
+	tick := func(i int) Tick { return NewTick(epoch).Step(epoch.Add(time.Duration(i) * time.Millisecond)) }
+	names := func(f Frame) []string {
+		var ns []string
+		for _, e := range f.Events {
+			ns = append(ns, e.Key.Name)
+		}
+		return ns
+	}
+
+	// Events go into the frame of the next tick.
+	events <- key("a")
+	events <- key("b")
+	ticks <- tick(1)
+	if f := <-frames; f.Tick != tick(1) || !reflect.DeepEqual(names(f), []string{"a", "b"}) {
+		t.Errorf("frame 1 = %v %v; want a, b", f.Tick, names(f))
+	}
+	ticks <- tick(2)
+	if f := <-frames; f.Tick != tick(2) || len(f.Events) != 0 {
+		t.Errorf("frame 2 = %v %v; want no events", f.Tick, names(f))
+	}
+	// Events that arrive before a frame is received still join it.
+	ticks <- tick(3)
+	events <- key("c")
+	if f := <-frames; f.Tick != tick(3) || !reflect.DeepEqual(names(f), []string{"c"}) {
+		t.Errorf("frame 3 = %v %v; want c", f.Tick, names(f))
+	}
+	// Frames keep coming without events.
+	close(events)
+	ticks <- tick(4)
+	if f := <-frames; f.Tick != tick(4) || len(f.Events) != 0 {
+		t.Errorf("frame 4 = %v %v; want no events", f.Tick, names(f))
+	}
+	close(ticks)
+	if _, ok := <-frames; ok {
+		t.Errorf("frames not closed after ticks")
+	}
+}
+
+func keys(events ...interface{}) []Event {
+	var out []Event
+	for i := 0; i < len(events); i += 2 {
+		out = append(out, keyEvent(events[i].(string), events[i+1].(key.State)))
+	}
+	return out
+}
+
+func TestInputKeys(t *testing.T) {
+	var s inputState
+	// A press and release in the same frame are both seen.
+	s.Apply(keys("A", key.Press, "A", key.Release))
+	in := s.Take()
+	if !in.Pressed("A") || !in.Released("A") || in.Down("A") {
+		t.Errorf("tap: pressed %v, released %v, down %v; want true, true, false", in.Pressed("A"), in.Released("A"), in.Down("A"))
+	}
+
+	// Take clears edges, but held keys persist.
+	s.Apply(keys("B", key.Press, "C", key.Press, "B", key.Press))
+	in = s.Take()
+	if !in.Down("B") || !in.Down("C") || !reflect.DeepEqual(in.PressedKeys(), []string{"B", "C"}) || in.Pressed("A") {
+		t.Errorf("hold: down B %v, C %v, pressed %v", in.Down("B"), in.Down("C"), in.PressedKeys())
+	}
+	next := s.Take()
+	if !next.Down("B") || !next.Down("C") || next.Pressed("B") || len(next.PressedKeys()) != 0 {
+		t.Errorf("after Take: down B %v, C %v, pressed %v; want held without edges", next.Down("B"), next.Down("C"), next.PressedKeys())
+	}
+
+	// Earlier snapshots do not change with later events.
+	s.Apply(keys("B", key.Release))
+	in2 := s.Take()
+	if in2.Down("B") || !in2.Released("B") || !in2.Down("C") {
+		t.Errorf("release: down B %v, released B %v, down C %v", in2.Down("B"), in2.Released("B"), in2.Down("C"))
+	}
+	if !in.Down("B") || !next.Down("B") {
+		t.Errorf("a later release changed an earlier snapshot")
+	}
+	// A release of a key that is not down is ignored.
+	s.Apply(keys("D", key.Release))
+	if in := s.Take(); in.Released("D") {
+		t.Errorf("release of a key not down was reported")
+	}
+}
+
+func TestInputPointer(t *testing.T) {
+	var s inputState
+	s.Apply([]Event{
+		{Kind: EventPointer, Pointer: PointerEvent{Type: pointer.Move, Position: gfx.V(1, 2)}},
+		{Kind: EventPointer, Pointer: PointerEvent{Type: pointer.Press, Position: gfx.V(3, 4), Buttons: pointer.ButtonPrimary}},
+		{Kind: EventPointer, Pointer: PointerEvent{Type: pointer.Scroll, Position: gfx.V(3, 4), Buttons: pointer.ButtonPrimary, Scroll: gfx.V(0, 2)}},
+		{Kind: EventPointer, Pointer: PointerEvent{Type: pointer.Scroll, Position: gfx.V(3, 4), Buttons: pointer.ButtonPrimary, Scroll: gfx.V(1, 1)}},
+	})
+	in := s.Take()
+	if in.Pointer != gfx.V(3, 4) || in.Buttons != pointer.ButtonPrimary || in.ButtonsPressed != pointer.ButtonPrimary || in.Scroll != gfx.V(1, 3) {
+		t.Errorf("after press: %+v", in)
+	}
+	in = s.Take()
+	if in.Buttons != pointer.ButtonPrimary || in.ButtonsPressed != 0 || in.Scroll != (gfx.Vec{}) {
+		t.Errorf("after Take: %+v; want the button held without edges", in)
+	}
+
+	// Leaving keeps the last position, and a cancel releases the buttons.
+	s.Apply([]Event{
+		{Kind: EventPointer, Pointer: PointerEvent{Type: pointer.Leave, Position: gfx.V(-1, -1), Buttons: pointer.ButtonPrimary}},
+		{Kind: EventPointer, Pointer: PointerEvent{Type: pointer.Cancel, Buttons: pointer.ButtonPrimary}},
+	})
+	in = s.Take()
+	if in.Pointer != gfx.V(3, 4) || in.Buttons != 0 || in.ButtonsReleased != pointer.ButtonPrimary {
+		t.Errorf("after cancel: %+v", in)
+	}
+}
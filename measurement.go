@@ -3,6 +3,8 @@ package bit
 import (
 	"fmt"
 	"math"
+	"math/bits"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -67,3 +69,154 @@ func (m DurationMetric) String() string {
 		m.AverageDuration().Round(time.Nanosecond),
 	)
 }
+
+const DefaultMetricWindow = 5 * time.Second
+
+// Durations are kept in histogram buckets: one per nanosecond below
+// histSub, then histSub per power of two, which is within about 3% of any
+// duration.
+const (
+	histSub     = 16
+	histBuckets = (62-3)*histSub + histSub
+)
+
+func histIndex(d time.Duration) int {
+	if d < histSub {
+		if d < 0 {
+			return 0
+		}
+		return int(d)
+	}
+	e := bits.Len64(uint64(d)) - 1
+	sub := int(uint64(d)>>(e-4)) & (histSub - 1)
+	return (e-3)*histSub + sub
+}
+
+// histValue returns the middle of bucket i.
+func histValue(i int) time.Duration {
+	if i < histSub {
+		return time.Duration(i)
+	}
+	e, sub := i/histSub+3, i%histSub
+	min := uint64(histSub+sub) << (e - 4)
+	return time.Duration(min + uint64(1)<<(e-4)/2)
+}
+
+// WindowMetric keeps a histogram of the durations of recent events, one
+// second at a time, to report percentiles and spikes that an average hides.
+// It may be used from several goroutines; each event takes a short lock.
+type WindowMetric struct {
+	// Budget is how long an event may take before it counts as over
+	// budget. Zero disables counting. Set it before use.
+	Budget time.Duration
+
+	mu    sync.Mutex
+	slots []windowSlot
+}
+
+type windowSlot struct {
+	sec        int64
+	count      uint64
+	overBudget uint64
+	max        time.Duration
+	hist       [histBuckets]uint32
+}
+
+// WindowStats are the statistics of the events in a WindowMetric's window.
+type WindowStats struct {
+	Count, OverBudget  uint64
+	P50, P95, P99, Max time.Duration
+	// PerSecond is the number of events in the last whole second, such as
+	// frames per second.
+	PerSecond uint64
+}
+
+// NewWindowMetric returns a metric of the events in the last window, which is
+// rounded up to whole seconds, and at least two.
+func NewWindowMetric(window, budget time.Duration) *WindowMetric {
+	n := int((window + time.Second - 1) / time.Second)
+	if n < 2 {
+		n = 2
+	}
+	return &WindowMetric{Budget: budget, slots: make([]windowSlot, n)}
+}
+
+func (m *WindowMetric) PutEvent(d time.Duration) { m.PutEventAt(time.Now(), d) }
+
+// PutEventAt records an event of duration d that ended at now.
+func (m *WindowMetric) PutEventAt(now time.Time, d time.Duration) {
+	sec := now.Unix()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// The remainder is negative before 1970.
+	n := int64(len(m.slots))
+	s := &m.slots[(sec%n+n)%n]
+	if s.sec != sec {
+		*s = windowSlot{sec: sec}
+	}
+	s.count++
+	if m.Budget > 0 && d > m.Budget {
+		s.overBudget++
+	}
+	if d > s.max {
+		s.max = d
+	}
+	s.hist[histIndex(d)]++
+}
+
+func (m *WindowMetric) Stats() WindowStats { return m.StatsAt(time.Now()) }
+
+// StatsAt returns the statistics of the window ending at now.
+func (m *WindowMetric) StatsAt(now time.Time) WindowStats {
+	sec := now.Unix()
+	var stats WindowStats
+	var hist [histBuckets]uint64
+	m.mu.Lock()
+	for i := range m.slots {
+		s := &m.slots[i]
+		if s.sec <= sec-int64(len(m.slots)) || s.sec > sec || s.count == 0 {
+			continue
+		}
+		stats.Count += s.count
+		stats.OverBudget += s.overBudget
+		if s.max > stats.Max {
+			stats.Max = s.max
+		}
+		if s.sec == sec-1 {
+			stats.PerSecond = s.count
+		}
+		for j, n := range s.hist {
+			hist[j] += uint64(n)
+		}
+	}
+	m.mu.Unlock()
+	percentile := func(q float64) time.Duration {
+		rank := uint64(math.Ceil(q * float64(stats.Count)))
+		var seen uint64
+		for i, n := range hist {
+			if seen += n; seen >= rank && n > 0 {
+				if v := histValue(i); v < stats.Max {
+					return v
+				}
+				return stats.Max
+			}
+		}
+		return stats.Max
+	}
+	if stats.Count > 0 {
+		stats.P50, stats.P95, stats.P99 = percentile(0.5), percentile(0.95), percentile(0.99)
+	}
+	return stats
+}
+
+func (s WindowStats) String() string {
+	return fmt.Sprintf(
+		"[p50 %v, p95 %v, p99 %v, max %v, %d/s, %d over budget]",
+		s.P50.Round(time.Microsecond),
+		s.P95.Round(time.Microsecond),
+		s.P99.Round(time.Microsecond),
+		s.Max.Round(time.Microsecond),
+		s.PerSecond,
+		s.OverBudget,
+	)
+}
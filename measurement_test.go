@@ -0,0 +1,268 @@
+package bit
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHistIndex(t *testing.T) {
+	for _, tt := range []struct {
+		d    time.Duration
+		want int
+	}{
+		{-5, 0},
+		{0, 0},
+		{1, 1},
+		{15, 15},
+		{16, 16},
+		{17, 17},
+		{31, 31},
+		{32, 32},
+		{33, 32},
+		{34, 33},
+		{63, 47},
+		{64, 48},
+		{math.MaxInt64, histBuckets - 1},
+	} {
+		if got := histIndex(tt.d); got != tt.want {
+			t.Errorf("histIndex(%d) = %d; want %d", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestHistBuckets(t *testing.T) {
+	// Every bucket's value falls in it, and buckets do not overlap.
+	last := time.Duration(-1)
+	for i := 0; i < histBuckets; i++ {
+		v := histValue(i)
+		if got := histIndex(v); got != i {
+			t.Errorf("histIndex(histValue(%d) = %d) = %d", i, v, got)
+		}
+		if v <= last {
+			t.Errorf("histValue(%d) = %d; not above histValue(%d) = %d", i, v, i-1, last)
+		}
+		last = v
+	}
+	// Every duration is within about 3% of its bucket's value.
+	for _, d := range []time.Duration{16, 100, 999, 12345, time.Millisecond, 16667 * time.Microsecond, time.Hour, math.MaxInt64 / 3, math.MaxInt64} {
+		v := histValue(histIndex(d))
+		if err := math.Abs(float64(v)-float64(d)) / float64(d); err > 1.0/32 {
+			t.Errorf("histValue(histIndex(%d)) = %d, off by %.2f%%", d, v, err*100)
+		}
+	}
+}
+
+// within reports whether got is within the error of a bucket of want.
+func within(got, want time.Duration) bool {
+	return math.Abs(float64(got)-float64(want)) <= float64(want)/32
+}
+
+func TestWindowPercentiles(t *testing.T) {
+	now := epoch.Add(500 * time.Millisecond)
+	for _, tt := range []struct {
+		name          string
+		events        []time.Duration
+		p50, p95, p99 time.Duration
+		max           time.Duration
+	}{
+		{
+			name:   "one",
+			events: []time.Duration{3 * time.Millisecond},
+			p50:    3 * time.Millisecond, p95: 3 * time.Millisecond, p99: 3 * time.Millisecond,
+			max: 3 * time.Millisecond,
+		},
+		{
+			name:   "uniform",
+			events: spread(1, 100, time.Millisecond),
+			p50:    50 * time.Millisecond, p95: 95 * time.Millisecond, p99: 99 * time.Millisecond,
+			max: 100 * time.Millisecond,
+		},
+		{
+			name:   "constant",
+			events: repeat(1000, 16667*time.Microsecond),
+			p50:    16667 * time.Microsecond, p95: 16667 * time.Microsecond, p99: 16667 * time.Microsecond,
+			max: 16667 * time.Microsecond,
+		},
+		{
+			name:   "spikes",
+			events: append(repeat(96, time.Millisecond), repeat(4, 40*time.Millisecond)...),
+			p50:    time.Millisecond, p95: time.Millisecond, p99: 40 * time.Millisecond,
+			max: 40 * time.Millisecond,
+		},
+		{
+			name:   "nanoseconds",
+			events: spread(1, 10, 1),
+			p50:    5, p95: 10, p99: 10,
+			max: 10,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewWindowMetric(time.Second, 0)
+			for _, d := range tt.events {
+				m.PutEventAt(now, d)
+			}
+			s := m.StatsAt(now)
+			if s.Count != uint64(len(tt.events)) || s.Max != tt.max {
+				t.Errorf("Count, Max = %d, %v; want %d, %v", s.Count, s.Max, len(tt.events), tt.max)
+			}
+			if !within(s.P50, tt.p50) || !within(s.P95, tt.p95) || !within(s.P99, tt.p99) {
+				t.Errorf("p50, p95, p99 = %v, %v, %v; want about %v, %v, %v", s.P50, s.P95, s.P99, tt.p50, tt.p95, tt.p99)
+			}
+			if s.P50 > s.P95 || s.P95 > s.P99 || s.P99 > s.Max {
+				t.Errorf("percentiles out of order: %v", s)
+			}
+		})
+	}
+	if s := NewWindowMetric(time.Second, 0).StatsAt(now); s != (WindowStats{}) {
+		t.Errorf("StatsAt() with no events = %v; want zero", s)
+	}
+}
+
+// spread returns the durations from lo to hi times unit.
+func spread(lo, hi int, unit time.Duration) []time.Duration {
+	var ds []time.Duration
+	for i := lo; i <= hi; i++ {
+		ds = append(ds, time.Duration(i)*unit)
+	}
+	return ds
+}
+
+func repeat(n int, d time.Duration) []time.Duration {
+	ds := make([]time.Duration, n)
+	for i := range ds {
+		ds[i] = d
+	}
+	return ds
+}
+
+func TestWindowExpiry(t *testing.T) {
+	m := NewWindowMetric(2*time.Second, 0)
+	sec := func(s float64) time.Time { return epoch.Add(time.Duration(s * float64(time.Second))) }
+	for i := 0; i < 3; i++ {
+		m.PutEventAt(sec(0.1), time.Millisecond)
+	}
+	for i := 0; i < 5; i++ {
+		m.PutEventAt(sec(1.9), 2*time.Millisecond)
+	}
+	for _, tt := range []struct {
+		at               float64
+		count, perSecond uint64
+		max              time.Duration
+	}{
+		{0.5, 3, 0, time.Millisecond},
+		{1.5, 8, 3, 2 * time.Millisecond},
+		{2.0, 5, 5, 2 * time.Millisecond},
+		{2.99, 5, 5, 2 * time.Millisecond},
+		{3.0, 0, 0, 0},
+		{-0.5, 0, 0, 0}, // before every event
+	} {
+		s := m.StatsAt(sec(tt.at))
+		if s.Count != tt.count || s.PerSecond != tt.perSecond || s.Max != tt.max {
+			t.Errorf("StatsAt(+%gs) = %d events, %d/s, max %v; want %d, %d/s, max %v", tt.at, s.Count, s.PerSecond, s.Max, tt.count, tt.perSecond, tt.max)
+		}
+	}
+
+	// An event two seconds on reuses the oldest slot, dropping its events.
+	m.PutEventAt(sec(2.5), 3*time.Millisecond)
+	if s := m.StatsAt(sec(2.5)); s.Count != 6 || s.PerSecond != 5 || s.Max != 3*time.Millisecond {
+		t.Errorf("after reuse: %d events, %d/s, max %v; want 6, 5/s, 3ms", s.Count, s.PerSecond, s.Max)
+	}
+}
+
+func TestWindowBeforeEpoch(t *testing.T) {
+	m := NewWindowMetric(3*time.Second, 0)
+	// Two events in the second from -5s, and one in each of the next two.
+	for i, at := range []time.Duration{-5000, -4200, -3400, -2600} {
+		m.PutEventAt(time.Unix(0, 0).Add(at*time.Millisecond), time.Duration(i+1)*time.Millisecond)
+	}
+	if s := m.StatsAt(time.Unix(-3, 0)); s.Count != 4 || s.PerSecond != 1 || s.Max != 4*time.Millisecond {
+		t.Errorf("StatsAt(-3s) = %d events, %d/s, max %v; want 4, 1/s, max 4ms", s.Count, s.PerSecond, s.Max)
+	}
+	// The window runs on across the epoch, reusing the slot of -4s.
+	m.PutEventAt(time.Unix(0, 0).Add(-time.Millisecond), 5*time.Millisecond)
+	if s := m.StatsAt(time.Unix(-1, 0)); s.Count != 2 || s.Max != 5*time.Millisecond {
+		t.Errorf("StatsAt(-1s) = %d events, max %v; want 2, max 5ms", s.Count, s.Max)
+	}
+	m.PutEventAt(time.Unix(0, 0), 6*time.Millisecond)
+	if s := m.StatsAt(time.Unix(0, 0)); s.Count != 2 || s.PerSecond != 1 || s.Max != 6*time.Millisecond {
+		t.Errorf("StatsAt(0) = %d events, %d/s, max %v; want 2, 1/s, max 6ms", s.Count, s.PerSecond, s.Max)
+	}
+}
+
+func TestNewWindowMetric(t *testing.T) {
+	for _, tt := range []struct {
+		window time.Duration
+		slots  int
+	}{
+		{0, 2},
+		{time.Second, 2},
+		{1500 * time.Millisecond, 2},
+		{3*time.Second + 1, 4},
+		{DefaultMetricWindow, 5},
+	} {
+		if got := len(NewWindowMetric(tt.window, 0).slots); got != tt.slots {
+			t.Errorf("NewWindowMetric(%v) has %d slots; want %d", tt.window, got, tt.slots)
+		}
+	}
+}
+
+func TestWindowOverBudget(t *testing.T) {
+	now := epoch
+	m := NewWindowMetric(time.Second, 10*time.Millisecond)
+	for _, d := range []time.Duration{5, 10, 11, 20} {
+		m.PutEventAt(now, d*time.Millisecond)
+	}
+	m.PutEventAt(now.Add(time.Second), 30*time.Millisecond)
+	if s := m.StatsAt(now.Add(time.Second)); s.OverBudget != 3 || s.Count != 5 || s.PerSecond != 4 {
+		t.Errorf("OverBudget, Count, PerSecond = %d, %d, %d; want 3, 5, 4", s.OverBudget, s.Count, s.PerSecond)
+	}
+	m = NewWindowMetric(time.Second, 0)
+	m.PutEventAt(now, time.Hour)
+	if s := m.StatsAt(now); s.OverBudget != 0 {
+		t.Errorf("OverBudget with no budget = %d; want 0", s.OverBudget)
+	}
+}
+
+func TestWindowConcurrent(t *testing.T) {
+	m := NewWindowMetric(time.Second, 500*time.Microsecond)
+	now := epoch
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				m.PutEventAt(now, time.Duration(i)*time.Microsecond)
+				if i%100 == 0 {
+					m.StatsAt(now)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if s := m.StatsAt(now); s.Count != 4000 || s.OverBudget != 4*499 {
+		t.Errorf("Count, OverBudget = %d, %d; want 4000, %d", s.Count, s.OverBudget, 4*499)
+	}
+}
+
+func BenchmarkWindowPutEvent(b *testing.B) {
+	m := NewWindowMetric(DefaultMetricWindow, time.Millisecond)
+	now := time.Now()
+	for i := 0; i < b.N; i++ {
+		m.PutEventAt(now, time.Duration(i))
+	}
+}
+
+func BenchmarkWindowStats(b *testing.B) {
+	m := NewWindowMetric(DefaultMetricWindow, time.Millisecond)
+	now := time.Now()
+	for i := 0; i < 1000; i++ {
+		m.PutEventAt(now.Add(time.Duration(i)*time.Millisecond), time.Duration(i)*time.Microsecond)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.StatsAt(now)
+	}
+}
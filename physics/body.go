@@ -0,0 +1,100 @@
+// Package physics simulates 2D bodies that collide with each other. It is
+// deliberately small: bodies translate but do not rotate, and everything is
+// processed in a fixed order so that a simulation given the same inputs
+// always produces the same results.
+package physics
+
+import "github.com/jncornett/bit/gfx"
+
+type BodyType int
+
+const (
+	// Dynamic bodies are moved by gravity, forces and collisions.
+	Dynamic BodyType = iota
+	// Kinematic bodies move only at their velocity, and push dynamic
+	// bodies out of their way.
+	Kinematic
+	// Static bodies never move.
+	Static
+)
+
+func (t BodyType) String() string {
+	switch t {
+	case Dynamic:
+		return "dynamic"
+	case Kinematic:
+		return "kinematic"
+	case Static:
+		return "static"
+	}
+	return "unknown"
+}
+
+// BodyID identifies a body in a World. IDs are never reused.
+type BodyID uint32
+
+type Body struct {
+	Type BodyType
+	Pos  gfx.Vec
+	Vel  gfx.Vec
+	// Shape is the body's collider, relative to Pos. A body without a
+	// shape collides with nothing.
+	Shape gfx.Shape
+	// Mass of a dynamic body. Zero is treated as 1.
+	Mass float64
+	// Restitution is how bouncy the body is, from 0 to 1. A collision
+	// uses the larger restitution of the two bodies.
+	Restitution float64
+	// Friction slows bodies sliding against each other. A collision uses
+	// the geometric mean of the two bodies' friction.
+	Friction float64
+	// GravityScale multiplies the world's gravity for this body. Zero is
+	// treated as 1; use a tiny value to disable gravity.
+	GravityScale float64
+	// Sensor bodies do not collide, but report the bodies that overlap
+	// them to the world's OnEnter and OnExit.
+	Sensor bool
+
+	force gfx.Vec
+}
+
+// ApplyForce adds a force acting on the body during the next step.
+func (b *Body) ApplyForce(f gfx.Vec) { b.force = b.force.Add(f) }
+
+// ApplyImpulse changes the velocity of a dynamic body immediately.
+func (b *Body) ApplyImpulse(j gfx.Vec) {
+	b.Vel = b.Vel.Add(j.Mul(b.invMass()))
+}
+
+// Bounds returns the bounds of the body's shape in world coordinates.
+func (b *Body) Bounds() gfx.Rect {
+	if b.Shape == nil {
+		return gfx.Rect{Min: b.Pos, Max: b.Pos}
+	}
+	return b.Shape.Bounds().Add(b.Pos)
+}
+
+// WorldShape returns the body's shape in world coordinates.
+func (b *Body) WorldShape() gfx.Shape {
+	if b.Shape == nil {
+		return nil
+	}
+	return gfx.Move(b.Shape, b.Pos)
+}
+
+func (b *Body) invMass() float64 {
+	if b.Type != Dynamic {
+		return 0
+	}
+	if b.Mass <= 0 {
+		return 1
+	}
+	return 1 / b.Mass
+}
+
+func (b *Body) gravityScale() float64 {
+	if b.GravityScale == 0 {
+		return 1
+	}
+	return b.GravityScale
+}
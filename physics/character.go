@@ -0,0 +1,326 @@
+package physics
+
+import (
+	"math"
+	"time"
+
+	"github.com/jncornett/bit/gfx"
+)
+
+type TileShape uint8
+
+const (
+	TileEmpty TileShape = iota
+	TileSolid
+	// TileOneWay can be jumped through from below and stood on from above.
+	TileOneWay
+	// TileSlope is solid below a floor line running across the tile.
+	TileSlope
+)
+
+type Tile struct {
+	Shape TileShape
+	// Left and Right are the heights of a slope's floor at the tile's left
+	// and right edges, from 0 at its bottom to 1 at its top.
+	Left, Right float64
+}
+
+// floor returns the y coordinate of a slope's floor at x, for the tile whose
+// cell is r.
+func (t Tile) floor(r gfx.Rect, x float64) float64 {
+	u := math.Max(0, math.Min(1, (x-r.Min.X)/(r.Max.X-r.Min.X)))
+	h := t.Left + (t.Right-t.Left)*u
+	return r.Max.Y - h*(r.Max.Y-r.Min.Y)
+}
+
+// TileGrid is a grid of tiles that a Character moves through. Cell (x, y)
+// covers the world rectangle from (x, y) to (x+1, y+1) times TileSize.
+type TileGrid interface {
+	TileSize() gfx.Vec
+	Tile(x, y int) Tile
+}
+
+// SolidGrid is a TileGrid made of solid and empty tiles.
+type SolidGrid struct {
+	Size  gfx.Vec
+	Solid func(x, y int) bool
+}
+
+func (g SolidGrid) TileSize() gfx.Vec { return g.Size }
+
+func (g SolidGrid) Tile(x, y int) Tile {
+	if g.Solid(x, y) {
+		return Tile{Shape: TileSolid}
+	}
+	return Tile{}
+}
+
+// Character is a box moved through a TileGrid by its velocity, without being
+// pushed around like a physics body. It is a value, so it can be part of a
+// game state.
+type Character struct {
+	// Box is the character's bounds in world coordinates.
+	Box gfx.Rect
+	Vel gfx.Vec
+	// StepHeight is the tallest ledge that the character walks up onto
+	// without jumping. It also lets the character walk from the top of a
+	// slope onto the tile next to it.
+	StepHeight float64
+	// SnapDistance keeps a grounded character on the ground when walking
+	// down slopes and steps up to this height.
+	SnapDistance float64
+	// CoyoteTime is how long after leaving the ground the character can
+	// still jump.
+	CoyoteTime time.Duration
+	// DropThrough makes the character fall through one-way platforms.
+	DropThrough bool
+
+	// Contacts found by the last Update.
+	OnGround, OnCeiling, OnWallLeft, OnWallRight, OnSlope bool
+	// SinceGround is how long it has been since the character was on the
+	// ground. It only counts once Landed is set.
+	SinceGround time.Duration
+	// Landed reports whether the character has been on the ground, so that
+	// one spawned in midair cannot use its coyote time.
+	Landed bool
+}
+
+// CanJump reports whether the character is on the ground or has left it less
+// than CoyoteTime ago.
+func (c *Character) CanJump() bool {
+	return c.OnGround || c.Landed && c.SinceGround <= c.CoyoteTime
+}
+
+// Jump sets the character's upward speed if it can jump, and reports whether
+// it did.
+func (c *Character) Jump(speed float64) bool {
+	if !c.CanJump() {
+		return false
+	}
+	c.Vel.Y = -speed
+	c.OnGround = false
+	c.SinceGround = c.CoyoteTime + 1
+	return true
+}
+
+// Update moves the character by its velocity for dt, resolving collisions
+// with the grid one axis at a time: first horizontally, then vertically.
+// Velocity into a wall, floor or ceiling is cancelled.
+func (c *Character) Update(g TileGrid, dt time.Duration) {
+	grounded := c.OnGround
+	c.OnGround, c.OnCeiling, c.OnWallLeft, c.OnWallRight, c.OnSlope = false, false, false, false, false
+	d := c.Vel.Mul(dt.Seconds())
+	m := mover{grid: g, size: g.TileSize(), box: c.Box}
+
+	if d.X != 0 {
+		if !m.moveX(d.X, grounded, c.StepHeight) {
+			if d.X > 0 {
+				c.OnWallRight = true
+			} else {
+				c.OnWallLeft = true
+			}
+			c.Vel.X = 0
+		}
+		if grounded {
+			m.climb()
+		}
+	}
+	switch {
+	case d.Y > 0 || d.Y == 0 && grounded:
+		if m.moveDown(d.Y, c.DropThrough) {
+			c.OnGround = true
+		} else if grounded && c.SnapDistance > 0 {
+			probe := m
+			if probe.moveDown(c.SnapDistance, c.DropThrough) {
+				m, c.OnGround = probe, true
+			}
+		}
+		if c.OnGround && c.Vel.Y > 0 {
+			c.Vel.Y = 0
+		}
+	case d.Y < 0:
+		if !m.moveUp(d.Y) {
+			c.OnCeiling = true
+			c.Vel.Y = 0
+		}
+	}
+	c.Box = m.box
+	c.OnSlope = c.OnGround && m.slope
+	c.Landed = c.Landed || grounded || c.OnGround
+	if c.OnGround {
+		c.SinceGround = 0
+	} else {
+		c.SinceGround += dt
+	}
+}
+
+// mover moves a box through a grid.
+type mover struct {
+	grid  TileGrid
+	size  gfx.Vec
+	box   gfx.Rect
+	slope bool
+}
+
+// cells returns the range of cells that [min, max) overlaps along one axis.
+func cells(min, max, size float64) (int, int) {
+	return int(math.Floor(min / size)), int(math.Ceil(max/size)) - 1
+}
+
+func (m *mover) cell(x, y int) gfx.Rect {
+	min := gfx.V(float64(x)*m.size.X, float64(y)*m.size.Y)
+	return gfx.Rect{Min: min, Max: min.Add(m.size)}
+}
+
+// wall reports whether any solid tile in column x overlaps the box's rows.
+func (m *mover) wall(x int, box gfx.Rect) bool {
+	y0, y1 := cells(box.Min.Y, box.Max.Y, m.size.Y)
+	for y := y0; y <= y1; y++ {
+		if m.grid.Tile(x, y).Shape == TileSolid {
+			return true
+		}
+	}
+	return false
+}
+
+// moveX moves the box horizontally, stepping up ledges if grounded. It
+// reports false if it was stopped by a wall.
+func (m *mover) moveX(dx float64, grounded bool, stepHeight float64) bool {
+	var x0, x1, step int
+	if dx > 0 {
+		x0, x1, step = int(math.Ceil(m.box.Max.X/m.size.X)), int(math.Ceil((m.box.Max.X+dx)/m.size.X))-1, 1
+	} else {
+		x0, x1, step = int(math.Floor(m.box.Min.X/m.size.X))-1, int(math.Floor((m.box.Min.X+dx)/m.size.X)), -1
+	}
+	for x := x0; x*step <= x1*step; x += step {
+		if !m.wall(x, m.box) {
+			continue
+		}
+		if grounded && stepHeight > 0 {
+			if rise, ok := m.stepUp(x, stepHeight); ok {
+				m.box = m.box.Sub(gfx.V(0, rise))
+				continue
+			}
+		}
+		edge := m.cell(x, 0).Min.X - m.box.Max.X
+		if step < 0 {
+			edge = m.cell(x, 0).Max.X - m.box.Min.X
+		}
+		m.box = m.box.Add(gfx.V(edge, 0))
+		return false
+	}
+	m.box = m.box.Add(gfx.V(dx, 0))
+	return true
+}
+
+// stepUp returns how far the box must rise to stand on the solid tiles of
+// column x, if that is no more than maxRise and there is room.
+func (m *mover) stepUp(x int, maxRise float64) (float64, bool) {
+	_, y := cells(m.box.Min.Y, m.box.Max.Y, m.size.Y)
+	// Columns taller than maxRise cannot be stepped onto, so there is no
+	// need to find their top, which an endless wall does not have.
+	limit := int(math.Ceil(maxRise/m.size.Y)) + 1
+	for i := 0; m.grid.Tile(x, y).Shape == TileSolid; i++ {
+		if i == limit {
+			return 0, false
+		}
+		y--
+	}
+	rise := m.box.Max.Y - m.cell(x, y).Max.Y
+	if rise <= 0 || rise > maxRise+1e-9 {
+		return 0, false
+	}
+	raised := m.box.Sub(gfx.V(0, rise))
+	x0, x1 := cells(raised.Min.X, raised.Max.X, m.size.X)
+	for cx := minInt(x0, x); cx <= maxInt(x1, x); cx++ {
+		if m.wall(cx, raised) {
+			return 0, false
+		}
+	}
+	return rise, true
+}
+
+// climb lifts the box onto the floor of a slope that it walked into.
+func (m *mover) climb() {
+	foot := gfx.V(m.box.Center().X, m.box.Max.Y)
+	x, y := int(math.Floor(foot.X/m.size.X)), int(math.Floor((foot.Y-1e-9)/m.size.Y))
+	t := m.grid.Tile(x, y)
+	if t.Shape != TileSlope {
+		return
+	}
+	if floor := t.floor(m.cell(x, y), foot.X); floor < foot.Y {
+		m.box = m.box.Sub(gfx.V(0, foot.Y-floor))
+	}
+}
+
+// moveDown moves the box down by dy, and reports whether it landed. Rows
+// whose top is at the target are landed on too, so that a box resting on the
+// ground stays there when dy is zero.
+func (m *mover) moveDown(dy float64, dropThrough bool) bool {
+	bottom, target := m.box.Max.Y, m.box.Max.Y+dy
+	land, landed := target, false
+	x0, x1 := cells(m.box.Min.X, m.box.Max.X, m.size.X)
+	y0, y1 := int(math.Ceil(bottom/m.size.Y)), int(math.Floor(target/m.size.Y))
+	for y := y0; y <= y1 && !landed; y++ {
+		for x := x0; x <= x1; x++ {
+			top := m.cell(x, y).Min.Y
+			switch m.grid.Tile(x, y).Shape {
+			case TileSolid:
+				land, landed = top, true
+			case TileOneWay:
+				if !dropThrough && bottom <= top {
+					land, landed = top, true
+				}
+			}
+		}
+	}
+
+	// Slopes are only stood on at the middle of the box's bottom edge.
+	m.slope = false
+	cx := m.box.Center().X
+	sx := int(math.Floor(cx / m.size.X))
+	for y := int(math.Floor((bottom - 1e-9) / m.size.Y)); y <= y1; y++ {
+		t := m.grid.Tile(sx, y)
+		if t.Shape != TileSlope {
+			continue
+		}
+		if floor := t.floor(m.cell(sx, y), cx); floor >= bottom-1e-9 && floor <= land {
+			land, m.slope = floor, true
+		}
+	}
+
+	m.box = m.box.Add(gfx.V(0, land-bottom))
+	return landed || m.slope
+}
+
+// moveUp moves the box up by dy, which is negative, and reports false if it
+// hit a ceiling.
+func (m *mover) moveUp(dy float64) bool {
+	top, target := m.box.Min.Y, m.box.Min.Y+dy
+	x0, x1 := cells(m.box.Min.X, m.box.Max.X, m.size.X)
+	y0, y1 := int(math.Floor(top/m.size.Y))-1, int(math.Floor(target/m.size.Y))
+	for y := y0; y >= y1; y-- {
+		for x := x0; x <= x1; x++ {
+			if s := m.grid.Tile(x, y).Shape; s == TileSolid || s == TileSlope {
+				m.box = m.box.Add(gfx.V(0, m.cell(x, y).Max.Y-top))
+				return false
+			}
+		}
+	}
+	m.box = m.box.Add(gfx.V(0, dy))
+	return true
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
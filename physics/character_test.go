@@ -0,0 +1,195 @@
+package physics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jncornett/bit/gfx"
+)
+
+// asciiGrid is a grid of 10 by 10 tiles drawn as text: '#' is solid, '-' is
+// one-way, '/' and '\' are slopes rising to the right and left. Cells
+// outside of it are empty.
+type asciiGrid []string
+
+func (g asciiGrid) TileSize() gfx.Vec { return gfx.V(10, 10) }
+
+func (g asciiGrid) Tile(x, y int) Tile {
+	if y < 0 || y >= len(g) || x < 0 || x >= len(g[y]) {
+		return Tile{}
+	}
+	switch g[y][x] {
+	case '#':
+		return Tile{Shape: TileSolid}
+	case '-':
+		return Tile{Shape: TileOneWay}
+	case '/':
+		return Tile{Shape: TileSlope, Left: 0, Right: 1}
+	case '\\':
+		return Tile{Shape: TileSlope, Left: 1, Right: 0}
+	}
+	return Tile{}
+}
+
+func TestCharacterUpdate(t *testing.T) {
+	floor := asciiGrid{
+		"..........",
+		"..........",
+		"..........",
+		"..........",
+		"##########",
+	}
+	// An 8 by 8 character standing on the floor in column 2.
+	standing := gfx.R(20, 32, 28, 40)
+	wall := SolidGrid{Size: gfx.V(10, 10), Solid: func(x, y int) bool { return x == 3 || y == 4 }}
+	for _, tc := range []struct {
+		name string
+		grid TileGrid
+		c    Character
+		want gfx.Rect
+		// What the character touches afterwards.
+		ground, ceiling, wallRight, slope bool
+		vel                               gfx.Vec
+	}{
+		{
+			name: "stand", grid: floor,
+			c:    Character{Box: standing, OnGround: true},
+			want: standing, ground: true,
+		},
+		{
+			name: "stand on one-way", grid: asciiGrid{"....", "....", "....", "....", "----"},
+			c:    Character{Box: standing, OnGround: true},
+			want: standing, ground: true,
+		},
+		{
+			name: "land exactly", grid: floor,
+			c:    Character{Box: standing.Sub(gfx.V(0, 5)), Vel: gfx.V(0, 50)},
+			want: standing, ground: true,
+		},
+		{
+			name: "walk", grid: floor,
+			c:    Character{Box: standing, Vel: gfx.V(100, 50), OnGround: true},
+			want: standing.Add(gfx.V(10, 0)), ground: true, vel: gfx.V(100, 0),
+		},
+		{
+			name: "walk into wall", grid: asciiGrid{"...#", "...#", "...#", "...#", "####"},
+			c:    Character{Box: standing, Vel: gfx.V(100, 50), OnGround: true, StepHeight: 10},
+			want: standing.Add(gfx.V(2, 0)), ground: true, wallRight: true,
+		},
+		{
+			name: "step up ledge", grid: asciiGrid{"....", "....", "....", "...#", "####"},
+			c:    Character{Box: standing, Vel: gfx.V(100, 50), OnGround: true, StepHeight: 10},
+			want: standing.Add(gfx.V(10, -10)), ground: true, vel: gfx.V(100, 0),
+		},
+		{
+			name: "ledge too high", grid: asciiGrid{"....", "....", "...#", "...#", "####"},
+			c:    Character{Box: standing, Vel: gfx.V(100, 50), OnGround: true, StepHeight: 10},
+			want: standing.Add(gfx.V(2, 0)), ground: true, wallRight: true,
+		},
+		{
+			name: "no room above ledge", grid: asciiGrid{"....", "....", "..##", "...#", "####"},
+			c:    Character{Box: standing, Vel: gfx.V(100, 50), OnGround: true, StepHeight: 10},
+			want: standing.Add(gfx.V(2, 0)), ground: true, wallRight: true,
+		},
+		{
+			name: "ledge while falling", grid: asciiGrid{"....", "....", "....", "...#", "####"},
+			c:    Character{Box: standing.Sub(gfx.V(0, 1)), Vel: gfx.V(100, 50), StepHeight: 10},
+			want: standing.Add(gfx.V(2, 0)), ground: true, wallRight: true,
+		},
+		{
+			// The wall has no top, so stepping up must give up.
+			name: "endless wall", grid: wall,
+			c:    Character{Box: standing, Vel: gfx.V(100, 50), OnGround: true, StepHeight: 25},
+			want: standing.Add(gfx.V(2, 0)), ground: true, wallRight: true,
+		},
+		{
+			// The middle of the bottom edge ends up 4 along the slope.
+			name: "walk up slope", grid: asciiGrid{"....", "....", "....", ".../", "####"},
+			c:    Character{Box: standing, Vel: gfx.V(100, 50), OnGround: true},
+			want: standing.Add(gfx.V(10, -4)), ground: true, slope: true, vel: gfx.V(100, 0),
+		},
+		{
+			name: "walk down slope", grid: asciiGrid{"....", "....", "....", "...\\", "####"},
+			c:    Character{Box: gfx.R(28, 24, 36, 32), Vel: gfx.V(40, 0), OnGround: true, SnapDistance: 10},
+			want: gfx.R(32, 28, 40, 36), ground: true, slope: true, vel: gfx.V(40, 0),
+		},
+		{
+			name: "walk off slope", grid: asciiGrid{"....", "....", "....", "...\\", "####"},
+			// Standing 2 along the slope, where it is 8 high, and walking
+			// off its bottom onto the floor.
+			c:    Character{Box: gfx.R(28, 24, 36, 32), Vel: gfx.V(100, 0), OnGround: true, SnapDistance: 10},
+			want: gfx.R(38, 32, 46, 40), ground: true, vel: gfx.V(100, 0),
+		},
+		{
+			name: "jump through one-way", grid: asciiGrid{"....", "....", "----", "....", "####"},
+			c:    Character{Box: standing, Vel: gfx.V(0, -300), OnGround: true},
+			want: standing.Sub(gfx.V(0, 30)), vel: gfx.V(0, -300),
+		},
+		{
+			name: "land on one-way", grid: asciiGrid{"....", "....", "----", "....", "####"},
+			c:    Character{Box: standing.Sub(gfx.V(0, 22)), Vel: gfx.V(0, 50)},
+			want: standing.Sub(gfx.V(0, 20)), ground: true,
+		},
+		{
+			name: "drop through one-way", grid: asciiGrid{"....", "....", "----", "....", "####"},
+			c:    Character{Box: standing.Sub(gfx.V(0, 20)), Vel: gfx.V(0, 50), OnGround: true, DropThrough: true},
+			want: standing.Sub(gfx.V(0, 15)), vel: gfx.V(0, 50),
+		},
+		{
+			name: "hit ceiling", grid: asciiGrid{"....", "....", "####", "....", "####"},
+			c:    Character{Box: standing, Vel: gfx.V(0, -300), OnGround: true},
+			want: standing.Sub(gfx.V(0, 2)), ceiling: true,
+		},
+	} {
+		c := tc.c
+		c.Update(tc.grid, 100*time.Millisecond)
+		if !c.Box.Min.ApproxEqual(tc.want.Min, 1e-9) || !c.Box.Max.ApproxEqual(tc.want.Max, 1e-9) {
+			t.Errorf("%s: box %v, want %v", tc.name, c.Box, tc.want)
+		}
+		if c.OnGround != tc.ground || c.OnCeiling != tc.ceiling || c.OnWallRight != tc.wallRight || c.OnSlope != tc.slope || c.OnWallLeft {
+			t.Errorf("%s: ground %v, ceiling %v, wall %v/%v, slope %v", tc.name, c.OnGround, c.OnCeiling, c.OnWallLeft, c.OnWallRight, c.OnSlope)
+		}
+		if c.Vel != tc.vel {
+			t.Errorf("%s: velocity %v, want %v", tc.name, c.Vel, tc.vel)
+		}
+	}
+}
+
+func TestCharacterCoyoteTime(t *testing.T) {
+	// A floor that ends after column 2.
+	g := asciiGrid{"....", "###."}
+	c := Character{Box: gfx.R(20, 2, 28, 10), Vel: gfx.V(50, 10), OnGround: true, CoyoteTime: 150 * time.Millisecond}
+	for i := 0; i < 2; i++ {
+		c.Update(g, 100*time.Millisecond)
+	}
+	if c.OnGround || !c.CanJump() {
+		t.Fatalf("after walking off: ground %v, can jump %v, since ground %v", c.OnGround, c.CanJump(), c.SinceGround)
+	}
+	c.Update(g, 100*time.Millisecond)
+	if c.CanJump() || c.Jump(100) {
+		t.Errorf("can still jump %v after leaving the ground", c.SinceGround)
+	}
+}
+
+func TestCharacterMidairSpawn(t *testing.T) {
+	g := asciiGrid{"....", "....", "....", "####"}
+	c := Character{Box: gfx.R(10, 0, 18, 8), CoyoteTime: 150 * time.Millisecond}
+	if c.CanJump() || c.Jump(100) {
+		t.Fatalf("jumped in midair before falling")
+	}
+	c.Update(g, 10*time.Millisecond)
+	if c.CanJump() || c.Jump(100) {
+		t.Fatalf("jumped in midair while falling")
+	}
+	c.Vel.Y = 200
+	for i := 0; i < 10 && !c.OnGround; i++ {
+		c.Update(g, 100*time.Millisecond)
+	}
+	if !c.OnGround || !c.Landed || !c.Jump(100) {
+		t.Fatalf("cannot jump after landing: ground %v, landed %v", c.OnGround, c.Landed)
+	}
+	c.Update(g, 10*time.Millisecond)
+	if c.CanJump() {
+		t.Errorf("can jump again in midair after jumping")
+	}
+}
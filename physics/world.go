@@ -0,0 +1,299 @@
+package physics
+
+import (
+	"math"
+	"time"
+
+	"github.com/jncornett/bit/gfx"
+	"github.com/jncornett/bit/spatial"
+)
+
+const (
+	DefaultStep       = time.Second / 60
+	DefaultMaxSteps   = 5
+	DefaultIterations = 8
+)
+
+// World holds bodies and advances them in fixed steps.
+type World struct {
+	Gravity gfx.Vec
+	// Step is the fixed time step. Zero is DefaultStep.
+	Step time.Duration
+	// MaxSteps limits how many steps Advance takes at once. Zero is
+	// DefaultMaxSteps.
+	MaxSteps int
+	// Iterations is how many times collisions are resolved per step.
+	// Zero is DefaultIterations.
+	Iterations int
+	// Slop is how far bodies may overlap before they are pushed apart,
+	// which keeps resting contacts stable.
+	Slop float64
+	// Correction is the fraction of the remaining overlap that is removed
+	// each step.
+	Correction float64
+	// BounceThreshold is the slowest impact speed that bounces, so that
+	// resting bodies do not jitter.
+	BounceThreshold float64
+
+	// OnContact is called once per step for every pair of colliding
+	// bodies, with the normal pointing from a to b.
+	OnContact func(a, b BodyID, m gfx.Manifold)
+	// OnEnter and OnExit are called when a body starts or stops
+	// overlapping a sensor.
+	OnEnter, OnExit func(sensor, other BodyID)
+
+	bodies  map[BodyID]*Body
+	order   []BodyID
+	index   map[BodyID]int
+	broad   *spatial.AABBTree[BodyID]
+	next    BodyID
+	acc     time.Duration
+	sensing []sensorPair
+	sensed  map[sensorPair]bool
+}
+
+type sensorPair struct {
+	sensor, other BodyID
+}
+
+type contact struct {
+	a, b     *Body
+	m        gfx.Manifold
+	mass     float64
+	bounce   float64
+	friction float64
+	normal   float64
+	tangent  float64
+}
+
+func NewWorld(gravity gfx.Vec) *World {
+	return &World{
+		Gravity:         gravity,
+		Slop:            0.01,
+		Correction:      0.8,
+		BounceThreshold: 1,
+		bodies:          make(map[BodyID]*Body),
+		index:           make(map[BodyID]int),
+		broad:           spatial.NewAABBTree[BodyID](),
+		sensed:          make(map[sensorPair]bool),
+	}
+}
+
+// Add adds a copy of b to the world.
+func (w *World) Add(b Body) BodyID {
+	w.next++
+	id := w.next
+	w.bodies[id] = &b
+	w.index[id] = len(w.order)
+	w.order = append(w.order, id)
+	w.broad.Insert(id, b.Bounds())
+	return id
+}
+
+// Remove removes a body. No OnExit is reported for sensors it overlapped.
+func (w *World) Remove(id BodyID) {
+	i, ok := w.index[id]
+	if !ok {
+		return
+	}
+	copy(w.order[i:], w.order[i+1:])
+	w.order = w.order[:len(w.order)-1]
+	for _, other := range w.order[i:] {
+		w.index[other]--
+	}
+	delete(w.index, id)
+	delete(w.bodies, id)
+	w.broad.Remove(id)
+	kept := w.sensing[:0]
+	for _, p := range w.sensing {
+		if p.sensor == id || p.other == id {
+			delete(w.sensed, p)
+			continue
+		}
+		kept = append(kept, p)
+	}
+	w.sensing = kept
+}
+
+// Body returns the body with the given id, which may be changed directly, or
+// nil if there is none.
+func (w *World) Body(id BodyID) *Body { return w.bodies[id] }
+
+// Bodies returns the ids of every body, in the order they were added.
+func (w *World) Bodies() []BodyID { return w.order }
+
+func (w *World) step() time.Duration {
+	if w.Step <= 0 {
+		return DefaultStep
+	}
+	return w.Step
+}
+
+// Advance accumulates d, such as the delta of a frame's tick, and takes as
+// many fixed steps as are due, which it returns. If more than MaxSteps are
+// due, the backlog is dropped.
+func (w *World) Advance(d time.Duration) int {
+	step := w.step()
+	maxSteps := w.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+	w.acc += d
+	steps := int(w.acc / step)
+	if steps > maxSteps {
+		steps = maxSteps
+		w.acc %= step
+	} else {
+		w.acc -= time.Duration(steps) * step
+	}
+	for i := 0; i < steps; i++ {
+		w.StepOnce()
+	}
+	return steps
+}
+
+// Alpha returns how far, in [0, 1), the time Advance has accumulated is into
+// the next step, for interpolating positions when rendering.
+func (w *World) Alpha() float64 { return float64(w.acc) / float64(w.step()) }
+
+// StepOnce advances the world by a single fixed step.
+func (w *World) StepOnce() {
+	dt := w.step().Seconds()
+	for _, id := range w.order {
+		b := w.bodies[id]
+		if b.Type == Dynamic {
+			g := w.Gravity.Mul(b.gravityScale())
+			b.Vel = b.Vel.Add(g.Add(b.force.Mul(b.invMass())).Mul(dt))
+		}
+		b.force = gfx.Vec{}
+		w.broad.Move(id, b.Bounds())
+	}
+
+	contacts := w.collide()
+
+	iterations := w.Iterations
+	if iterations <= 0 {
+		iterations = DefaultIterations
+	}
+	for i := 0; i < iterations; i++ {
+		for j := range contacts {
+			contacts[j].solve()
+		}
+	}
+
+	for _, id := range w.order {
+		if b := w.bodies[id]; b.Type != Static {
+			b.Pos = b.Pos.Add(b.Vel.Mul(dt))
+		}
+	}
+	for _, c := range contacts {
+		depth := math.Max(c.m.Depth-w.Slop, 0) * w.Correction / c.mass
+		if depth == 0 {
+			continue
+		}
+		c.a.Pos = c.a.Pos.Sub(c.m.Normal.Mul(depth * c.a.invMass()))
+		c.b.Pos = c.b.Pos.Add(c.m.Normal.Mul(depth * c.b.invMass()))
+	}
+}
+
+// collide finds the colliding pairs of bodies, and reports sensor changes.
+func (w *World) collide() []contact {
+	var contacts []contact
+	var sensing []sensorPair
+	w.broad.Pairs(func(ia, ib BodyID) {
+		a, b := w.bodies[ia], w.bodies[ib]
+		if a.Shape == nil || b.Shape == nil || a.Sensor && b.Sensor {
+			return
+		}
+		if !a.Sensor && !b.Sensor && a.invMass() == 0 && b.invMass() == 0 {
+			return
+		}
+		m, ok := gfx.Collide(a.WorldShape(), b.WorldShape())
+		if !ok {
+			return
+		}
+		switch {
+		case a.Sensor:
+			sensing = append(sensing, sensorPair{ia, ib})
+			return
+		case b.Sensor:
+			sensing = append(sensing, sensorPair{ib, ia})
+			return
+		}
+		if w.OnContact != nil {
+			w.OnContact(ia, ib, m)
+		}
+		c := contact{
+			a:        a,
+			b:        b,
+			m:        m,
+			mass:     a.invMass() + b.invMass(),
+			friction: math.Sqrt(a.Friction * b.Friction),
+		}
+		if vn := b.Vel.Sub(a.Vel).Dot(m.Normal); -vn > w.BounceThreshold {
+			c.bounce = -vn * math.Max(a.Restitution, b.Restitution)
+		}
+		contacts = append(contacts, c)
+	})
+
+	now := make(map[sensorPair]bool, len(sensing))
+	for _, p := range sensing {
+		now[p] = true
+	}
+	for _, p := range w.sensing {
+		if !now[p] && w.OnExit != nil {
+			w.OnExit(p.sensor, p.other)
+		}
+	}
+	for _, p := range sensing {
+		if !w.sensed[p] && w.OnEnter != nil {
+			w.OnEnter(p.sensor, p.other)
+		}
+	}
+	w.sensing, w.sensed = sensing, now
+	return contacts
+}
+
+// solve applies impulses along the contact normal and tangent, accumulating
+// them so that later iterations can correct earlier ones.
+func (c *contact) solve() {
+	rel := c.b.Vel.Sub(c.a.Vel)
+	n := c.m.Normal
+	j := (c.bounce - rel.Dot(n)) / c.mass
+	old := c.normal
+	c.normal = math.Max(old+j, 0)
+	c.apply(n.Mul(c.normal - old))
+
+	rel = c.b.Vel.Sub(c.a.Vel)
+	t := n.Perp()
+	jt := -rel.Dot(t) / c.mass
+	limit := c.friction * c.normal
+	old = c.tangent
+	c.tangent = math.Max(-limit, math.Min(limit, old+jt))
+	c.apply(t.Mul(c.tangent - old))
+}
+
+func (c *contact) apply(j gfx.Vec) {
+	c.a.Vel = c.a.Vel.Sub(j.Mul(c.a.invMass()))
+	c.b.Vel = c.b.Vel.Add(j.Mul(c.b.invMass()))
+}
+
+// Raycast returns the first non-sensor body hit by ray at or before maxT.
+func (w *World) Raycast(ray gfx.Ray, maxT float64) (BodyID, gfx.RayHit, bool) {
+	var best BodyID
+	hit := gfx.RayHit{T: math.Inf(1)}
+	w.broad.Ray(ray, maxT, func(id BodyID, t float64) bool {
+		b := w.bodies[id]
+		if b.Sensor || b.Shape == nil || t > hit.T {
+			return true
+		}
+		if h, ok := b.WorldShape().Raycast(ray); ok && h.T <= maxT && (h.T < hit.T || h.T == hit.T && id < best) {
+			best, hit = id, h
+		}
+		return true
+	})
+	return best, hit, best != 0
+}
+
+// Query calls f with every body whose bounds overlap r, until f returns false.
+func (w *World) Query(r gfx.Rect, f func(id BodyID) bool) { w.broad.Query(r, f) }
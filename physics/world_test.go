@@ -0,0 +1,199 @@
+package physics
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jncornett/bit/gfx"
+)
+
+// pile returns a world with a floor, two walls and a pile of boxes and
+// circles dropped onto it, some of them bouncy.
+func pile() *World {
+	w := NewWorld(gfx.V(0, 500))
+	w.Add(Body{Type: Static, Pos: gfx.V(0, 200), Shape: gfx.R(-200, 0, 200, 20), Friction: 0.5})
+	w.Add(Body{Type: Static, Pos: gfx.V(-200, 0), Shape: gfx.R(-20, -400, 0, 220)})
+	w.Add(Body{Type: Static, Pos: gfx.V(200, 0), Shape: gfx.R(0, -400, 20, 220)})
+	w.Add(Body{Type: Kinematic, Pos: gfx.V(-150, 150), Vel: gfx.V(40, 0), Shape: gfx.R(0, 0, 60, 10)})
+	for i := 0; i < 40; i++ {
+		b := Body{
+			Pos:         gfx.V(float64(i%8*40-150)+float64(i%3), float64(-i/8*30)),
+			Vel:         gfx.V(float64(i%5-2)*10, 0),
+			Mass:        1 + float64(i%4),
+			Restitution: float64(i%3) * 0.3,
+			Friction:    0.4,
+		}
+		if i%2 == 0 {
+			b.Shape = gfx.FromCenter(gfx.Vec{}, gfx.V(12, 10))
+		} else {
+			b.Shape = gfx.Circle{Radius: 6}
+		}
+		w.Add(b)
+	}
+	return w
+}
+
+// state formats the exact bits of every body's position and velocity.
+func state(w *World) string {
+	var s string
+	for _, id := range w.Bodies() {
+		b := w.Body(id)
+		s += fmt.Sprintf("%d:%x,%x,%x,%x ", id,
+			math.Float64bits(b.Pos.X), math.Float64bits(b.Pos.Y),
+			math.Float64bits(b.Vel.X), math.Float64bits(b.Vel.Y))
+	}
+	return s
+}
+
+func TestWorldDeterministic(t *testing.T) {
+	run := func() (string, []string) {
+		w := pile()
+		var contacts []string
+		w.OnContact = func(a, b BodyID, m gfx.Manifold) {
+			contacts = append(contacts, fmt.Sprint(a, b, m.Count))
+		}
+		// Uneven frame times, as a real game would have.
+		for i := 0; i < 300; i++ {
+			w.Advance(time.Duration(10+i%13) * time.Millisecond)
+			if i == 100 {
+				w.Body(10).ApplyImpulse(gfx.V(0, -300))
+				w.Remove(20)
+			}
+		}
+		return state(w), contacts
+	}
+	s1, c1 := run()
+	s2, c2 := run()
+	if s1 != s2 {
+		t.Errorf("body states differ between runs:\n%s\n%s", s1, s2)
+	}
+	if fmt.Sprint(c1) != fmt.Sprint(c2) {
+		t.Error("contacts differ between runs")
+	}
+	if len(c1) == 0 {
+		t.Error("no contacts were reported")
+	}
+}
+
+func TestWorldAdvance(t *testing.T) {
+	w := NewWorld(gfx.Vec{})
+	w.Step = 10 * time.Millisecond
+	for _, tc := range []struct {
+		d     time.Duration
+		steps int
+		alpha float64
+	}{
+		{5 * time.Millisecond, 0, 0.5},
+		{5 * time.Millisecond, 1, 0},
+		{25 * time.Millisecond, 2, 0.5},
+		// More than DefaultMaxSteps are due, so the backlog is dropped.
+		{time.Second + 2*time.Millisecond, DefaultMaxSteps, 0.7},
+	} {
+		if got := w.Advance(tc.d); got != tc.steps {
+			t.Errorf("Advance(%v) = %d, want %d", tc.d, got, tc.steps)
+		}
+		if got := w.Alpha(); math.Abs(got-tc.alpha) > 1e-9 {
+			t.Errorf("after Advance(%v), Alpha = %g, want %g", tc.d, got, tc.alpha)
+		}
+	}
+}
+
+func TestWorldRestingContact(t *testing.T) {
+	w := NewWorld(gfx.V(0, 100))
+	w.Add(Body{Type: Static, Shape: gfx.R(-50, 0, 50, 10)})
+	box := w.Add(Body{Pos: gfx.V(0, -20), Shape: gfx.FromCenter(gfx.Vec{}, gfx.V(10, 10))})
+	for i := 0; i < 240; i++ {
+		w.StepOnce()
+	}
+	b := w.Body(box)
+	if math.Abs(b.Pos.Y+5) > 0.1 || math.Abs(b.Vel.Y) > 1 {
+		t.Errorf("box rests at %v moving %v, want it on the floor at y -5", b.Pos, b.Vel)
+	}
+}
+
+func TestWorldSensors(t *testing.T) {
+	w := NewWorld(gfx.V(0, 100))
+	var events []string
+	w.OnEnter = func(sensor, other BodyID) { events = append(events, fmt.Sprint("enter ", sensor, " ", other)) }
+	w.OnExit = func(sensor, other BodyID) { events = append(events, fmt.Sprint("exit ", sensor, " ", other)) }
+	w.OnContact = func(a, b BodyID, _ gfx.Manifold) { t.Errorf("bodies %d and %d collided with a sensor", a, b) }
+
+	sensor := w.Add(Body{Type: Static, Sensor: true, Shape: gfx.R(-10, 0, 10, 20)})
+	other := w.Add(Body{Type: Static, Sensor: true, Shape: gfx.R(-10, 0, 10, 20)})
+	ball := w.Add(Body{Pos: gfx.V(0, -10), Shape: gfx.Circle{Radius: 2}})
+	step := func(want ...string) {
+		t.Helper()
+		events = nil
+		w.StepOnce()
+		if fmt.Sprint(events) != fmt.Sprint(want) {
+			t.Errorf("events = %v, want %v", events, want)
+		}
+	}
+	// next steps until something is reported, and returns where the ball
+	// was at the start of that step, when overlaps are found.
+	next := func() float64 {
+		t.Helper()
+		for i := 0; i < 100; i++ {
+			y := w.Body(ball).Pos.Y
+			events = nil
+			w.StepOnce()
+			if len(events) > 0 {
+				return y
+			}
+		}
+		t.Fatal("nothing was reported")
+		return 0
+	}
+	both := func(what string, id BodyID) string {
+		return fmt.Sprint([]string{fmt.Sprint(what, " ", sensor, " ", id), fmt.Sprint(what, " ", other, " ", id)})
+	}
+
+	// Falling into both sensors, which do not sense each other. The ball's
+	// bottom enters them at y -2, and its top leaves them at 22.
+	if y := next(); y <= -2 || y > 0 || fmt.Sprint(events) != both("enter", ball) {
+		t.Errorf("at y %.2f: %v, want %s", y, events, both("enter", ball))
+	}
+	if y := next(); y < 22 || y > 24 || fmt.Sprint(events) != both("exit", ball) {
+		t.Errorf("at y %.2f: %v, want %s", y, events, both("exit", ball))
+	}
+	step()
+
+	// Removing a body inside a sensor reports no exit, and it can enter
+	// again once added back.
+	w.Body(ball).Pos, w.Body(ball).Vel = gfx.V(0, 10), gfx.Vec{}
+	step(fmt.Sprint("enter ", sensor, " ", ball), fmt.Sprint("enter ", other, " ", ball))
+	step()
+	w.Remove(ball)
+	step()
+	again := w.Add(Body{Pos: gfx.V(0, 10), Shape: gfx.Circle{Radius: 2}})
+	step(fmt.Sprint("enter ", sensor, " ", again), fmt.Sprint("enter ", other, " ", again))
+	w.Remove(other)
+	step()
+}
+
+func TestWorldRaycast(t *testing.T) {
+	w := NewWorld(gfx.Vec{})
+	w.Add(Body{Type: Static, Sensor: true, Shape: gfx.R(0, 0, 10, 10)})
+	far := w.Add(Body{Type: Static, Pos: gfx.V(40, 0), Shape: gfx.R(0, 0, 10, 10)})
+	near := w.Add(Body{Type: Static, Pos: gfx.V(20, 5), Shape: gfx.Circle{Radius: 3}})
+	ray := gfx.Ray{Origin: gfx.V(-10, 5), Dir: gfx.V(1, 0)}
+	if id, hit, ok := w.Raycast(ray, 100); !ok || id != near || math.Abs(hit.T-27) > 1e-9 {
+		t.Errorf("Raycast = %d, %+v, %v, want %d at 27", id, hit, ok, near)
+	}
+	w.Remove(near)
+	if id, hit, ok := w.Raycast(ray, 100); !ok || id != far || hit.T != 50 {
+		t.Errorf("Raycast = %d, %+v, %v, want %d at 50", id, hit, ok, far)
+	}
+	if _, _, ok := w.Raycast(ray, 49); ok {
+		t.Error("Raycast hit beyond maxT")
+	}
+}
+
+func BenchmarkWorldStep(b *testing.B) {
+	w := pile()
+	for i := 0; i < b.N; i++ {
+		w.StepOnce()
+	}
+}
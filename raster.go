@@ -0,0 +1,167 @@
+package bit
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/jncornett/bit/gfx"
+)
+
+// blend composites c over the pixel at offset i of dst.Pix.
+func blend(pix []uint8, i int, c color.NRGBA) {
+	if c.A == 0xff {
+		pix[i+0], pix[i+1], pix[i+2], pix[i+3] = c.R, c.G, c.B, 0xff
+		return
+	}
+	if c.A == 0 {
+		return
+	}
+	sa := uint32(c.A)
+	da := uint32(pix[i+3]) * (0xff - sa) / 0xff
+	a := sa + da
+	pix[i+0] = uint8((uint32(c.R)*sa + uint32(pix[i+0])*da) / a)
+	pix[i+1] = uint8((uint32(c.G)*sa + uint32(pix[i+1])*da) / a)
+	pix[i+2] = uint8((uint32(c.B)*sa + uint32(pix[i+2])*da) / a)
+	pix[i+3] = uint8(a)
+}
+
+// plot blends c over the pixel at (x, y), if it is inside dst.
+func plot(dst *image.NRGBA, x, y int, c color.NRGBA) {
+	if (image.Point{x, y}).In(dst.Rect) {
+		blend(dst.Pix, dst.PixOffset(x, y), c)
+	}
+}
+
+// hline blends c over the pixels from x0 to x1 inclusive on row y.
+func hline(dst *image.NRGBA, x0, x1, y int, c color.NRGBA) {
+	b := dst.Rect
+	if y < b.Min.Y || y >= b.Max.Y {
+		return
+	}
+	if x0 < b.Min.X {
+		x0 = b.Min.X
+	}
+	if x1 >= b.Max.X {
+		x1 = b.Max.X - 1
+	}
+	for i, end := dst.PixOffset(x0, y), dst.PixOffset(x1, y); i <= end; i += 4 {
+		blend(dst.Pix, i, c)
+	}
+}
+
+func fillRect(dst *image.NRGBA, r image.Rectangle, c color.NRGBA) {
+	r = r.Intersect(dst.Rect)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		hline(dst, r.Min.X, r.Max.X-1, y, c)
+	}
+}
+
+// circleSpan returns the pixels of row y whose centers are within r of center.
+func circleSpan(center [2]float64, r float64, y int) (x0, x1 int, ok bool) {
+	dy := float64(y) + 0.5 - center[1]
+	if dy < -r || dy > r {
+		return 0, 0, false
+	}
+	w := math.Sqrt(r*r - dy*dy)
+	x0 = int(math.Ceil(center[0] - w - 0.5))
+	x1 = int(math.Floor(center[0] + w - 0.5))
+	return x0, x1, x0 <= x1
+}
+
+func fillCircle(dst *image.NRGBA, cx, cy, r float64, c color.NRGBA) {
+	center := [2]float64{cx, cy}
+	for y := int(math.Floor(cy - r)); y <= int(math.Ceil(cy+r)); y++ {
+		if x0, x1, ok := circleSpan(center, r, y); ok {
+			hline(dst, x0, x1, y, c)
+		}
+	}
+}
+
+func strokeCircle(dst *image.NRGBA, cx, cy, r, width float64, c color.NRGBA) {
+	center := [2]float64{cx, cy}
+	inner := r - width
+	for y := int(math.Floor(cy - r)); y <= int(math.Ceil(cy+r)); y++ {
+		x0, x1, ok := circleSpan(center, r, y)
+		if !ok {
+			continue
+		}
+		i0, i1, hole := circleSpan(center, inner, y)
+		if inner <= 0 || !hole {
+			hline(dst, x0, x1, y, c)
+			continue
+		}
+		hline(dst, x0, i0-1, y, c)
+		hline(dst, i1+1, x1, y, c)
+	}
+}
+
+// fillPolygon fills the pixels whose centers are inside a polygon, using the
+// even-odd rule.
+func fillPolygon(dst *image.NRGBA, pts []gfx.Vec, c color.NRGBA) {
+	if len(pts) < 3 {
+		return
+	}
+	minY, maxY := pts[0].Y, pts[0].Y
+	for _, p := range pts[1:] {
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	var xs []float64
+	for y := int(math.Floor(minY)); y <= int(math.Ceil(maxY)); y++ {
+		yc := float64(y) + 0.5
+		xs = xs[:0]
+		for i, a := range pts {
+			b := pts[(i+1)%len(pts)]
+			if (a.Y <= yc) != (b.Y <= yc) {
+				xs = append(xs, a.X+(yc-a.Y)*(b.X-a.X)/(b.Y-a.Y))
+			}
+		}
+		sort.Float64s(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			x0, x1 := int(math.Ceil(xs[i]-0.5)), int(math.Floor(xs[i+1]-0.5))
+			if x0 <= x1 {
+				hline(dst, x0, x1, y, c)
+			}
+		}
+	}
+}
+
+// line draws a one pixel wide line between two pixels with Bresenham's algorithm.
+func line(dst *image.NRGBA, p0, p1 image.Point, c color.NRGBA) {
+	dx, dy := abs(p1.X-p0.X), -abs(p1.Y-p0.Y)
+	sx, sy := sign(p1.X-p0.X), sign(p1.Y-p0.Y)
+	err := dx + dy
+	for {
+		plot(dst, p0.X, p0.Y, c)
+		if p0 == p1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			p0.X += sx
+		}
+		if e2 <= dx {
+			err += dx
+			p0.Y += sy
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func sign(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,311 @@
+package bit
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"time"
+
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"github.com/jncornett/bit/gfx"
+)
+
+// Replay files start with replayMagic followed by the format version as a
+// uvarint. The zero time of the first tick follows as a varint of Unix
+// nanoseconds, then one record per frame:
+//
+//	varint   tick[1] - tick[0] in nanoseconds
+//	varint   tick[2] - tick[1] in nanoseconds
+//	byte     flags (flagHashed)
+//	uint64   state hash, little endian, only if flagHashed is set
+//	uvarint  number of events, followed by each event
+//
+// Each event is its kind as a byte followed by the kind's fields. Key events
+// are the name as a uvarint length and bytes, the modifiers as a uvarint and
+// the state as a byte. Pointer events are the type as a uvarint, the position
+// as two float32s, the buttons as a byte and the scroll as two float32s, with
+// float32s stored as little endian bits.
+const (
+	replayMagic   = "bitrplay"
+	ReplayVersion = 1
+)
+
+const flagHashed = 1 << 0
+
+// maxKeyName is the longest key name a replay may hold, so that a corrupt
+// length cannot make ReadReplay allocate a huge buffer.
+const maxKeyName = 64
+
+var ErrBadReplay = errors.New("bit: malformed replay")
+
+// ErrControlledReplay is returned by Engine.Run for a game that is recorded
+// or replayed while it has a Control or a Debugger, since a replay cannot
+// reproduce their pauses, steps and rewinds.
+var ErrControlledReplay = errors.New("bit: cannot record or replay a game with a Control or Debugger")
+
+// DivergenceError is returned when a replayed game state does not hash to the
+// value recorded for that frame.
+type DivergenceError struct {
+	Frame     int
+	Want, Got uint64
+}
+
+func (e *DivergenceError) Error() string {
+	return fmt.Sprintf("bit: replay diverged at frame %d: state hash %016x, recorded %016x", e.Frame, e.Got, e.Want)
+}
+
+// HashJSON hashes the JSON encoding of v. Map keys are sorted by
+// encoding/json, so equal values hash equally, but unexported fields are
+// ignored.
+func HashJSON[T any](v T) (uint64, error) {
+	h := fnv.New64a()
+	if err := json.NewEncoder(h).Encode(v); err != nil {
+		return 0, err
+	}
+	return h.Sum64(), nil
+}
+
+// Recorder writes frames to a replay file.
+type Recorder struct {
+	w       *bufio.Writer
+	started bool
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func NewRecorder(w io.Writer) (*Recorder, error) {
+	r := &Recorder{w: bufio.NewWriter(w)}
+	if _, err := r.w.WriteString(replayMagic); err != nil {
+		return nil, err
+	}
+	r.uvarint(ReplayVersion)
+	return r, nil
+}
+
+// Record appends a frame. If hashed is set, hash is stored with the frame so
+// that a replay can detect divergence.
+func (r *Recorder) Record(f Frame, hash uint64, hashed bool) error {
+	// Check every event before writing any, so that a frame that cannot be
+	// recorded leaves the replay intact.
+	for _, e := range f.Events {
+		switch {
+		case e.Kind != EventKey && e.Kind != EventPointer:
+			return fmt.Errorf("bit: cannot record event kind %d", e.Kind)
+		case e.Kind == EventKey && len(e.Key.Name) > maxKeyName:
+			return fmt.Errorf("bit: cannot record key name longer than %d bytes: %q", maxKeyName, e.Key.Name)
+		}
+	}
+	if !r.started {
+		r.started = true
+		r.varint(f.Tick.Zero().UnixNano())
+	}
+	r.varint(int64(f.Tick[1].Sub(f.Tick[0])))
+	r.varint(int64(f.Tick[2].Sub(f.Tick[1])))
+	if hashed {
+		r.w.WriteByte(flagHashed)
+		binary.LittleEndian.PutUint64(r.scratch[:8], hash)
+		r.w.Write(r.scratch[:8])
+	} else {
+		r.w.WriteByte(0)
+	}
+	r.uvarint(uint64(len(f.Events)))
+	for _, e := range f.Events {
+		r.w.WriteByte(byte(e.Kind))
+		switch e.Kind {
+		case EventKey:
+			r.uvarint(uint64(len(e.Key.Name)))
+			r.w.WriteString(e.Key.Name)
+			r.uvarint(uint64(e.Key.Modifiers))
+			r.w.WriteByte(byte(e.Key.State))
+		case EventPointer:
+			r.uvarint(uint64(e.Pointer.Type))
+			r.float32(e.Pointer.Position.X)
+			r.float32(e.Pointer.Position.Y)
+			r.w.WriteByte(byte(e.Pointer.Buttons))
+			r.float32(e.Pointer.Scroll.X)
+			r.float32(e.Pointer.Scroll.Y)
+		}
+	}
+	// bufio.Writer errors are sticky, so checking once per frame is enough.
+	_, err := r.w.Write(nil)
+	return err
+}
+
+func (r *Recorder) Flush() error { return r.w.Flush() }
+
+func (r *Recorder) varint(v int64) {
+	n := binary.PutVarint(r.scratch[:], v)
+	r.w.Write(r.scratch[:n])
+}
+
+func (r *Recorder) uvarint(v uint64) {
+	n := binary.PutUvarint(r.scratch[:], v)
+	r.w.Write(r.scratch[:n])
+}
+
+func (r *Recorder) float32(v float64) {
+	binary.LittleEndian.PutUint32(r.scratch[:4], math.Float32bits(float32(v)))
+	r.w.Write(r.scratch[:4])
+}
+
+type ReplayFrame struct {
+	Frame
+	Hash   uint64
+	Hashed bool
+}
+
+// Replay is a recorded sequence of frames.
+type Replay struct {
+	Frames []ReplayFrame
+	// Realtime paces frames by their recorded deltas instead of producing
+	// them as fast as they are consumed.
+	Realtime bool
+}
+
+func ReadReplay(r io.Reader) (*Replay, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(replayMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != replayMagic {
+		return nil, ErrBadReplay
+	}
+	if v, err := binary.ReadUvarint(br); err != nil {
+		return nil, ErrBadReplay
+	} else if v != ReplayVersion {
+		return nil, fmt.Errorf("bit: unsupported replay version %d", v)
+	}
+	zero, err := binary.ReadVarint(br)
+	if err == io.EOF {
+		return &Replay{}, nil // nothing was recorded
+	} else if err != nil {
+		return nil, ErrBadReplay
+	}
+	t0 := time.Unix(0, zero)
+	var replay Replay
+	for {
+		var f ReplayFrame
+		if err := readReplayFrame(br, t0, &f); err == io.EOF {
+			return &replay, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: frame %d: %v", ErrBadReplay, len(replay.Frames), err)
+		}
+		replay.Frames = append(replay.Frames, f)
+	}
+}
+
+func readReplayFrame(r *bufio.Reader, t0 time.Time, f *ReplayFrame) error {
+	d1, err := binary.ReadVarint(r)
+	if err != nil {
+		return err // io.EOF here is a clean end of file
+	}
+	d2, err := binary.ReadVarint(r)
+	if err != nil {
+		return noEOF(err)
+	}
+	t1 := t0.Add(time.Duration(d1))
+	f.Tick = Tick{t0, t1, t1.Add(time.Duration(d2))}
+	flags, err := r.ReadByte()
+	if err != nil {
+		return noEOF(err)
+	}
+	if flags&flagHashed != 0 {
+		var b [8]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return noEOF(err)
+		}
+		f.Hash, f.Hashed = binary.LittleEndian.Uint64(b[:]), true
+	}
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return noEOF(err)
+	}
+	for i := uint64(0); i < n; i++ {
+		kind, err := r.ReadByte()
+		if err != nil {
+			return noEOF(err)
+		}
+		e := Event{Kind: EventKind(kind)}
+		switch e.Kind {
+		case EventKey:
+			size, err := binary.ReadUvarint(r)
+			if err != nil {
+				return noEOF(err)
+			}
+			if size > maxKeyName {
+				return fmt.Errorf("key name of %d bytes is too long", size)
+			}
+			name := make([]byte, size)
+			if _, err := io.ReadFull(r, name); err != nil {
+				return noEOF(err)
+			}
+			mods, err := binary.ReadUvarint(r)
+			if err != nil {
+				return noEOF(err)
+			}
+			state, err := r.ReadByte()
+			if err != nil {
+				return noEOF(err)
+			}
+			e.Key = KeyEvent{Name: string(name), Modifiers: key.Modifiers(mods), State: key.State(state)}
+		case EventPointer:
+			typ, err := binary.ReadUvarint(r)
+			if err != nil {
+				return noEOF(err)
+			}
+			var b [17]byte
+			if _, err := io.ReadFull(r, b[:]); err != nil {
+				return noEOF(err)
+			}
+			f32 := func(b []byte) float64 {
+				return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+			}
+			e.Pointer = PointerEvent{
+				Type:     pointer.Type(typ),
+				Position: gfx.V(f32(b[0:]), f32(b[4:])),
+				Buttons:  pointer.Buttons(b[8]),
+				Scroll:   gfx.V(f32(b[9:]), f32(b[13:])),
+			}
+		default:
+			return fmt.Errorf("unknown event kind %d", kind)
+		}
+		f.Events = append(f.Events, e)
+	}
+	return nil
+}
+
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// StartFrames implements the Engine.StartFrames contract, feeding the
+// recorded frames back in order and closing the channel after the last one.
+func (r *Replay) StartFrames() (frames <-chan Frame, stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan Frame)
+	go func() {
+		defer close(out)
+		for _, f := range r.Frames {
+			if r.Realtime {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(f.Tick.Delta()):
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- f.Frame:
+			}
+		}
+	}()
+	return out, cancel
+}
@@ -0,0 +1,160 @@
+package bit
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"reflect"
+	"testing"
+	"time"
+
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"github.com/jncornett/bit/gfx"
+)
+
+func testReplayFrames() []ReplayFrame {
+	fs := frames(3)
+	fs[0].Events = []Event{
+		{Kind: EventKey, Key: KeyEvent{Name: "A", Modifiers: key.ModShift, State: key.Press}},
+		{Kind: EventPointer, Pointer: PointerEvent{Type: pointer.Press, Position: gfx.V(1.5, 2), Buttons: pointer.ButtonPrimary, Scroll: gfx.V(0, -3)}},
+	}
+	fs[2].Events = []Event{{Kind: EventKey, Key: KeyEvent{Name: "A", State: key.Release}}}
+	return []ReplayFrame{
+		{Frame: fs[0], Hash: 1, Hashed: true},
+		{Frame: fs[1]},
+		{Frame: fs[2], Hash: 0xdeadbeef, Hashed: true},
+	}
+}
+
+func TestReplayRoundTrip(t *testing.T) {
+	want := testReplayFrames()
+	var buf bytes.Buffer
+	r, err := NewRecorder(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range want {
+		if err := r.Record(f.Frame, f.Hash, f.Hashed); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadReplay(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Frames) != len(want) {
+		t.Fatalf("got %d frames, want %d", len(got.Frames), len(want))
+	}
+	for i := range want {
+		g, w := got.Frames[i], want[i]
+		if !g.Tick[0].Equal(w.Tick[0]) || !g.Tick[1].Equal(w.Tick[1]) || !g.Tick[2].Equal(w.Tick[2]) {
+			t.Errorf("frame %d tick = %v, want %v", i, g.Tick, w.Tick)
+		}
+		if g.Hash != w.Hash || g.Hashed != w.Hashed || !reflect.DeepEqual(g.Events, w.Events) {
+			t.Errorf("frame %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestReadReplayEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	r, _ := NewRecorder(&buf)
+	r.Flush()
+	got, err := ReadReplay(&buf)
+	if err != nil || len(got.Frames) != 0 {
+		t.Errorf("ReadReplay = %v, %v, want no frames", got, err)
+	}
+}
+
+func TestReadReplayMalformed(t *testing.T) {
+	var buf bytes.Buffer
+	r, _ := NewRecorder(&buf)
+	for _, f := range testReplayFrames() {
+		r.Record(f.Frame, f.Hash, f.Hashed)
+	}
+	r.Flush()
+	good := buf.Bytes()
+
+	// A key event whose name claims to be huge.
+	huge := append([]byte(replayMagic), byte(ReplayVersion))
+	huge = binary.AppendVarint(huge, 0)
+	huge = binary.AppendVarint(huge, 0)
+	huge = binary.AppendVarint(huge, 0)
+	huge = append(huge, 0, 1, byte(EventKey))
+	huge = binary.AppendUvarint(huge, 1<<62)
+
+	for name, data := range map[string][]byte{
+		"bad magic": append([]byte("notmagic"), good[len(replayMagic):]...),
+		"truncated": good[:len(good)-3],
+		"huge name": huge,
+	} {
+		if _, err := ReadReplay(bytes.NewReader(data)); !errors.Is(err, ErrBadReplay) {
+			t.Errorf("%s: ReadReplay = %v, want ErrBadReplay", name, err)
+		}
+	}
+}
+
+func TestRecordRejected(t *testing.T) {
+	good := Event{Kind: EventKey, Key: KeyEvent{Name: "A"}}
+	for name, bad := range map[string]Event{
+		"long key name": {Kind: EventKey, Key: KeyEvent{Name: string(make([]byte, maxKeyName+1))}},
+		"unknown kind":  {Kind: 99},
+	} {
+		var buf bytes.Buffer
+		r, _ := NewRecorder(&buf)
+		fs := frames(2)
+		if err := r.Record(fs[0], 0, false); err != nil {
+			t.Fatal(err)
+		}
+		// A rejected frame, even after a good event, writes nothing.
+		fs[1].Events = []Event{good, bad}
+		if err := r.Record(fs[1], 0, false); err == nil {
+			t.Errorf("%s: Record accepted %+v", name, bad)
+		}
+		r.Flush()
+		replay, err := ReadReplay(&buf)
+		if err != nil || len(replay.Frames) != 1 {
+			t.Errorf("%s: ReadReplay after a rejected frame = %v; want the one good frame", name, err)
+		}
+	}
+}
+
+func TestReplayDivergence(t *testing.T) {
+	update := func(step int) func(Tick, int) (int, DrawList) {
+		return func(_ Tick, n int) (int, DrawList) { return n + step, nil }
+	}
+	hash := func(n int) (uint64, error) { return uint64(n), nil }
+	var buf bytes.Buffer
+	rec := NewDrawApp(image.Pt(1, 1), 0, update(1)).
+		Clock(MakeVirtualClock(epoch, time.Millisecond, 5)).
+		Headless(Headless{}).
+		HashState(hash).
+		Record(&buf)
+	if err := rec.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	replay, err := ReadReplay(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replay.Frames) != 5 {
+		t.Fatalf("recorded %d frames, want 5", len(replay.Frames))
+	}
+
+	same := NewDrawApp(image.Pt(1, 1), 0, update(1)).Headless(Headless{}).HashState(hash).Replay(replay)
+	if err := same.Run(context.Background()); err != nil {
+		t.Errorf("faithful replay: %v", err)
+	}
+
+	diverged := NewDrawApp(image.Pt(1, 1), 0, update(2)).Headless(Headless{}).HashState(hash).Replay(replay)
+	var div *DivergenceError
+	if err := diverged.Run(context.Background()); !errors.As(err, &div) || div.Frame != 0 {
+		t.Errorf("diverging replay = %v, want divergence at frame 0", err)
+	}
+}
@@ -0,0 +1,317 @@
+package bit
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Save files start with saveMagic followed by the format version as a
+// uvarint, then:
+//
+//	uvarint  schema version of the game state
+//	varint   time saved, in Unix nanoseconds
+//	uvarint  length of the encoded game state, followed by it
+//	uint32   CRC-32 (Castagnoli) of everything before it, little endian
+const (
+	saveMagic   = "bitsave\n"
+	SaveVersion = 1
+	saveExt     = ".sav"
+)
+
+var (
+	ErrBadSave = errors.New("bit: malformed save")
+	ErrBadSlot = errors.New("bit: bad save slot name")
+	saveTable  = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// SchemaError is returned when a save's schema version is newer than the
+// game's, or there is no migration from it.
+type SchemaError struct {
+	Version, Want int
+}
+
+func (e *SchemaError) Error() string {
+	if e.Version > e.Want {
+		return fmt.Sprintf("bit: save schema version %d is newer than %d", e.Version, e.Want)
+	}
+	return fmt.Sprintf("bit: no migration from save schema version %d", e.Version)
+}
+
+// Saves saves game states to slots, which are files in Dir.
+//
+// Game states are encoded with their MarshalBinary and UnmarshalBinary
+// methods if they have them, and as JSON otherwise, unless Encode and Decode
+// are set.
+type Saves[GameState any] struct {
+	Dir string
+	// Version is the schema version of the game state, which is saved with
+	// it. Raise it whenever the encoding changes, and add a migration.
+	Version int
+	Encode  func(GameState) ([]byte, error)
+	Decode  func([]byte) (GameState, error)
+	// Migrations[v] upgrades an encoded game state from schema version v to
+	// v+1. Older saves are upgraded one version at a time.
+	Migrations map[int]func([]byte) ([]byte, error)
+}
+
+// SaveInfo describes a save slot.
+type SaveInfo struct {
+	Slot    string
+	Version int
+	Time    time.Time
+}
+
+func NewSaves[GameState any](dir string, version int) *Saves[GameState] {
+	return &Saves[GameState]{
+		Dir:        dir,
+		Version:    version,
+		Migrations: make(map[int]func([]byte) ([]byte, error)),
+	}
+}
+
+// Migrate sets the migration from schema version v to v+1.
+func (s *Saves[GameState]) Migrate(v int, f func([]byte) ([]byte, error)) *Saves[GameState] {
+	if s.Migrations == nil {
+		s.Migrations = make(map[int]func([]byte) ([]byte, error))
+	}
+	s.Migrations[v] = f
+	return s
+}
+
+func (s *Saves[GameState]) path(slot string) (string, error) {
+	if slot == "" || slot == "." || slot == ".." || strings.ContainsAny(slot, `/\`) {
+		return "", fmt.Errorf("%w: %q", ErrBadSlot, slot)
+	}
+	return filepath.Join(s.Dir, slot+saveExt), nil
+}
+
+// Save writes state to a slot. The slot is replaced atomically, so it keeps
+// its old contents if saving fails.
+func (s *Saves[GameState]) Save(slot string, state GameState) error {
+	path, err := s.path(slot)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.Dir, slot+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := s.Write(f, state); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Load reads the game state in a slot, migrating it if it is older. If the
+// slot does not exist, the error satisfies errors.Is(err, fs.ErrNotExist).
+func (s *Saves[GameState]) Load(slot string) (GameState, error) {
+	var state GameState
+	path, err := s.path(slot)
+	if err != nil {
+		return state, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return state, err
+	}
+	defer f.Close()
+	return s.Read(f)
+}
+
+// Delete removes a slot, if it exists.
+func (s *Saves[GameState]) Delete(slot string) error {
+	path, err := s.path(slot)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Slots describes every slot in Dir, sorted by name. Slots that cannot be
+// read are left out.
+func (s *Saves[GameState]) Slots() ([]SaveInfo, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var infos []SaveInfo
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, saveExt) {
+			continue
+		}
+		slot := strings.TrimSuffix(name, saveExt)
+		if info, err := s.Info(slot); err == nil {
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Slot < infos[j].Slot })
+	return infos, nil
+}
+
+// Info describes a slot without decoding its game state.
+func (s *Saves[GameState]) Info(slot string) (SaveInfo, error) {
+	path, err := s.path(slot)
+	if err != nil {
+		return SaveInfo{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SaveInfo{}, err
+	}
+	version, t, _, err := parseSave(data)
+	if err != nil {
+		return SaveInfo{}, err
+	}
+	return SaveInfo{Slot: slot, Version: version, Time: t}, nil
+}
+
+// Write writes state to w in the save format, e.g. to snapshot it in
+// memory.
+func (s *Saves[GameState]) Write(w io.Writer, state GameState) error {
+	payload, err := s.encode(state)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	var scratch [binary.MaxVarintLen64]byte
+	buf.WriteString(saveMagic)
+	buf.Write(scratch[:binary.PutUvarint(scratch[:], SaveVersion)])
+	buf.Write(scratch[:binary.PutUvarint(scratch[:], uint64(s.Version))])
+	buf.Write(scratch[:binary.PutVarint(scratch[:], time.Now().UnixNano())])
+	buf.Write(scratch[:binary.PutUvarint(scratch[:], uint64(len(payload)))])
+	buf.Write(payload)
+	sum := crc32.Checksum(buf.Bytes(), saveTable)
+	binary.LittleEndian.PutUint32(scratch[:4], sum)
+	buf.Write(scratch[:4])
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// Read reads a game state written by Write, migrating it if it is older.
+func (s *Saves[GameState]) Read(r io.Reader) (GameState, error) {
+	var state GameState
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return state, err
+	}
+	version, _, payload, err := parseSave(data)
+	if err != nil {
+		return state, err
+	}
+	if version > s.Version {
+		return state, &SchemaError{version, s.Version}
+	}
+	for ; version < s.Version; version++ {
+		migrate, ok := s.Migrations[version]
+		if !ok {
+			return state, &SchemaError{version, s.Version}
+		}
+		if payload, err = migrate(payload); err != nil {
+			return state, fmt.Errorf("bit: migrating save from schema version %d: %w", version, err)
+		}
+	}
+	return s.decode(payload)
+}
+
+// parseSave checks a save and returns its schema version, time and encoded
+// game state.
+func parseSave(data []byte) (int, time.Time, []byte, error) {
+	if len(data) < len(saveMagic)+4 || string(data[:len(saveMagic)]) != saveMagic {
+		return 0, time.Time{}, nil, ErrBadSave
+	}
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.Checksum(body, saveTable) != sum {
+		return 0, time.Time{}, nil, fmt.Errorf("%w: checksum mismatch", ErrBadSave)
+	}
+	r := bytes.NewReader(body[len(saveMagic):])
+	format, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, time.Time{}, nil, ErrBadSave
+	}
+	if format != SaveVersion {
+		return 0, time.Time{}, nil, fmt.Errorf("%w: unsupported format version %d", ErrBadSave, format)
+	}
+	version, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, time.Time{}, nil, ErrBadSave
+	}
+	nanos, err := binary.ReadVarint(r)
+	if err != nil {
+		return 0, time.Time{}, nil, ErrBadSave
+	}
+	n, err := binary.ReadUvarint(r)
+	if err != nil || n != uint64(r.Len()) {
+		return 0, time.Time{}, nil, ErrBadSave
+	}
+	payload := body[len(body)-r.Len():]
+	return int(version), time.Unix(0, nanos), payload, nil
+}
+
+func (s *Saves[GameState]) encode(state GameState) ([]byte, error) {
+	if s.Encode != nil {
+		return s.Encode(state)
+	}
+	// Check the same receivers as decode, so both use the same encoding.
+	if m, ok := any(state).(encoding.BinaryMarshaler); ok {
+		return m.MarshalBinary()
+	}
+	if m, ok := any(&state).(encoding.BinaryMarshaler); ok {
+		return m.MarshalBinary()
+	}
+	return json.Marshal(state)
+}
+
+func (s *Saves[GameState]) decode(data []byte) (GameState, error) {
+	if s.Decode != nil {
+		return s.Decode(data)
+	}
+	state := newState[GameState]()
+	if u, ok := any(state).(encoding.BinaryUnmarshaler); ok {
+		return state, u.UnmarshalBinary(data)
+	}
+	if u, ok := any(&state).(encoding.BinaryUnmarshaler); ok {
+		return state, u.UnmarshalBinary(data)
+	}
+	return state, json.Unmarshal(data, &state)
+}
+
+// newState returns the zero GameState, or a pointer to a new zero value if
+// GameState is a pointer type, so that it can be decoded into.
+func newState[GameState any]() GameState {
+	var state GameState
+	if t := reflect.TypeOf(&state).Elem(); t.Kind() == reflect.Pointer {
+		state = reflect.New(t.Elem()).Interface().(GameState)
+	}
+	return state
+}
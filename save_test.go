@@ -0,0 +1,208 @@
+package bit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type saveState struct {
+	Level int
+	Name  string
+}
+
+// packed encodes itself in binary, with methods on its pointer.
+type packed struct{ A, B byte }
+
+func (p *packed) MarshalBinary() ([]byte, error) { return []byte{'p', p.A, p.B}, nil }
+
+func (p *packed) UnmarshalBinary(data []byte) error {
+	if len(data) != 3 || data[0] != 'p' {
+		return errors.New("not packed")
+	}
+	p.A, p.B = data[1], data[2]
+	return nil
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	s := NewSaves[saveState](dir, 3)
+	want := saveState{Level: 4, Name: "cave"}
+	if err := s.Save("one", want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Load("one")
+	if err != nil || got != want {
+		t.Errorf("Load() = %v, %v; want %v", got, err, want)
+	}
+
+	if err := s.Save("two", saveState{}); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "junk"+saveExt), []byte("junk"), 0o644)
+	infos, err := s.Slots()
+	if err != nil || len(infos) != 2 || infos[0].Slot != "one" || infos[1].Slot != "two" || infos[0].Version != 3 {
+		t.Errorf("Slots() = %+v, %v; want one and two at version 3", infos, err)
+	}
+
+	if err := s.Delete("one"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Load("one"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Load() of a deleted slot = %v; want fs.ErrNotExist", err)
+	}
+	if err := s.Delete("one"); err != nil {
+		t.Errorf("Delete() of a missing slot = %v", err)
+	}
+	for _, slot := range []string{"", ".", "..", "a/b", `a\b`} {
+		if err := s.Save(slot, want); !errors.Is(err, ErrBadSlot) {
+			t.Errorf("Save(%q) = %v; want ErrBadSlot", slot, err)
+		}
+	}
+}
+
+func TestSaveBinary(t *testing.T) {
+	// Encoding and decoding must agree whether the methods are on the value
+	// or its pointer.
+	var buf bytes.Buffer
+	s := NewSaves[packed]("", 1)
+	if err := s.Write(&buf, packed{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte{'p', 1, 2}) {
+		t.Errorf("packed was not encoded with MarshalBinary: %q", buf.Bytes())
+	}
+	if got, err := s.Read(&buf); err != nil || got != (packed{1, 2}) {
+		t.Errorf("Read() = %v, %v; want {1 2}", got, err)
+	}
+
+	buf.Reset()
+	ps := NewSaves[*packed]("", 1)
+	if err := ps.Write(&buf, &packed{3, 4}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ps.Read(&buf); err != nil || *got != (packed{3, 4}) {
+		t.Errorf("Read() = %v, %v; want &{3 4}", got, err)
+	}
+
+	buf.Reset()
+	js := NewSaves[*saveState]("", 1)
+	if err := js.Write(&buf, &saveState{Level: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := js.Read(&buf); err != nil || *got != (saveState{Level: 2}) {
+		t.Errorf("Read() = %v, %v; want &{2 }", got, err)
+	}
+}
+
+func TestSaveMigrate(t *testing.T) {
+	var buf bytes.Buffer
+	v1 := NewSaves[map[string]int]("", 1)
+	if err := v1.Write(&buf, map[string]int{"level": 7}); err != nil {
+		t.Fatal(err)
+	}
+	old := buf.Bytes()
+
+	var steps []int
+	v3 := NewSaves[saveState]("", 3).
+		Migrate(1, func(b []byte) ([]byte, error) {
+			steps = append(steps, 1)
+			var m map[string]int
+			if err := json.Unmarshal(b, &m); err != nil {
+				return nil, err
+			}
+			return json.Marshal(map[string]any{"Level": m["level"]})
+		}).
+		Migrate(2, func(b []byte) ([]byte, error) {
+			steps = append(steps, 2)
+			var m map[string]any
+			if err := json.Unmarshal(b, &m); err != nil {
+				return nil, err
+			}
+			m["Name"] = "migrated"
+			return json.Marshal(m)
+		})
+	got, err := v3.Read(bytes.NewReader(old))
+	if want := (saveState{7, "migrated"}); err != nil || got != want {
+		t.Errorf("Read() = %v, %v; want %v", got, err, want)
+	}
+	if !reflect.DeepEqual(steps, []int{1, 2}) {
+		t.Errorf("migrations ran %v; want [1 2]", steps)
+	}
+
+	var schema *SchemaError
+	if _, err := v1.Read(bytes.NewReader(mustWrite(t, v3, saveState{}))); !errors.As(err, &schema) || schema.Version != 3 || schema.Want != 1 {
+		t.Errorf("Read() of a newer save = %v; want SchemaError", err)
+	}
+	delete(v3.Migrations, 2)
+	if _, err := v3.Read(bytes.NewReader(old)); !errors.As(err, &schema) || schema.Version != 2 {
+		t.Errorf("Read() with a missing migration = %v; want SchemaError from 2", err)
+	}
+	v3.Migrate(2, func([]byte) ([]byte, error) { return nil, errors.New("broken") })
+	if _, err := v3.Read(bytes.NewReader(old)); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Read() with a failing migration = %v", err)
+	}
+}
+
+func mustWrite[G any](t *testing.T, s *Saves[G], state G) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := s.Write(&buf, state); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSaveCorrupt(t *testing.T) {
+	s := NewSaves[saveState]("", 1)
+	good := mustWrite(t, s, saveState{Level: 1, Name: "x"})
+	for i := range good {
+		bad := append([]byte(nil), good...)
+		bad[i] ^= 0x40
+		if _, err := s.Read(bytes.NewReader(bad)); !errors.Is(err, ErrBadSave) {
+			t.Errorf("Read() with byte %d flipped = %v; want ErrBadSave", i, err)
+		}
+	}
+	for _, n := range []int{0, 3, len(saveMagic) + 3, len(good) - 1} {
+		if _, err := s.Read(bytes.NewReader(good[:n])); !errors.Is(err, ErrBadSave) {
+			t.Errorf("Read() of %d bytes = %v; want ErrBadSave", n, err)
+		}
+	}
+}
+
+func TestSaveAtomic(t *testing.T) {
+	dir := t.TempDir()
+	s := NewSaves[saveState](dir, 1)
+	if err := s.Save("slot", saveState{Level: 1}); err != nil {
+		t.Fatal(err)
+	}
+	s.Encode = func(saveState) ([]byte, error) { return nil, errors.New("disk on fire") }
+	if err := s.Save("slot", saveState{Level: 2}); err == nil {
+		t.Fatal("Save() with a failing encoder succeeded")
+	}
+	s.Encode = nil
+	if got, err := s.Load("slot"); err != nil || got.Level != 1 {
+		t.Errorf("Load() after a failed save = %v, %v; want level 1", got, err)
+	}
+
+	if err := s.Save("slot", saveState{Level: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.Load("slot"); err != nil || got.Level != 3 {
+		t.Errorf("Load() after replacing = %v, %v; want level 3", got, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "slot"+saveExt {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("dir holds %v; want only slot%s", names, saveExt)
+	}
+}
@@ -0,0 +1,294 @@
+package bit
+
+import (
+	"image"
+	"image/color"
+	"time"
+)
+
+// Scene is one screen of a game, such as a title screen, a level or a pause
+// menu, holding its own state. Scenes may also implement Enterer, Exiter,
+// Pauser and Resumer to be told when they are shown and hidden.
+type Scene interface {
+	// Update updates the scene, which may change the stack through s.
+	Update(t Tick, in Input, s *Scenes)
+	Render() Drawer
+}
+
+// Enterer is a Scene that is told when it is added to the stack.
+type Enterer interface{ Enter() }
+
+// Exiter is a Scene that is told when it is removed from the stack.
+type Exiter interface{ Exit() }
+
+// Pauser is a Scene that is told when another scene is pushed over it.
+type Pauser interface{ Pause() }
+
+// Resumer is a Scene that is told when the scene over it is popped.
+type Resumer interface{ Resume() }
+
+// Scenes is a stack of scenes. The top scene is updated, along with the
+// scenes below it if it was pushed as a HUD, and so on down the stack. It is
+// rendered over the scenes below it if it was pushed as an overlay or a HUD.
+// Changes made during an update take effect after every scene has updated.
+type Scenes struct {
+	stack    []sceneEntry
+	updating bool
+	pending  []func()
+
+	transition Transition
+	from       Drawer
+	elapsed    time.Duration
+}
+
+type sceneEntry struct {
+	scene   Scene
+	overlay bool
+	// hud is set if the scenes below keep updating.
+	hud bool
+}
+
+func NewScenes(first Scene) *Scenes {
+	s := &Scenes{}
+	s.Push(first, nil)
+	return s
+}
+
+// NewSceneApp returns an app that runs a stack of scenes, starting with
+// first.
+func NewSceneApp(size image.Point, first Scene) *DrawApp[*Scenes, Drawer] {
+	return NewInputDrawApp(size, NewScenes(first), func(t Tick, in Input, s *Scenes) (*Scenes, Drawer) {
+		return s, s.Update(t, in)
+	})
+}
+
+func (s *Scenes) Len() int { return len(s.stack) }
+
+// Top returns the top scene, or nil if the stack is empty.
+func (s *Scenes) Top() Scene {
+	if len(s.stack) == 0 {
+		return nil
+	}
+	return s.stack[len(s.stack)-1].scene
+}
+
+// Transitioning reports whether a transition is playing. Scenes are not
+// updated while it does.
+func (s *Scenes) Transitioning() bool { return s.transition != nil }
+
+// Push pauses the top scene and pushes scene over it, playing t if it is not
+// nil.
+func (s *Scenes) Push(scene Scene, t Transition) {
+	s.change(t, func() { s.push(sceneEntry{scene: scene}) })
+}
+
+// PushOverlay is like Push, but the scenes below keep being rendered.
+func (s *Scenes) PushOverlay(scene Scene, t Transition) {
+	s.change(t, func() { s.push(sceneEntry{scene: scene, overlay: true}) })
+}
+
+// PushHUD is like PushOverlay, but the scenes below are not paused and keep
+// being updated, before scene, as for a heads-up display or a notification.
+func (s *Scenes) PushHUD(scene Scene, t Transition) {
+	s.change(t, func() { s.push(sceneEntry{scene: scene, overlay: true, hud: true}) })
+}
+
+// Pop removes the top scene and resumes the one below it.
+func (s *Scenes) Pop(t Transition) {
+	s.change(t, func() {
+		if len(s.stack) == 0 {
+			return
+		}
+		hud := s.stack[len(s.stack)-1].hud
+		s.pop()
+		if r, ok := s.Top().(Resumer); ok && !hud {
+			r.Resume()
+		}
+	})
+}
+
+// Replace replaces the top scene with scene.
+func (s *Scenes) Replace(scene Scene, t Transition) {
+	s.change(t, func() {
+		e := sceneEntry{scene: scene}
+		if len(s.stack) > 0 {
+			top := s.stack[len(s.stack)-1]
+			e.overlay, e.hud = top.overlay, top.hud
+			s.pop()
+		}
+		s.stack = append(s.stack, e)
+		if e, ok := scene.(Enterer); ok {
+			e.Enter()
+		}
+	})
+}
+
+func (s *Scenes) push(e sceneEntry) {
+	if p, ok := s.Top().(Pauser); ok && !e.hud {
+		p.Pause()
+	}
+	s.stack = append(s.stack, e)
+	if e, ok := e.scene.(Enterer); ok {
+		e.Enter()
+	}
+}
+
+func (s *Scenes) pop() {
+	top := s.Top()
+	s.stack[len(s.stack)-1] = sceneEntry{}
+	s.stack = s.stack[:len(s.stack)-1]
+	if e, ok := top.(Exiter); ok {
+		e.Exit()
+	}
+}
+
+func (s *Scenes) change(t Transition, f func()) {
+	apply := func() {
+		if t != nil {
+			s.from, s.transition, s.elapsed = s.render(), t, 0
+		}
+		f()
+	}
+	if s.updating {
+		s.pending = append(s.pending, apply)
+		return
+	}
+	apply()
+}
+
+// Update updates the top scene and any scenes below it that it lets update,
+// from the bottom up, or the transition if one is playing, and returns what
+// to draw.
+func (s *Scenes) Update(t Tick, in Input) Drawer {
+	if s.transition != nil {
+		s.elapsed += t.Delta()
+		if s.elapsed >= s.transition.Duration() {
+			s.transition, s.from = nil, nil
+		}
+	} else if len(s.stack) > 0 {
+		i := len(s.stack) - 1
+		for i > 0 && s.stack[i].hud {
+			i--
+		}
+		// Changes are deferred, so the stack stays the same while it is
+		// updated.
+		s.updating = true
+		for _, e := range s.stack[i:] {
+			e.scene.Update(t, in, s)
+		}
+		s.updating = false
+		pending := s.pending
+		s.pending = nil
+		for _, f := range pending {
+			f()
+		}
+	}
+	return s.Render()
+}
+
+// Render returns the visible scenes, from the bottom up, and the transition
+// if one is playing.
+func (s *Scenes) Render() Drawer {
+	to := s.render()
+	if s.transition == nil {
+		return to
+	}
+	p := 1.0
+	if d := s.transition.Duration(); d > 0 {
+		p = float64(s.elapsed) / float64(d)
+	}
+	return transitionFrame{s.transition, s.from, to, p}
+}
+
+func (s *Scenes) render() DrawList {
+	i := len(s.stack) - 1
+	for i > 0 && s.stack[i].overlay {
+		i--
+	}
+	var l DrawList
+	for ; i >= 0 && i < len(s.stack); i++ {
+		if d := s.stack[i].scene.Render(); d != nil {
+			l = append(l, d)
+		}
+	}
+	return l
+}
+
+// Transition draws the change from one scene to another.
+type Transition interface {
+	Duration() time.Duration
+	// Draw draws the transition at progress p, from 0 to 1, given what the
+	// old and new scenes draw.
+	Draw(dst *image.NRGBA, from, to Drawer, p float64)
+}
+
+type transitionFrame struct {
+	t        Transition
+	from, to Drawer
+	p        float64
+}
+
+func (f transitionFrame) Draw(dst *image.NRGBA) { f.t.Draw(dst, f.from, f.to, f.p) }
+
+// Fade returns a transition that fades the old scene out to c, and then the
+// new scene in from it.
+func Fade(d time.Duration, c color.NRGBA) Transition { return fade{d, c} }
+
+type fade struct {
+	d time.Duration
+	c color.NRGBA
+}
+
+func (f fade) Duration() time.Duration { return f.d }
+
+func (f fade) Draw(dst *image.NRGBA, from, to Drawer, p float64) {
+	scene, a := from, 2*p
+	if p >= 0.5 {
+		scene, a = to, 2*(1-p)
+	}
+	scene.Draw(dst)
+	c := f.c
+	c.A = uint8(float64(c.A)*a + 0.5)
+	fillRect(dst, dst.Rect, c)
+}
+
+type WipeDirection uint8
+
+// The direction in which the edge between the scenes moves.
+const (
+	WipeRight WipeDirection = iota
+	WipeLeft
+	WipeDown
+	WipeUp
+)
+
+// Wipe returns a transition in which the new scene is uncovered behind an
+// edge moving across the buffer.
+func Wipe(d time.Duration, dir WipeDirection) Transition { return wipe{d, dir} }
+
+type wipe struct {
+	d   time.Duration
+	dir WipeDirection
+}
+
+func (w wipe) Duration() time.Duration { return w.d }
+
+func (w wipe) Draw(dst *image.NRGBA, from, to Drawer, p float64) {
+	to.Draw(dst)
+	r := dst.Rect
+	dx, dy := int(float64(r.Dx())*p+0.5), int(float64(r.Dy())*p+0.5)
+	switch w.dir {
+	case WipeRight:
+		r.Min.X += dx
+	case WipeLeft:
+		r.Max.X -= dx
+	case WipeDown:
+		r.Min.Y += dy
+	case WipeUp:
+		r.Max.Y -= dy
+	}
+	if r.Empty() {
+		return
+	}
+	from.Draw(dst.SubImage(r).(*image.NRGBA))
+}
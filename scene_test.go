@@ -0,0 +1,178 @@
+package bit
+
+import (
+	"image"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// logScene logs its updates and hooks, and runs do when it updates.
+type logScene struct {
+	name string
+	log  *[]string
+	do   func(s *Scenes)
+}
+
+func (l *logScene) Update(t Tick, in Input, s *Scenes) {
+	*l.log = append(*l.log, l.name+".update")
+	if l.do != nil {
+		l.do(s)
+		l.do = nil
+	}
+}
+
+func (l *logScene) Render() Drawer { return sceneDrawer(l.name) }
+func (l *logScene) Enter()         { *l.log = append(*l.log, l.name+".enter") }
+func (l *logScene) Exit()          { *l.log = append(*l.log, l.name+".exit") }
+func (l *logScene) Pause()         { *l.log = append(*l.log, l.name+".pause") }
+func (l *logScene) Resume()        { *l.log = append(*l.log, l.name+".resume") }
+
+type sceneDrawer string
+
+func (sceneDrawer) Draw(*image.NRGBA) {}
+
+// checkLog checks the log holds want, and clears it.
+func checkLog(t *testing.T, log *[]string, want ...string) {
+	t.Helper()
+	if !reflect.DeepEqual(*log, want) && (len(*log) > 0 || len(want) > 0) {
+		t.Errorf("log = %v; want %v", *log, want)
+	}
+	*log = nil
+}
+
+func checkRender(t *testing.T, s *Scenes, want ...string) {
+	t.Helper()
+	var got []string
+	for _, d := range s.Render().(DrawList) {
+		got = append(got, string(d.(sceneDrawer)))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rendered %v; want %v", got, want)
+	}
+}
+
+func TestScenesHooks(t *testing.T) {
+	var log []string
+	scene := func(name string) *logScene { return &logScene{name: name, log: &log} }
+
+	s := NewScenes(scene("a"))
+	checkLog(t, &log, "a.enter")
+	s.Push(scene("b"), nil)
+	checkLog(t, &log, "a.pause", "b.enter")
+	checkRender(t, s, "b")
+	s.PushOverlay(scene("c"), nil)
+	checkLog(t, &log, "b.pause", "c.enter")
+	checkRender(t, s, "b", "c")
+	s.Replace(scene("d"), nil)
+	checkLog(t, &log, "c.exit", "d.enter")
+	checkRender(t, s, "b", "d") // a replacement keeps being an overlay
+	s.Pop(nil)
+	checkLog(t, &log, "d.exit", "b.resume")
+	s.Pop(nil)
+	checkLog(t, &log, "b.exit", "a.resume")
+	s.Pop(nil)
+	checkLog(t, &log, "a.exit")
+	s.Pop(nil)
+	checkLog(t, &log)
+	if s.Len() != 0 || s.Top() != nil {
+		t.Errorf("Len() = %d, Top() = %v; want empty", s.Len(), s.Top())
+	}
+	s.Update(Tick{}, Input{})
+	checkLog(t, &log)
+}
+
+func TestScenesDeferred(t *testing.T) {
+	var log []string
+	a := &logScene{name: "a", log: &log}
+	s := NewScenes(a)
+	c := &logScene{name: "c", log: &log}
+	b := &logScene{name: "b", log: &log}
+	b.do = func(s *Scenes) {
+		s.Pop(nil)
+		s.Push(c, nil)
+		// Nothing changes until the update is over.
+		if s.Top() != b || s.Len() != 2 {
+			t.Errorf("during Update: Top() = %v, Len() = %d; want b, 2", s.Top(), s.Len())
+		}
+		*b.log = append(*b.log, "b.done")
+	}
+	a.do = func(s *Scenes) { s.Push(b, nil) }
+	checkLog(t, &log, "a.enter")
+
+	s.Update(Tick{}, Input{})
+	checkLog(t, &log, "a.update", "a.pause", "b.enter")
+	s.Update(Tick{}, Input{})
+	checkLog(t, &log, "b.update", "b.done", "b.exit", "a.resume", "a.pause", "c.enter")
+	if s.Top() != c || s.Len() != 2 {
+		t.Errorf("after Update: Top() = %v, Len() = %d; want c, 2", s.Top(), s.Len())
+	}
+	s.Update(Tick{}, Input{})
+	checkLog(t, &log, "c.update")
+}
+
+func TestScenesHUD(t *testing.T) {
+	var log []string
+	scene := func(name string) *logScene { return &logScene{name: name, log: &log} }
+	s := NewScenes(scene("a"))
+	s.Push(scene("b"), nil)
+	hud := scene("hud")
+	s.PushHUD(hud, nil)
+	checkLog(t, &log, "a.enter", "a.pause", "b.enter", "hud.enter")
+	checkRender(t, s, "b", "hud")
+
+	// The scenes under a HUD update first, down to the first opaque one.
+	s.Update(Tick{}, Input{})
+	checkLog(t, &log, "b.update", "hud.update")
+	s.PushHUD(scene("toast"), nil)
+	s.Update(Tick{}, Input{})
+	checkLog(t, &log, "toast.enter", "b.update", "hud.update", "toast.update")
+	checkRender(t, s, "b", "hud", "toast")
+
+	// An overlay over a HUD stops the updates below it.
+	s.PushOverlay(scene("menu"), nil)
+	s.Update(Tick{}, Input{})
+	checkLog(t, &log, "toast.pause", "menu.enter", "menu.update")
+	checkRender(t, s, "b", "hud", "toast", "menu")
+	s.Pop(nil)
+	checkLog(t, &log, "menu.exit", "toast.resume")
+
+	// A scene under a HUD may change the stack, after every scene updates.
+	b := s.stack[1].scene.(*logScene)
+	b.do = func(s *Scenes) { s.Pop(nil) }
+	s.Replace(scene("hud2"), nil)
+	checkLog(t, &log, "toast.exit", "hud2.enter")
+	s.Update(Tick{}, Input{})
+	checkLog(t, &log, "b.update", "hud.update", "hud2.update", "hud2.exit")
+	s.Pop(nil)
+	checkLog(t, &log, "hud.exit")
+	s.Update(Tick{}, Input{})
+	checkLog(t, &log, "b.update")
+}
+
+type instant struct{ d time.Duration }
+
+func (i instant) Duration() time.Duration                         { return i.d }
+func (instant) Draw(dst *image.NRGBA, from, to Drawer, p float64) {}
+
+func TestScenesTransition(t *testing.T) {
+	var log []string
+	s := NewScenes(&logScene{name: "a", log: &log})
+	s.Push(&logScene{name: "b", log: &log}, instant{20 * time.Millisecond})
+	checkLog(t, &log, "a.enter", "a.pause", "b.enter")
+	tick := Tick{epoch, epoch, epoch.Add(10 * time.Millisecond)}
+	if f, ok := s.Update(tick, Input{}).(transitionFrame); !ok || f.p != 0.5 {
+		t.Errorf("Update during a transition drew %#v; want a frame at 0.5", f)
+	}
+	checkLog(t, &log)
+	if !s.Transitioning() {
+		t.Errorf("Transitioning() = false")
+	}
+	s.Update(tick, Input{})
+	if s.Transitioning() {
+		t.Errorf("Transitioning() = true after the transition")
+	}
+	checkLog(t, &log)
+	s.Update(tick, Input{})
+	checkLog(t, &log, "b.update")
+}
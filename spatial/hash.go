@@ -0,0 +1,234 @@
+package spatial
+
+import (
+	"math"
+
+	"github.com/jncornett/bit/gfx"
+)
+
+// SpatialHash buckets rectangles into a uniform grid of square cells. It
+// suits many objects of similar size, ideally no larger than a cell.
+type SpatialHash[K comparable] struct {
+	cellSize float64
+	cells    map[gfx.IVec][]*hashItem[K]
+	items    map[K]*hashItem[K]
+	list     []*hashItem[K]
+	// extent covers every cell that has been used, so rays can stop.
+	extent gfx.IRect
+	seq    uint64
+	stamp  uint64
+}
+
+type hashItem[K comparable] struct {
+	key   K
+	rect  gfx.Rect
+	cells gfx.IRect
+	seq   uint64
+	index int
+	stamp uint64
+}
+
+func NewSpatialHash[K comparable](cellSize float64) *SpatialHash[K] {
+	return &SpatialHash[K]{
+		cellSize: cellSize,
+		cells:    make(map[gfx.IVec][]*hashItem[K]),
+		items:    make(map[K]*hashItem[K]),
+	}
+}
+
+func (h *SpatialHash[K]) Len() int { return len(h.list) }
+
+func (h *SpatialHash[K]) Bounds(k K) (gfx.Rect, bool) {
+	it, ok := h.items[k]
+	if !ok {
+		return gfx.Rect{}, false
+	}
+	return it.rect, true
+}
+
+// cellsOf returns the range of cells that r overlaps.
+func (h *SpatialHash[K]) cellsOf(r gfx.Rect) gfx.IRect {
+	min := r.Min.Div(h.cellSize).Floor().IVec()
+	max := r.Max.Div(h.cellSize).Floor().IVec()
+	return gfx.IRect{Min: min, Max: max.Add(gfx.IV(1, 1))}
+}
+
+func (h *SpatialHash[K]) Insert(k K, r gfx.Rect) {
+	r = r.Canon()
+	if it, ok := h.items[k]; ok {
+		cells := h.cellsOf(r)
+		if cells != it.cells {
+			h.unlink(it)
+			it.cells = cells
+			h.link(it)
+		}
+		it.rect = r
+		return
+	}
+	h.seq++
+	it := &hashItem[K]{key: k, rect: r, cells: h.cellsOf(r), seq: h.seq, index: len(h.list)}
+	h.items[k] = it
+	h.list = append(h.list, it)
+	h.link(it)
+}
+
+func (h *SpatialHash[K]) Move(k K, r gfx.Rect) { h.Insert(k, r) }
+
+func (h *SpatialHash[K]) Remove(k K) {
+	it, ok := h.items[k]
+	if !ok {
+		return
+	}
+	h.unlink(it)
+	delete(h.items, k)
+	last := h.list[len(h.list)-1]
+	last.index = it.index
+	h.list[it.index] = last
+	h.list[len(h.list)-1] = nil
+	h.list = h.list[:len(h.list)-1]
+}
+
+func (h *SpatialHash[K]) link(it *hashItem[K]) {
+	h.extent = h.extent.Union(it.cells)
+	for y := it.cells.Min.Y; y < it.cells.Max.Y; y++ {
+		for x := it.cells.Min.X; x < it.cells.Max.X; x++ {
+			c := gfx.IV(x, y)
+			h.cells[c] = append(h.cells[c], it)
+		}
+	}
+}
+
+func (h *SpatialHash[K]) unlink(it *hashItem[K]) {
+	for y := it.cells.Min.Y; y < it.cells.Max.Y; y++ {
+		for x := it.cells.Min.X; x < it.cells.Max.X; x++ {
+			c := gfx.IV(x, y)
+			cell := h.cells[c]
+			for i, other := range cell {
+				if other == it {
+					cell[i] = cell[len(cell)-1]
+					cell[len(cell)-1] = nil
+					cell = cell[:len(cell)-1]
+					break
+				}
+			}
+			if len(cell) == 0 {
+				delete(h.cells, c)
+			} else {
+				h.cells[c] = cell
+			}
+		}
+	}
+}
+
+// visit calls f once with every item in a range of cells, until f returns
+// false. An item is only visited in the first cell of the range it is in, so
+// that visits need no state and f may query the hash too.
+func (h *SpatialHash[K]) visit(cells gfx.IRect, f func(it *hashItem[K]) bool) {
+	for y := cells.Min.Y; y < cells.Max.Y; y++ {
+		for x := cells.Min.X; x < cells.Max.X; x++ {
+			c := gfx.IV(x, y)
+			for _, it := range h.cells[c] {
+				if it.cells.Min.Max(cells.Min) != c {
+					continue
+				}
+				if !f(it) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (h *SpatialHash[K]) Query(r gfx.Rect, f func(k K) bool) {
+	r = r.Canon()
+	h.visit(h.cellsOf(r).Intersect(h.extent), func(it *hashItem[K]) bool {
+		return !overlaps(it.rect, r) || f(it.key)
+	})
+}
+
+func (h *SpatialHash[K]) Pairs(f func(a, b K)) {
+	for _, it := range h.list {
+		h.visit(it.cells, func(other *hashItem[K]) bool {
+			if other.seq > it.seq && overlaps(it.rect, other.rect) {
+				f(it.key, other.key)
+			}
+			return true
+		})
+	}
+}
+
+// Ray walks the cells along the ray in order, so keys are visited roughly
+// from nearest to farthest. Unlike the other methods, f must not call Ray.
+func (h *SpatialHash[K]) Ray(ray gfx.Ray, maxT float64, f func(k K, t float64) bool) {
+	if len(h.list) == 0 {
+		return
+	}
+	extent := gfx.Rect{Min: h.extent.Min.Vec().Mul(h.cellSize), Max: h.extent.Max.Vec().Mul(h.cellSize)}
+	start, ok := rayRect(ray, extent, maxT)
+	if !ok {
+		return
+	}
+	h.stamp++
+	stamp := h.stamp
+	visitCell := func(c gfx.IVec) bool {
+		for _, it := range h.cells[c] {
+			if it.stamp == stamp {
+				continue
+			}
+			it.stamp = stamp
+			if t, ok := rayRect(ray, it.rect, maxT); ok && !f(it.key, t) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Step from cell to cell, after Amanatides and Woo.
+	p := ray.At(start)
+	// A ray entering on the far side of the extent starts in its last cell.
+	cell := p.Div(h.cellSize).Floor().IVec().Clamp(h.extent.Min, h.extent.Max.Sub(gfx.IV(1, 1)))
+	step := gfx.IV(sign(ray.Dir.X), sign(ray.Dir.Y))
+	next := func(o, d float64, c int) (tMax, tDelta float64) {
+		if d == 0 {
+			return math.Inf(1), math.Inf(1)
+		}
+		edge := float64(c) * h.cellSize
+		if d > 0 {
+			edge += h.cellSize
+		}
+		return (edge - o) / d, h.cellSize / math.Abs(d)
+	}
+	tMaxX, tDeltaX := next(ray.Origin.X, ray.Dir.X, cell.X)
+	tMaxY, tDeltaY := next(ray.Origin.Y, ray.Dir.Y, cell.Y)
+	for cell.In(h.extent) {
+		if !visitCell(cell) {
+			return
+		}
+		if step == (gfx.IVec{}) {
+			return // a ray with no direction only covers its origin
+		}
+		if tMaxX < tMaxY {
+			if tMaxX > maxT {
+				return
+			}
+			cell.X += step.X
+			tMaxX += tDeltaX
+		} else {
+			if tMaxY > maxT {
+				return
+			}
+			cell.Y += step.Y
+			tMaxY += tDeltaY
+		}
+	}
+}
+
+func sign(f float64) int {
+	switch {
+	case f < 0:
+		return -1
+	case f > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,75 @@
+// Package spatial provides broad-phase indexes that find which of many
+// rectangles may overlap, without testing every pair.
+package spatial
+
+import (
+	"math"
+
+	"github.com/jncornett/bit/gfx"
+)
+
+// Index is a set of keyed rectangles that can be searched by region.
+//
+// Rectangles that merely touch are reported as overlapping, and so are
+// empty ones, which lets points be indexed. Every method visits keys in an
+// order that only depends on the sequence of calls made, so an index can be
+// used in a deterministic simulation.
+type Index[K comparable] interface {
+	// Insert adds k with bounds r, or moves it if it is already present.
+	Insert(k K, r gfx.Rect)
+	// Move changes the bounds of k, inserting it if it is not present.
+	Move(k K, r gfx.Rect)
+	// Remove removes k, if it is present.
+	Remove(k K)
+	// Bounds returns the bounds of k.
+	Bounds(k K) (gfx.Rect, bool)
+	Len() int
+	// Query calls f with every key whose bounds overlap r, until f returns
+	// false.
+	Query(r gfx.Rect, f func(k K) bool)
+	// Ray calls f with every key whose bounds the ray enters at or before
+	// maxT, along with where it enters, until f returns false. A ray
+	// starting inside bounds enters them at 0.
+	Ray(ray gfx.Ray, maxT float64, f func(k K, t float64) bool)
+	// Pairs calls f once with every pair of keys whose bounds overlap.
+	Pairs(f func(a, b K))
+}
+
+var (
+	_ Index[int] = (*SpatialHash[int])(nil)
+	_ Index[int] = (*AABBTree[int])(nil)
+)
+
+func overlaps(a, b gfx.Rect) bool {
+	return a.Min.X <= b.Max.X && b.Min.X <= a.Max.X && a.Min.Y <= b.Max.Y && b.Min.Y <= a.Max.Y
+}
+
+// union is like gfx.Rect.Union, but keeps empty rectangles.
+func union(a, b gfx.Rect) gfx.Rect {
+	return gfx.Rect{Min: a.Min.Min(b.Min), Max: a.Max.Max(b.Max)}
+}
+
+func perimeter(r gfx.Rect) float64 {
+	s := r.Size()
+	return 2 * (s.X + s.Y)
+}
+
+// rayRect returns where ray enters r, if it does so between 0 and maxT.
+func rayRect(ray gfx.Ray, r gfx.Rect, maxT float64) (float64, bool) {
+	enter, exit := 0.0, maxT
+	slab := func(o, d, min, max float64) bool {
+		if d == 0 {
+			return min <= o && o <= max
+		}
+		t0, t1 := (min-o)/d, (max-o)/d
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		enter, exit = math.Max(enter, t0), math.Min(exit, t1)
+		return enter <= exit
+	}
+	if !slab(ray.Origin.X, ray.Dir.X, r.Min.X, r.Max.X) || !slab(ray.Origin.Y, ray.Dir.Y, r.Min.Y, r.Max.Y) {
+		return 0, false
+	}
+	return enter, true
+}
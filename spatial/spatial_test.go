@@ -0,0 +1,301 @@
+package spatial
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/jncornett/bit/gfx"
+)
+
+func indexes() map[string]func() Index[int] {
+	return map[string]func() Index[int]{
+		"SpatialHash": func() Index[int] { return NewSpatialHash[int](8) },
+		"AABBTree":    func() Index[int] { return NewAABBTree[int]() },
+	}
+}
+
+func randRect(rng *rand.Rand) gfx.Rect {
+	min := gfx.V(rng.Float64()*200-100, rng.Float64()*200-100)
+	var size gfx.Vec
+	switch rng.Intn(4) {
+	case 0: // a point
+	case 1: // bigger than several cells
+		size = gfx.V(rng.Float64()*40, rng.Float64()*40)
+	default:
+		size = gfx.V(rng.Float64()*6, rng.Float64()*6)
+	}
+	// Snap some rectangles to the cell grid to exercise touching edges.
+	if rng.Intn(4) == 0 {
+		min, size = min.Div(8).Round().Mul(8), size.Div(8).Round().Mul(8)
+	}
+	return gfx.Rect{Min: min, Max: min.Add(size)}
+}
+
+func randRay(rng *rand.Rand) gfx.Ray {
+	o := gfx.V(rng.Float64()*300-150, rng.Float64()*300-150)
+	var d gfx.Vec
+	switch rng.Intn(4) {
+	case 0:
+		d = gfx.V(float64(rng.Intn(3)-1), 0)
+	case 1:
+		d = gfx.V(0, float64(rng.Intn(3)-1))
+	default:
+		d = gfx.Polar(rng.Float64()*10, rng.Float64()*2*math.Pi)
+	}
+	return gfx.Ray{Origin: o, Dir: d}
+}
+
+func sorted(keys []int) []int {
+	sort.Ints(keys)
+	return keys
+}
+
+func TestIndexMatchesBruteForce(t *testing.T) {
+	for name, newIndex := range indexes() {
+		t.Run(name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			idx := newIndex()
+			want := make(map[int]gfx.Rect)
+			for step := 0; step < 3000; step++ {
+				k := rng.Intn(200)
+				switch op := rng.Intn(10); {
+				case op < 4:
+					r := randRect(rng)
+					idx.Insert(k, r)
+					want[k] = r
+				case op < 7:
+					r, ok := want[k]
+					if !ok {
+						r = randRect(rng)
+					}
+					// Mostly small moves, which the tree absorbs in its
+					// fat bounds, and sometimes jumps.
+					r = r.Add(gfx.V(rng.Float64()*4-2, rng.Float64()*4-2))
+					if rng.Intn(5) == 0 {
+						r = randRect(rng)
+					}
+					idx.Move(k, r)
+					want[k] = r
+				default:
+					idx.Remove(k)
+					delete(want, k)
+				}
+				if step%10 == 0 {
+					checkIndex(t, rng, idx, want)
+					if t.Failed() {
+						t.Fatalf("after step %d", step)
+					}
+				}
+			}
+		})
+	}
+}
+
+func checkIndex(t *testing.T, rng *rand.Rand, idx Index[int], want map[int]gfx.Rect) {
+	t.Helper()
+	if idx.Len() != len(want) {
+		t.Errorf("Len = %d, want %d", idx.Len(), len(want))
+	}
+	for k, r := range want {
+		if got, ok := idx.Bounds(k); !ok || got != r {
+			t.Errorf("Bounds(%d) = %v, %v, want %v", k, got, ok, r)
+		}
+	}
+
+	q := randRect(rng)
+	var got, exp []int
+	idx.Query(q, func(k int) bool { got = append(got, k); return true })
+	for k, r := range want {
+		if overlaps(r, q) {
+			exp = append(exp, k)
+		}
+	}
+	if g, e := fmt.Sprint(sorted(got)), fmt.Sprint(sorted(exp)); g != e {
+		t.Errorf("Query(%v) = %s, want %s", q, g, e)
+	}
+
+	var pairs, expPairs []string
+	idx.Pairs(func(a, b int) {
+		if a > b {
+			a, b = b, a
+		}
+		pairs = append(pairs, fmt.Sprint(a, "-", b))
+	})
+	for a, ra := range want {
+		for b, rb := range want {
+			if a < b && overlaps(ra, rb) {
+				expPairs = append(expPairs, fmt.Sprint(a, "-", b))
+			}
+		}
+	}
+	sort.Strings(pairs)
+	sort.Strings(expPairs)
+	if g, e := fmt.Sprint(pairs), fmt.Sprint(expPairs); g != e {
+		t.Errorf("Pairs = %s, want %s", g, e)
+	}
+
+	ray := randRay(rng)
+	maxT := rng.Float64() * 100
+	if rng.Intn(4) == 0 {
+		maxT = math.Inf(1)
+	}
+	hits := make(map[int]float64)
+	idx.Ray(ray, maxT, func(k int, t float64) bool { hits[k] = t; return true })
+	expHits := make(map[int]float64)
+	for k, r := range want {
+		if t, ok := rayRect(ray, r, maxT); ok {
+			expHits[k] = t
+		}
+	}
+	if len(hits) != len(expHits) {
+		t.Errorf("Ray(%v, %g) hit %v, want %v", ray, maxT, hits, expHits)
+	}
+	for k, e := range expHits {
+		if g, ok := hits[k]; !ok || g != e {
+			t.Errorf("Ray(%v, %g) hit %d at %g, %v, want %g", ray, maxT, k, g, ok, e)
+		}
+	}
+}
+
+func TestIndexStop(t *testing.T) {
+	for name, newIndex := range indexes() {
+		idx := newIndex()
+		for i := 0; i < 10; i++ {
+			idx.Insert(i, gfx.R(float64(i), 0, float64(i)+1, 1))
+		}
+		n := 0
+		idx.Query(gfx.R(0, 0, 20, 1), func(int) bool { n++; return n < 3 })
+		if n != 3 {
+			t.Errorf("%s: Query visited %d keys after stopping at 3", name, n)
+		}
+		n = 0
+		idx.Ray(gfx.Ray{Origin: gfx.V(-1, 0.5), Dir: gfx.V(1, 0)}, math.Inf(1), func(int, float64) bool { n++; return n < 3 })
+		if n != 3 {
+			t.Errorf("%s: Ray visited %d keys after stopping at 3", name, n)
+		}
+	}
+}
+
+func TestSpatialHashRayOrder(t *testing.T) {
+	h := NewSpatialHash[int](4)
+	for i := 0; i < 10; i++ {
+		h.Insert(i, gfx.R(float64(i*10), 0, float64(i*10+1), 1))
+	}
+	var got []int
+	h.Ray(gfx.Ray{Origin: gfx.V(200, 0.5), Dir: gfx.V(-1, 0)}, 150, func(k int, _ float64) bool {
+		got = append(got, k)
+		return true
+	})
+	if fmt.Sprint(got) != "[9 8 7 6 5]" {
+		t.Errorf("Ray visited %v, want the nearest keys first", got)
+	}
+}
+
+const benchEntities = 10000
+
+// benchIndex fills an index with benchEntities small rectangles spread over
+// a world 1000 units wide.
+func benchIndex(newIndex func() Index[int]) (Index[int], []gfx.Rect) {
+	rng := rand.New(rand.NewSource(1))
+	idx := newIndex()
+	rects := make([]gfx.Rect, benchEntities)
+	for i := range rects {
+		min := gfx.V(rng.Float64()*1000, rng.Float64()*1000)
+		rects[i] = gfx.Rect{Min: min, Max: min.Add(gfx.V(1+rng.Float64()*4, 1+rng.Float64()*4))}
+		idx.Insert(i, rects[i])
+	}
+	return idx, rects
+}
+
+func BenchmarkInsert10k(b *testing.B) {
+	for name, newIndex := range indexes() {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				benchIndex(newIndex)
+			}
+		})
+	}
+}
+
+func BenchmarkMove10k(b *testing.B) {
+	for name, newIndex := range indexes() {
+		b.Run(name, func(b *testing.B) {
+			idx, rects := benchIndex(newIndex)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				d := gfx.V(0.5, -0.5)
+				if i%2 == 1 {
+					d = d.Neg()
+				}
+				for k, r := range rects {
+					rects[k] = r.Add(d)
+					idx.Move(k, rects[k])
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkQuery10k(b *testing.B) {
+	for name, newIndex := range indexes() {
+		b.Run(name, func(b *testing.B) {
+			idx, rects := benchIndex(newIndex)
+			b.ResetTimer()
+			n := 0
+			for i := 0; i < b.N; i++ {
+				idx.Query(rects[i%len(rects)].Inset(-10), func(int) bool { n++; return true })
+			}
+		})
+	}
+}
+
+func BenchmarkPairs10k(b *testing.B) {
+	for name, newIndex := range indexes() {
+		b.Run(name, func(b *testing.B) {
+			idx, _ := benchIndex(newIndex)
+			b.ResetTimer()
+			n := 0
+			for i := 0; i < b.N; i++ {
+				idx.Pairs(func(int, int) { n++ })
+			}
+		})
+	}
+}
+
+func BenchmarkRay10k(b *testing.B) {
+	for name, newIndex := range indexes() {
+		b.Run(name, func(b *testing.B) {
+			idx, _ := benchIndex(newIndex)
+			rng := rand.New(rand.NewSource(2))
+			rays := make([]gfx.Ray, 64)
+			for i := range rays {
+				rays[i] = gfx.Ray{Origin: gfx.V(rng.Float64()*1000, rng.Float64()*1000), Dir: gfx.Polar(1, rng.Float64()*2*math.Pi)}
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// Find the nearest hit within 200 units, as a line of sight
+				// check would.
+				idx.Ray(rays[i%len(rays)], 200, func(int, float64) bool { return false })
+			}
+		})
+	}
+}
+
+func TestRayZeroDirection(t *testing.T) {
+	for name, newIndex := range indexes() {
+		idx := newIndex()
+		idx.Insert(1, gfx.R(0, 0, 4, 4))
+		idx.Insert(2, gfx.R(10, 0, 14, 4))
+		var got []int
+		idx.Ray(gfx.Ray{Origin: gfx.V(1, 1)}, math.Inf(1), func(k int, _ float64) bool {
+			got = append(got, k)
+			return true
+		})
+		if fmt.Sprint(got) != "[1]" {
+			t.Errorf("%s: Ray with no direction hit %v, want [1]", name, got)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package spatial
+
+import "github.com/jncornett/bit/gfx"
+
+// DefaultMargin is how much an AABBTree fattens rectangles by default.
+const DefaultMargin = 2
+
+// AABBTree is a dynamic bounding volume hierarchy, after the one in Box2D. It
+// suits objects of very different sizes and sparse worlds. Each rectangle is
+// stored fattened by Margin, so that small moves do not change the tree.
+type AABBTree[K comparable] struct {
+	Margin float64
+	nodes  []treeNode[K]
+	root   int
+	free   int
+	leaves map[K]int
+	list   []int
+	seq    uint64
+	stack  []int
+}
+
+// treeNode is a leaf holding a key, an internal node with two children, or
+// a free node in the free list, chained through next.
+type treeNode[K comparable] struct {
+	fat    gfx.Rect
+	rect   gfx.Rect
+	key    K
+	parent int
+	child1 int
+	child2 int
+	next   int
+	// height is 0 for leaves and -1 for free nodes.
+	height int
+	seq    uint64
+	index  int
+}
+
+const null = -1
+
+func NewAABBTree[K comparable]() *AABBTree[K] {
+	return &AABBTree[K]{Margin: DefaultMargin, root: null, free: null, leaves: make(map[K]int)}
+}
+
+func (t *AABBTree[K]) leaf(i int) bool { return t.nodes[i].child1 == null }
+
+func (t *AABBTree[K]) Len() int { return len(t.list) }
+
+func (t *AABBTree[K]) Bounds(k K) (gfx.Rect, bool) {
+	i, ok := t.leaves[k]
+	if !ok {
+		return gfx.Rect{}, false
+	}
+	return t.nodes[i].rect, true
+}
+
+func (t *AABBTree[K]) alloc() int {
+	if t.free == null {
+		t.nodes = append(t.nodes, treeNode[K]{})
+		t.free = len(t.nodes) - 1
+		t.nodes[t.free].next = null
+	}
+	i := t.free
+	t.free = t.nodes[i].next
+	t.nodes[i] = treeNode[K]{parent: null, child1: null, child2: null, next: null}
+	return i
+}
+
+func (t *AABBTree[K]) release(i int) {
+	var zero K
+	t.nodes[i].key = zero
+	t.nodes[i].next = t.free
+	t.nodes[i].height = -1
+	t.free = i
+}
+
+func (t *AABBTree[K]) Insert(k K, r gfx.Rect) {
+	r = r.Canon()
+	if i, ok := t.leaves[k]; ok {
+		n := &t.nodes[i]
+		n.rect = r
+		if within(r, n.fat) {
+			return
+		}
+		t.removeLeaf(i)
+		t.nodes[i].fat = r.Inset(-t.Margin)
+		t.insertLeaf(i)
+		return
+	}
+	i := t.alloc()
+	t.seq++
+	n := &t.nodes[i]
+	n.key, n.rect, n.fat, n.seq, n.index = k, r, r.Inset(-t.Margin), t.seq, len(t.list)
+	t.leaves[k] = i
+	t.list = append(t.list, i)
+	t.insertLeaf(i)
+}
+
+// within reports whether r is inside fat, counting its edges.
+func within(r, fat gfx.Rect) bool {
+	return fat.Min.X <= r.Min.X && r.Max.X <= fat.Max.X && fat.Min.Y <= r.Min.Y && r.Max.Y <= fat.Max.Y
+}
+
+func (t *AABBTree[K]) Move(k K, r gfx.Rect) { t.Insert(k, r) }
+
+func (t *AABBTree[K]) Remove(k K) {
+	i, ok := t.leaves[k]
+	if !ok {
+		return
+	}
+	t.removeLeaf(i)
+	delete(t.leaves, k)
+	index := t.nodes[i].index
+	last := t.list[len(t.list)-1]
+	t.nodes[last].index = index
+	t.list[index] = last
+	t.list = t.list[:len(t.list)-1]
+	t.release(i)
+}
+
+func (t *AABBTree[K]) insertLeaf(leaf int) {
+	if t.root == null {
+		t.root = leaf
+		t.nodes[leaf].parent = null
+		return
+	}
+
+	// Find the best sibling, by the increase in perimeter of the tree.
+	box := t.nodes[leaf].fat
+	index := t.root
+	for !t.leaf(index) {
+		n := t.nodes[index]
+		area := perimeter(n.fat)
+		combined := perimeter(union(n.fat, box))
+		cost := 2 * combined
+		inherit := 2 * (combined - area)
+		childCost := func(c int) float64 {
+			grown := perimeter(union(box, t.nodes[c].fat))
+			if t.leaf(c) {
+				return grown + inherit
+			}
+			return grown - perimeter(t.nodes[c].fat) + inherit
+		}
+		cost1, cost2 := childCost(n.child1), childCost(n.child2)
+		if cost < cost1 && cost < cost2 {
+			break
+		}
+		if cost1 < cost2 {
+			index = n.child1
+		} else {
+			index = n.child2
+		}
+	}
+	sibling := index
+
+	oldParent := t.nodes[sibling].parent
+	parent := t.alloc()
+	p := &t.nodes[parent]
+	p.parent = oldParent
+	p.fat = union(box, t.nodes[sibling].fat)
+	p.height = t.nodes[sibling].height + 1
+	p.child1, p.child2 = sibling, leaf
+	if oldParent == null {
+		t.root = parent
+	} else if t.nodes[oldParent].child1 == sibling {
+		t.nodes[oldParent].child1 = parent
+	} else {
+		t.nodes[oldParent].child2 = parent
+	}
+	t.nodes[sibling].parent = parent
+	t.nodes[leaf].parent = parent
+	t.refit(parent)
+}
+
+func (t *AABBTree[K]) removeLeaf(leaf int) {
+	if leaf == t.root {
+		t.root = null
+		return
+	}
+	parent := t.nodes[leaf].parent
+	grandParent := t.nodes[parent].parent
+	sibling := t.nodes[parent].child1
+	if sibling == leaf {
+		sibling = t.nodes[parent].child2
+	}
+	t.release(parent)
+	t.nodes[sibling].parent = grandParent
+	if grandParent == null {
+		t.root = sibling
+		return
+	}
+	if t.nodes[grandParent].child1 == parent {
+		t.nodes[grandParent].child1 = sibling
+	} else {
+		t.nodes[grandParent].child2 = sibling
+	}
+	t.refit(grandParent)
+}
+
+// refit rebalances and recomputes the bounds of index and its ancestors.
+func (t *AABBTree[K]) refit(index int) {
+	for index != null {
+		index = t.balance(index)
+		n := &t.nodes[index]
+		c1, c2 := t.nodes[n.child1], t.nodes[n.child2]
+		n.height = 1 + maxInt(c1.height, c2.height)
+		n.fat = union(c1.fat, c2.fat)
+		index = n.parent
+	}
+}
+
+// balance rotates the subtree at a up one level if it is unbalanced, and
+// returns its new root.
+func (t *AABBTree[K]) balance(a int) int {
+	A := &t.nodes[a]
+	if t.leaf(a) || A.height < 2 {
+		return a
+	}
+	b, c := A.child1, A.child2
+	B, C := &t.nodes[b], &t.nodes[c]
+	switch d := C.height - B.height; {
+	case d > 1:
+		t.rotate(a, c, b, false)
+		return c
+	case d < -1:
+		t.rotate(a, b, c, true)
+		return b
+	}
+	return a
+}
+
+// rotate promotes up, a child of a, to take a's place, moving one of up's
+// children under a next to other, a's remaining child.
+func (t *AABBTree[K]) rotate(a, up, other int, left bool) {
+	A, U := &t.nodes[a], &t.nodes[up]
+	f, g := U.child1, U.child2
+	F, G := &t.nodes[f], &t.nodes[g]
+	U.child1 = a
+	U.parent = A.parent
+	A.parent = up
+	switch {
+	case U.parent == null:
+		t.root = up
+	case t.nodes[U.parent].child1 == a:
+		t.nodes[U.parent].child1 = up
+	default:
+		t.nodes[U.parent].child2 = up
+	}
+	keep, move, moved := f, g, G
+	if F.height <= G.height {
+		keep, move, moved = g, f, F
+	}
+	U.child2 = keep
+	if left {
+		A.child1 = move
+	} else {
+		A.child2 = move
+	}
+	moved.parent = a
+	O := &t.nodes[other]
+	A.fat = union(O.fat, moved.fat)
+	A.height = 1 + maxInt(O.height, moved.height)
+	U.fat = union(A.fat, t.nodes[keep].fat)
+	U.height = 1 + maxInt(A.height, t.nodes[keep].height)
+}
+
+// walk calls f with every leaf whose fat bounds overlap r, until f returns
+// false.
+func (t *AABBTree[K]) walk(r gfx.Rect, f func(i int) bool) {
+	if t.root == null {
+		return
+	}
+	// Take the stack, so that f may query the tree too.
+	stack := append(t.stack[:0], t.root)
+	t.stack = nil
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		n := &t.nodes[i]
+		if !overlaps(n.fat, r) {
+			continue
+		}
+		if n.child1 == null {
+			if !f(i) {
+				break
+			}
+			continue
+		}
+		stack = append(stack, n.child2, n.child1)
+	}
+	t.stack = stack[:0]
+}
+
+func (t *AABBTree[K]) Query(r gfx.Rect, f func(k K) bool) {
+	r = r.Canon()
+	t.walk(r, func(i int) bool {
+		n := &t.nodes[i]
+		return !overlaps(n.rect, r) || f(n.key)
+	})
+}
+
+func (t *AABBTree[K]) Pairs(f func(a, b K)) {
+	for _, i := range t.list {
+		n := t.nodes[i]
+		t.walk(n.rect, func(j int) bool {
+			m := &t.nodes[j]
+			if m.seq > n.seq && overlaps(n.rect, m.rect) {
+				f(n.key, m.key)
+			}
+			return true
+		})
+	}
+}
+
+func (t *AABBTree[K]) Ray(ray gfx.Ray, maxT float64, f func(k K, t float64) bool) {
+	if t.root == null {
+		return
+	}
+	// Take the stack, so that f may query the tree too.
+	stack := append(t.stack[:0], t.root)
+	t.stack = nil
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		n := &t.nodes[i]
+		if _, ok := rayRect(ray, n.fat, maxT); !ok {
+			continue
+		}
+		if n.child1 == null {
+			if hit, ok := rayRect(ray, n.rect, maxT); ok && !f(n.key, hit) {
+				break
+			}
+			continue
+		}
+		stack = append(stack, n.child2, n.child1)
+	}
+	t.stack = stack[:0]
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
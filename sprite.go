@@ -1,5 +1,247 @@
 package bit
 
-import "image"
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"time"
 
-type Sprite struct{ *image.NRGBA }
+	"github.com/jncornett/bit/gfx"
+)
+
+// Sprite is an image that can be drawn. Sprites made by NewSprite and Sub
+// remember whether they are opaque, so that drawing them can copy rather than
+// blend; make the sprite again if its pixels change. Other sprites are always
+// blended.
+type Sprite struct {
+	*image.NRGBA
+	opaque bool
+}
+
+// NewSprite converts img into a sprite, copying it unless it already is an
+// *image.NRGBA.
+func NewSprite(img image.Image) Sprite {
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok {
+		nrgba = image.NewNRGBA(img.Bounds())
+		draw.Draw(nrgba, nrgba.Rect, img, img.Bounds().Min, draw.Src)
+	}
+	return Sprite{NRGBA: nrgba, opaque: nrgba.Opaque()}
+}
+
+// LoadSprite decodes a PNG or GIF image. For animated GIFs only the first
+// frame is loaded; see LoadGIF.
+func LoadSprite(r io.Reader) (Sprite, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return Sprite{}, err
+	}
+	return NewSprite(img), nil
+}
+
+func LoadSpriteFile(path string) (Sprite, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Sprite{}, err
+	}
+	defer f.Close()
+	return LoadSprite(f)
+}
+
+// LoadSpriteFS loads a sprite from a file system, e.g. an embed.FS.
+func LoadSpriteFS(fsys fs.FS, name string) (Sprite, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return Sprite{}, err
+	}
+	defer f.Close()
+	return LoadSprite(f)
+}
+
+// LoadGIF decodes every frame of an animated GIF, composited as they would be
+// displayed, along with their delays.
+func LoadGIF(r io.Reader) ([]Sprite, []time.Duration, error) {
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	canvas := image.NewNRGBA(bounds)
+	sprites := make([]Sprite, len(g.Image))
+	delays := make([]time.Duration, len(g.Image))
+	for i, frame := range g.Image {
+		var previous *image.NRGBA
+		if i < len(g.Disposal) && g.Disposal[i] == gif.DisposalPrevious {
+			previous = image.NewNRGBA(bounds)
+			copy(previous.Pix, canvas.Pix)
+		}
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		snapshot := image.NewNRGBA(bounds)
+		copy(snapshot.Pix, canvas.Pix)
+		sprites[i] = NewSprite(snapshot)
+		delays[i] = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		if i < len(g.Disposal) {
+			switch g.Disposal[i] {
+			case gif.DisposalBackground:
+				draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+			case gif.DisposalPrevious:
+				canvas = previous
+			}
+		}
+	}
+	return sprites, delays, nil
+}
+
+// Sub returns the part of the sprite within r, sharing its pixels.
+func (s Sprite) Sub(r image.Rectangle) Sprite {
+	sub := s.SubImage(r).(*image.NRGBA)
+	return Sprite{NRGBA: sub, opaque: s.opaque || sub.Opaque()}
+}
+
+// Grid slices the sprite into w by h frames, in rows from the top left.
+// Partial frames at the right and bottom edges are skipped.
+func (s Sprite) Grid(w, h int) []Sprite {
+	b := s.Bounds()
+	var frames []Sprite
+	for y := b.Min.Y; y+h <= b.Max.Y; y += h {
+		for x := b.Min.X; x+w <= b.Max.X; x += w {
+			frames = append(frames, s.Sub(image.Rect(x, y, x+w, y+h)))
+		}
+	}
+	return frames
+}
+
+// Slice returns a sub-sprite for each source rectangle.
+func (s Sprite) Slice(rects ...image.Rectangle) []Sprite {
+	frames := make([]Sprite, len(rects))
+	for i, r := range rects {
+		frames[i] = s.Sub(r)
+	}
+	return frames
+}
+
+type DrawOptions struct {
+	FlipX, FlipY bool
+	// Scale stretches the sprite. Zero components are treated as 1.
+	Scale gfx.Vec
+	// Tint, unless transparent, multiplies the color of every pixel.
+	Tint color.NRGBA
+}
+
+// Draw blends the sprite onto dst with its top left corner at pos.
+func (s Sprite) Draw(dst *image.NRGBA, pos gfx.Vec, opts DrawOptions) {
+	scale := opts.Scale
+	if scale.X == 0 {
+		scale.X = 1
+	}
+	if scale.Y == 0 {
+		scale.Y = 1
+	}
+	src := s.Bounds()
+	if scale == gfx.V(1, 1) && !opts.FlipX && !opts.FlipY && opts.Tint.A == 0 {
+		at := pos.Round().Point()
+		// Copying rows is much faster than blending them.
+		if s.opaque {
+			s.Blit(dst, at)
+			return
+		}
+		r := image.Rectangle{Max: src.Size()}.Add(at)
+		draw.Draw(dst, r, s, src.Min, draw.Over)
+		return
+	}
+	size := gfx.V(float64(src.Dx())*scale.X, float64(src.Dy())*scale.Y)
+	r := gfx.Rect{Min: pos, Max: pos.Add(size)}.Round().Rectangle().Intersect(dst.Rect)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		sy := int(math.Floor((float64(y) + 0.5 - pos.Y) / scale.Y))
+		if opts.FlipY {
+			sy = src.Dy() - 1 - sy
+		}
+		if sy < 0 || sy >= src.Dy() {
+			continue
+		}
+		for x := r.Min.X; x < r.Max.X; x++ {
+			sx := int(math.Floor((float64(x) + 0.5 - pos.X) / scale.X))
+			if opts.FlipX {
+				sx = src.Dx() - 1 - sx
+			}
+			if sx < 0 || sx >= src.Dx() {
+				continue
+			}
+			c := s.NRGBAAt(src.Min.X+sx, src.Min.Y+sy)
+			if opts.Tint.A != 0 {
+				c = tint(c, opts.Tint)
+			}
+			blend(dst.Pix, dst.PixOffset(x, y), c)
+		}
+	}
+}
+
+// drawView draws the sprite at a world position through a camera's transform.
+func (s Sprite) drawView(dst *image.NRGBA, pos gfx.Vec, opts DrawOptions, m gfx.Affine) {
+	scale := opts.Scale.Map(func(f float64) float64 {
+		if f == 0 {
+			return 1
+		}
+		return f
+	})
+	if m.AxisAligned() && m.A > 0 && m.E > 0 {
+		opts.Scale = gfx.V(scale.X*m.A, scale.Y*m.E)
+		s.Draw(dst, m.Apply(pos), opts)
+		return
+	}
+	inv, ok := m.Invert()
+	if !ok {
+		return
+	}
+	src := s.Bounds()
+	size := gfx.V(float64(src.Dx())*scale.X, float64(src.Dy())*scale.Y)
+	r := m.ApplyRect(gfx.Rect{Min: pos, Max: pos.Add(size)}).Round().Rectangle().Intersect(dst.Rect)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			p := inv.Apply(gfx.V(float64(x)+0.5, float64(y)+0.5)).Sub(pos)
+			sx, sy := int(math.Floor(p.X/scale.X)), int(math.Floor(p.Y/scale.Y))
+			if sx < 0 || sx >= src.Dx() || sy < 0 || sy >= src.Dy() {
+				continue
+			}
+			if opts.FlipX {
+				sx = src.Dx() - 1 - sx
+			}
+			if opts.FlipY {
+				sy = src.Dy() - 1 - sy
+			}
+			c := s.NRGBAAt(src.Min.X+sx, src.Min.Y+sy)
+			if opts.Tint.A != 0 {
+				c = tint(c, opts.Tint)
+			}
+			blend(dst.Pix, dst.PixOffset(x, y), c)
+		}
+	}
+}
+
+// Blit copies the sprite onto dst with its top left corner at p, ignoring
+// transparency. It is the fastest way to draw an opaque sprite.
+func (s Sprite) Blit(dst *image.NRGBA, p image.Point) {
+	src := s.Bounds()
+	r := image.Rectangle{Max: src.Size()}.Add(p).Intersect(dst.Rect)
+	if r.Empty() {
+		return
+	}
+	sp := src.Min.Add(r.Min.Sub(p))
+	n := r.Dx() * 4
+	for y := 0; y < r.Dy(); y++ {
+		di := dst.PixOffset(r.Min.X, r.Min.Y+y)
+		si := s.PixOffset(sp.X, sp.Y+y)
+		copy(dst.Pix[di:di+n], s.Pix[si:si+n])
+	}
+}
+
+func tint(c, t color.NRGBA) color.NRGBA {
+	mul := func(a, b uint8) uint8 { return uint8(uint16(a) * uint16(b) / 0xff) }
+	return color.NRGBA{mul(c.R, t.R), mul(c.G, t.G), mul(c.B, t.B), mul(c.A, t.A)}
+}
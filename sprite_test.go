@@ -0,0 +1,68 @@
+package bit
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/jncornett/bit/gfx"
+)
+
+func TestSpriteDrawOpacity(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	blue := color.NRGBA{0, 0, 255, 255}
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	for i := 0; i < len(img.Pix); i += 4 {
+		copy(img.Pix[i:], []uint8{255, 0, 0, 255})
+	}
+	for _, tc := range []struct {
+		name       string
+		alpha      uint8 // of the top left pixel
+		sprite     func() Sprite
+		wantOpaque bool
+		want       color.NRGBA // at the sprite's top left
+	}{
+		{"opaque", 255, func() Sprite { return NewSprite(img) }, true, red},
+		{"translucent", 0, func() Sprite { return NewSprite(img) }, false, blue},
+		{"translucent sub", 0, func() Sprite { return NewSprite(img).Sub(image.Rect(0, 0, 1, 2)) }, false, blue},
+		{"opaque sub", 0, func() Sprite { return NewSprite(img).Sub(image.Rect(1, 0, 2, 2)) }, true, red},
+		// Literals do not know they are opaque, and are blended.
+		{"opaque literal", 255, func() Sprite { return Sprite{NRGBA: img} }, false, red},
+		{"translucent literal", 0, func() Sprite { return Sprite{NRGBA: img} }, false, blue},
+	} {
+		img.Pix[3] = tc.alpha
+		s := tc.sprite()
+		if s.opaque != tc.wantOpaque {
+			t.Errorf("%s: opaque = %v, want %v", tc.name, s.opaque, tc.wantOpaque)
+		}
+		dst := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+		fillRect(dst, dst.Rect, blue)
+		s.Draw(dst, gfx.V(1, 1), DrawOptions{})
+		if got := dst.NRGBAAt(1, 1); got != tc.want {
+			t.Errorf("%s: pixel = %v, want %v", tc.name, got, tc.want)
+		}
+		if got := dst.NRGBAAt(1, 2); got != red {
+			t.Errorf("%s: opaque pixel = %v, want %v", tc.name, got, red)
+		}
+	}
+}
+
+func BenchmarkSpriteDraw(b *testing.B) {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	s := NewSprite(img)
+	dst := image.NewNRGBA(image.Rect(0, 0, 256, 256))
+	for i := 0; i < b.N; i++ {
+		s.Draw(dst, gfx.V(10, 10), DrawOptions{})
+	}
+}
+
+func TestSpriteLiteral(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	s := Sprite{NRGBA: img}
+	if frames := s.Grid(2, 2); len(frames) != 2 || frames[1].Bounds() != image.Rect(2, 0, 4, 2) {
+		t.Errorf("Grid = %v", frames)
+	}
+}
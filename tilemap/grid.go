@@ -0,0 +1,50 @@
+package tilemap
+
+import (
+	"github.com/jncornett/bit/gfx"
+	"github.com/jncornett/bit/physics"
+)
+
+// Grid returns a layer as a grid for physics.Character. A tile collides
+// according to its "collision" property:
+//
+//	none     not at all
+//	oneway   as a one-way platform
+//	slope    as a slope, with float "left" and "right" floor heights
+//
+// Any other tile is solid. Empty cells, cells outside of the map and the
+// layer's offset are ignored.
+func (m *Map) Grid(l *Layer) physics.TileGrid { return grid{m, l} }
+
+type grid struct {
+	m *Map
+	l *Layer
+}
+
+func (g grid) TileSize() gfx.Vec {
+	return gfx.V(float64(g.m.TileWidth), float64(g.m.TileHeight))
+}
+
+func (g grid) Tile(x, y int) physics.Tile {
+	gid := g.m.At(g.l, x, y)
+	if gid.Empty() {
+		return physics.Tile{}
+	}
+	t := g.m.Tile(gid)
+	if t == nil {
+		return physics.Tile{Shape: physics.TileSolid}
+	}
+	switch t.Properties.String("collision") {
+	case "none":
+		return physics.Tile{}
+	case "oneway":
+		return physics.Tile{Shape: physics.TileOneWay}
+	case "slope":
+		left, right := t.Properties.Float("left"), t.Properties.Float("right")
+		if gid&FlipX != 0 {
+			left, right = right, left
+		}
+		return physics.Tile{Shape: physics.TileSlope, Left: left, Right: right}
+	}
+	return physics.Tile{Shape: physics.TileSolid}
+}
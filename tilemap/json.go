@@ -0,0 +1,302 @@
+package tilemap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/jncornett/bit/gfx"
+)
+
+type jsonMap struct {
+	Orientation     string         `json:"orientation"`
+	Width           int            `json:"width"`
+	Height          int            `json:"height"`
+	TileWidth       int            `json:"tilewidth"`
+	TileHeight      int            `json:"tileheight"`
+	Infinite        bool           `json:"infinite"`
+	BackgroundColor string         `json:"backgroundcolor"`
+	Properties      []jsonProperty `json:"properties"`
+	Tilesets        []jsonTileset  `json:"tilesets"`
+	Layers          []jsonLayer    `json:"layers"`
+}
+
+type jsonProperty struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+type jsonTileset struct {
+	FirstGID   GID            `json:"firstgid"`
+	Source     string         `json:"source"`
+	Name       string         `json:"name"`
+	TileWidth  int            `json:"tilewidth"`
+	TileHeight int            `json:"tileheight"`
+	Spacing    int            `json:"spacing"`
+	Margin     int            `json:"margin"`
+	TileCount  int            `json:"tilecount"`
+	Columns    int            `json:"columns"`
+	Image      string         `json:"image"`
+	Properties []jsonProperty `json:"properties"`
+	TileOffset struct {
+		X float64 `json:"x"`
+		Y float64 `json:"y"`
+	} `json:"tileoffset"`
+	Tiles []struct {
+		ID         int            `json:"id"`
+		Type       string         `json:"type"`
+		Class      string         `json:"class"`
+		Image      string         `json:"image"`
+		Properties []jsonProperty `json:"properties"`
+		Animation  []struct {
+			TileID   int `json:"tileid"`
+			Duration int `json:"duration"`
+		} `json:"animation"`
+		ObjectGroup struct {
+			Objects []jsonObject `json:"objects"`
+		} `json:"objectgroup"`
+	} `json:"tiles"`
+}
+
+type jsonLayer struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name"`
+	Visible     *bool           `json:"visible"`
+	Opacity     *float64        `json:"opacity"`
+	OffsetX     float64         `json:"offsetx"`
+	OffsetY     float64         `json:"offsety"`
+	Properties  []jsonProperty  `json:"properties"`
+	Data        json.RawMessage `json:"data"`
+	Encoding    string          `json:"encoding"`
+	Compression string          `json:"compression"`
+	Objects     []jsonObject    `json:"objects"`
+	Layers      []jsonLayer     `json:"layers"`
+}
+
+type jsonObject struct {
+	ID         int            `json:"id"`
+	Name       string         `json:"name"`
+	Type       string         `json:"type"`
+	Class      string         `json:"class"`
+	X          float64        `json:"x"`
+	Y          float64        `json:"y"`
+	Width      float64        `json:"width"`
+	Height     float64        `json:"height"`
+	Rotation   float64        `json:"rotation"`
+	GID        GID            `json:"gid"`
+	Visible    *bool          `json:"visible"`
+	Ellipse    bool           `json:"ellipse"`
+	Point      bool           `json:"point"`
+	Polygon    []gfx.Vec      `json:"polygon"`
+	Polyline   []gfx.Vec      `json:"polyline"`
+	Properties []jsonProperty `json:"properties"`
+}
+
+func (l *loader) loadJSON(name string) (*Map, error) {
+	data, err := fs.ReadFile(l.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	var j jsonMap
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, err
+	}
+	m := &Map{
+		Width:      j.Width,
+		Height:     j.Height,
+		TileWidth:  j.TileWidth,
+		TileHeight: j.TileHeight,
+		Properties: jsonProperties(j.Properties),
+	}
+	if err := checkMap(m, j.Orientation, j.Infinite); err != nil {
+		return nil, err
+	}
+	if j.BackgroundColor != "" {
+		if m.BackgroundColor, err = parseColor(j.BackgroundColor); err != nil {
+			return nil, err
+		}
+	}
+	dir := path.Dir(name)
+	for _, jt := range j.Tilesets {
+		var ts *Tileset
+		if jt.Source != "" {
+			ts, err = l.tileset(dir, jt.Source)
+		} else {
+			ts, err = l.jsonTileset(dir, jt)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ts.FirstGID = jt.FirstGID
+		m.Tilesets = append(m.Tilesets, ts)
+	}
+	for _, jl := range j.Layers {
+		if err := jsonLayers(m, jl, true, 1, gfx.Vec{}); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (l *loader) loadJSONTileset(name string) (*Tileset, error) {
+	data, err := fs.ReadFile(l.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	var jt jsonTileset
+	if err := json.Unmarshal(data, &jt); err != nil {
+		return nil, err
+	}
+	return l.jsonTileset(path.Dir(name), jt)
+}
+
+func (l *loader) jsonTileset(dir string, jt jsonTileset) (*Tileset, error) {
+	ts := &Tileset{
+		Name:       jt.Name,
+		TileWidth:  jt.TileWidth,
+		TileHeight: jt.TileHeight,
+		Spacing:    jt.Spacing,
+		Margin:     jt.Margin,
+		Columns:    jt.Columns,
+		TileCount:  jt.TileCount,
+		Offset:     gfx.V(jt.TileOffset.X, jt.TileOffset.Y),
+		Properties: jsonProperties(jt.Properties),
+		Tiles:      make(map[int]*Tile),
+	}
+	var err error
+	if jt.Image != "" {
+		if ts.Image, err = l.image(dir, jt.Image); err != nil {
+			return nil, err
+		}
+	}
+	for _, jtile := range jt.Tiles {
+		t := &Tile{
+			ID:         jtile.ID,
+			Type:       firstNonEmpty(jtile.Class, jtile.Type),
+			Properties: jsonProperties(jtile.Properties),
+		}
+		for _, f := range jtile.Animation {
+			t.Animation = append(t.Animation, AnimationFrame{TileID: f.TileID, Duration: time.Duration(f.Duration) * time.Millisecond})
+		}
+		for _, o := range jtile.ObjectGroup.Objects {
+			t.Objects = append(t.Objects, jsonObjectToObject(o))
+		}
+		if jtile.Image != "" {
+			if t.Image, err = l.image(dir, jtile.Image); err != nil {
+				return nil, err
+			}
+		}
+		ts.Tiles[t.ID] = t
+	}
+	finishTileset(ts)
+	return ts, nil
+}
+
+// jsonLayers appends a layer, or the layers of a group, to m.
+func jsonLayers(m *Map, j jsonLayer, visible bool, opacity float64, offset gfx.Vec) error {
+	visible = visible && (j.Visible == nil || *j.Visible)
+	if j.Opacity != nil {
+		opacity *= *j.Opacity
+	}
+	offset = offset.Add(gfx.V(j.OffsetX, j.OffsetY))
+	l := &Layer{
+		Name:       j.Name,
+		Visible:    visible,
+		Opacity:    opacity,
+		Offset:     offset,
+		Properties: jsonProperties(j.Properties),
+	}
+	switch j.Type {
+	case "tilelayer":
+		l.Kind = TileLayer
+		tiles, err := jsonTiles(j, m.Width*m.Height)
+		if err != nil {
+			return fmt.Errorf("layer %q: %w", j.Name, err)
+		}
+		l.Tiles = tiles
+	case "objectgroup":
+		l.Kind = ObjectLayer
+		for _, o := range j.Objects {
+			l.Objects = append(l.Objects, jsonObjectToObject(o))
+		}
+	case "group":
+		for _, child := range j.Layers {
+			if err := jsonLayers(m, child, visible, opacity, offset); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return nil // image layers
+	}
+	m.Layers = append(m.Layers, l)
+	return nil
+}
+
+func jsonTiles(j jsonLayer, n int) ([]GID, error) {
+	if j.Encoding == "base64" {
+		var s string
+		if err := json.Unmarshal(j.Data, &s); err != nil {
+			return nil, err
+		}
+		return decodeData(s, j.Compression, n)
+	}
+	var tiles []GID
+	if err := json.Unmarshal(j.Data, &tiles); err != nil {
+		return nil, err
+	}
+	if len(tiles) != n {
+		return nil, fmt.Errorf("tile data has %d cells, want %d", len(tiles), n)
+	}
+	return tiles, nil
+}
+
+func jsonObjectToObject(j jsonObject) Object {
+	o := Object{
+		ID:         j.ID,
+		Name:       j.Name,
+		Type:       firstNonEmpty(j.Class, j.Type),
+		Pos:        gfx.V(j.X, j.Y),
+		Size:       gfx.V(j.Width, j.Height),
+		Rotation:   j.Rotation,
+		GID:        j.GID,
+		Visible:    j.Visible == nil || *j.Visible,
+		Properties: jsonProperties(j.Properties),
+	}
+	switch {
+	case j.GID != 0:
+		o.Shape = ShapeTile
+	case j.Ellipse:
+		o.Shape = ShapeEllipse
+	case j.Point:
+		o.Shape = ShapePoint
+	case j.Polygon != nil:
+		o.Shape, o.Points = ShapePolygon, j.Polygon
+	case j.Polyline != nil:
+		o.Shape, o.Points = ShapePolyline, j.Polyline
+	}
+	return o
+}
+
+func jsonProperties(js []jsonProperty) Properties {
+	if len(js) == 0 {
+		return nil
+	}
+	p := make(Properties, len(js))
+	for _, j := range js {
+		switch v := j.Value.(type) {
+		case string:
+			p[j.Name] = v
+		case float64:
+			p[j.Name] = strconv.FormatFloat(v, 'f', -1, 64)
+		case nil:
+			p[j.Name] = ""
+		default:
+			p[j.Name] = fmt.Sprint(v)
+		}
+	}
+	return p
+}
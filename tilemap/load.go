@@ -0,0 +1,170 @@
+package tilemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jncornett/bit"
+)
+
+// Load loads a map saved by Tiled in its TMX (.tmx) or JSON (.json, .tmj)
+// format from a file system, along with its external tilesets and images.
+func Load(fsys fs.FS, name string) (*Map, error) {
+	l := loader{fsys: fsys, tilesets: make(map[string]*Tileset)}
+	var m *Map
+	var err error
+	switch strings.ToLower(path.Ext(name)) {
+	case ".tmx":
+		m, err = l.loadTMX(name)
+	case ".json", ".tmj":
+		m, err = l.loadJSON(name)
+	default:
+		err = fmt.Errorf("unknown map format")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tilemap: %s: %w", name, err)
+	}
+	return m, nil
+}
+
+func LoadFile(name string) (*Map, error) {
+	return Load(os.DirFS(filepath.Dir(name)), filepath.Base(name))
+}
+
+// loader resolves the files a map refers to, relative to the map.
+type loader struct {
+	fsys     fs.FS
+	tilesets map[string]*Tileset
+}
+
+func (l *loader) image(dir, source string) (bit.Sprite, error) {
+	return bit.LoadSpriteFS(l.fsys, path.Join(dir, source))
+}
+
+// tileset loads an external tileset, which may be shared by several maps.
+// The returned tileset is a copy, so that its FirstGID can be set.
+func (l *loader) tileset(dir, source string) (*Tileset, error) {
+	name := path.Join(dir, source)
+	ts, ok := l.tilesets[name]
+	if !ok {
+		var err error
+		switch strings.ToLower(path.Ext(name)) {
+		case ".tsx":
+			ts, err = l.loadTSX(name)
+		case ".json", ".tsj":
+			ts, err = l.loadJSONTileset(name)
+		default:
+			err = fmt.Errorf("unknown tileset format")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		l.tilesets[name] = ts
+	}
+	c := *ts
+	return &c, nil
+}
+
+// decodeData decodes the cells of a tile layer stored as base64, possibly
+// compressed, little endian uint32s.
+func decodeData(data, compression string, n int) ([]GID, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+	if err != nil {
+		return nil, err
+	}
+	var r io.Reader = bytes.NewReader(raw)
+	switch compression {
+	case "":
+	case "zlib":
+		if r, err = zlib.NewReader(r); err != nil {
+			return nil, err
+		}
+	case "gzip":
+		if r, err = gzip.NewReader(r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", compression)
+	}
+	tiles := make([]GID, n)
+	if err := binary.Read(r, binary.LittleEndian, tiles); err != nil {
+		return nil, fmt.Errorf("tile data: %w", err)
+	}
+	return tiles, nil
+}
+
+func decodeCSV(data string, n int) ([]GID, error) {
+	fields := strings.FieldsFunc(data, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r' || r == ' ' || r == '\t'
+	})
+	if len(fields) != n {
+		return nil, fmt.Errorf("tile data has %d cells, want %d", len(fields), n)
+	}
+	tiles := make([]GID, n)
+	for i, f := range fields {
+		g, err := strconv.ParseUint(f, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		tiles[i] = GID(g)
+	}
+	return tiles, nil
+}
+
+// parseColor parses Tiled's #RRGGBB and #AARRGGBB colors.
+func parseColor(s string) (color.NRGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("bad color %q", s)
+	}
+	switch len(s) {
+	case 6:
+		return color.NRGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, nil
+	case 8:
+		return color.NRGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), uint8(v >> 24)}, nil
+	}
+	return color.NRGBA{}, fmt.Errorf("bad color %q", s)
+}
+
+func checkMap(m *Map, orientation string, infinite bool) error {
+	if orientation != "orthogonal" {
+		return fmt.Errorf("unsupported orientation %q", orientation)
+	}
+	if infinite {
+		return fmt.Errorf("infinite maps are not supported")
+	}
+	if m.Width <= 0 || m.Height <= 0 {
+		return fmt.Errorf("bad map size %dx%d", m.Width, m.Height)
+	}
+	if m.TileWidth <= 0 || m.TileHeight <= 0 {
+		return fmt.Errorf("bad tile size %dx%d", m.TileWidth, m.TileHeight)
+	}
+	return nil
+}
+
+// finishTileset fills in what Tiled leaves implicit.
+func finishTileset(ts *Tileset) {
+	if ts.Tiles == nil {
+		ts.Tiles = make(map[int]*Tile)
+	}
+	if ts.Columns == 0 && ts.Image.NRGBA != nil && ts.TileWidth > 0 {
+		ts.Columns = (ts.Image.Bounds().Dx() - 2*ts.Margin + ts.Spacing) / (ts.TileWidth + ts.Spacing)
+	}
+	if ts.TileCount == 0 && ts.Columns > 0 && ts.TileHeight > 0 {
+		rows := (ts.Image.Bounds().Dy() - 2*ts.Margin + ts.Spacing) / (ts.TileHeight + ts.Spacing)
+		ts.TileCount = rows * ts.Columns
+	}
+}
@@ -0,0 +1,239 @@
+package tilemap
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/jncornett/bit/gfx"
+)
+
+var (
+	red  = color.NRGBA{255, 0, 0, 255}
+	blue = color.NRGBA{0, 0, 255, 255}
+)
+
+// tilesPNG is a 4x2 image of two 2x2 tiles. The first is red with a blue top
+// left pixel, so that flips can be seen, and the second is blue.
+func tilesPNG(t *testing.T) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			c := red
+			if x >= 2 || x == 0 && y == 0 {
+				c = blue
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+const testTSX = `<?xml version="1.0" encoding="UTF-8"?>
+<tileset name="tiles" tilewidth="2" tileheight="2" tilecount="2" columns="2">
+ <image source="tiles.png" width="4" height="2"/>
+ <tile id="0" type="solid">
+  <properties><property name="friction" value="0.5"/></properties>
+  <objectgroup><object id="1" x="0" y="0" width="2" height="2"/></objectgroup>
+ </tile>
+ <tile id="1">
+  <animation><frame tileid="1" duration="100"/><frame tileid="0" duration="100"/></animation>
+ </tile>
+</tileset>`
+
+const testTMX = `<?xml version="1.0" encoding="UTF-8"?>
+<map orientation="orthogonal" width="2" height="2" tilewidth="2" tileheight="2" infinite="0" backgroundcolor="#80112233">
+ <properties><property name="title" value="test"/></properties>
+ <tileset firstgid="1" source="tiles.tsx"/>
+ <layer name="ground" width="2" height="2">
+  <data encoding="csv">1,2,
+0,2147483649</data>
+ </layer>
+ <group name="group" offsetx="1" opacity="0.5" visible="0">
+  <layer name="packed" width="2" height="2" offsety="2">
+   <data encoding="base64" compression="zlib">%s</data>
+  </layer>
+ </group>
+ <objectgroup name="things">
+  <object id="3" name="spawn" type="start" x="1" y="2"><point/></object>
+  <object id="4" x="0" y="0"><polygon points="0,0 4,0 0,4"/></object>
+ </objectgroup>
+</map>`
+
+const testJSON = `{
+ "orientation": "orthogonal", "width": 2, "height": 2, "tilewidth": 2, "tileheight": 2,
+ "backgroundcolor": "#80112233",
+ "properties": [{"name": "title", "type": "string", "value": "test"}],
+ "tilesets": [{"firstgid": 1, "name": "tiles", "tilewidth": 2, "tileheight": 2, "tilecount": 2, "columns": 2,
+  "image": "tiles.png",
+  "tiles": [
+   {"id": 0, "type": "solid", "properties": [{"name": "friction", "type": "float", "value": 0.5}],
+    "objectgroup": {"objects": [{"id": 1, "x": 0, "y": 0, "width": 2, "height": 2}]}},
+   {"id": 1, "animation": [{"tileid": 1, "duration": 100}, {"tileid": 0, "duration": 100}]}
+  ]}],
+ "layers": [
+  {"type": "tilelayer", "name": "ground", "data": [1, 2, 0, 2147483649]},
+  {"type": "group", "name": "group", "offsetx": 1, "opacity": 0.5, "visible": false, "layers": [
+   {"type": "tilelayer", "name": "packed", "offsety": 2, "encoding": "base64", "compression": "zlib", "data": "%s"}
+  ]},
+  {"type": "objectgroup", "name": "things", "objects": [
+   {"id": 3, "name": "spawn", "type": "start", "x": 1, "y": 2, "point": true},
+   {"id": 4, "x": 0, "y": 0, "polygon": [{"x": 0, "y": 0}, {"x": 4, "y": 0}, {"x": 0, "y": 4}]}
+  ]}
+ ]
+}`
+
+// packed encodes tiles as zlib compressed base64, as Tiled does.
+func packed(t *testing.T, tiles ...GID) string {
+	var raw bytes.Buffer
+	zw := zlib.NewWriter(&raw)
+	if err := binary.Write(zw, binary.LittleEndian, tiles); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+	return base64.StdEncoding.EncodeToString(raw.Bytes())
+}
+
+func testFS(t *testing.T) fstest.MapFS {
+	data := packed(t, 2, 0, 0, 1)
+	return fstest.MapFS{
+		"maps/tiles.png":  {Data: tilesPNG(t)},
+		"maps/tiles.tsx":  {Data: []byte(testTSX)},
+		"maps/level.tmx":  {Data: []byte(strings.Replace(testTMX, "%s", data, 1))},
+		"maps/level.json": {Data: []byte(strings.Replace(testJSON, "%s", data, 1))},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := testFS(t)
+	for _, name := range []string{"maps/level.tmx", "maps/level.json"} {
+		t.Run(name, func(t *testing.T) {
+			m, err := Load(fsys, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Width != 2 || m.Height != 2 || m.TileWidth != 2 || m.TileHeight != 2 {
+				t.Errorf("size = %dx%d of %dx%d", m.Width, m.Height, m.TileWidth, m.TileHeight)
+			}
+			if m.BackgroundColor != (color.NRGBA{0x11, 0x22, 0x33, 0x80}) {
+				t.Errorf("background = %v", m.BackgroundColor)
+			}
+			if m.Properties["title"] != "test" {
+				t.Errorf("properties = %v", m.Properties)
+			}
+			if len(m.Tilesets) != 1 || m.Tilesets[0].FirstGID != 1 || m.Tilesets[0].Image.NRGBA == nil {
+				t.Fatalf("tilesets = %+v", m.Tilesets)
+			}
+			solid := m.Tile(1)
+			if solid == nil || solid.Type != "solid" || solid.Properties["friction"] != "0.5" || len(m.Collision(1)) != 1 {
+				t.Errorf("tile 1 = %+v", solid)
+			}
+			if got := m.Animate(2, 150*time.Millisecond); got != 1 {
+				t.Errorf("animated tile = %d, want 1", got)
+			}
+
+			ground := m.Layer("ground")
+			if ground == nil || !ground.Visible || ground.Opacity != 1 {
+				t.Fatalf("ground = %+v", ground)
+			}
+			if got := []GID{m.At(ground, 0, 0), m.At(ground, 1, 0), m.At(ground, 0, 1), m.At(ground, 1, 1)}; got[0] != 1 || got[1] != 2 || got[2] != 0 || got[3] != FlipX|1 {
+				t.Errorf("ground tiles = %v", got)
+			}
+			p := m.Layer("packed")
+			if p == nil || p.Visible || p.Opacity != 0.5 || p.Offset != gfx.V(1, 2) {
+				t.Fatalf("packed = %+v", p)
+			}
+			if m.At(p, 0, 0) != 2 || m.At(p, 1, 1) != 1 {
+				t.Errorf("packed tiles = %v", p.Tiles)
+			}
+
+			things := m.Layer("things")
+			if things == nil || things.Kind != ObjectLayer || len(things.Objects) != 2 {
+				t.Fatalf("things = %+v", things)
+			}
+			spawn, poly := things.Objects[0], things.Objects[1]
+			if spawn.Name != "spawn" || spawn.Type != "start" || spawn.Shape != ShapePoint || spawn.Pos != gfx.V(1, 2) {
+				t.Errorf("spawn = %+v", spawn)
+			}
+			if poly.Shape != ShapePolygon || len(poly.Points) != 3 || poly.Points[1] != gfx.V(4, 0) {
+				t.Errorf("polygon = %+v", poly)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	fsys := testFS(t)
+	tmx := string(fsys["maps/level.tmx"].Data)
+	js := string(fsys["maps/level.json"].Data)
+	for name, data := range map[string]string{
+		"zero width.tmx":   strings.Replace(tmx, `width="2" height="2" tilewidth`, `width="0" height="2" tilewidth`, 1),
+		"zero height.json": strings.Replace(js, `"height": 2,`, `"height": 0,`, 1),
+		"negative.json":    strings.Replace(js, `"width": 2,`, `"width": -1,`, 1),
+		"isometric.tmx":    strings.Replace(tmx, "orthogonal", "isometric", 1),
+		"infinite.json":    strings.Replace(js, `"orthogonal",`, `"orthogonal", "infinite": true,`, 1),
+		"short.json":       strings.Replace(js, "[1, 2, 0, 2147483649]", "[1, 2, 0]", 1),
+		"bad csv.tmx":      strings.Replace(tmx, "0,2147483649", "0,x", 1),
+		"no tileset.tmx":   strings.Replace(tmx, "tiles.tsx", "missing.tsx", 1),
+	} {
+		fsys["maps/"+name] = &fstest.MapFile{Data: []byte(data)}
+		if _, err := Load(fsys, "maps/"+name); err == nil {
+			t.Errorf("%s: Load succeeded", name)
+		}
+	}
+}
+
+func TestDrawFlipped(t *testing.T) {
+	m, err := Load(testFS(t), "maps/level.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ground := m.Layer("ground")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dst := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+			m.DrawLayer(dst, ground, m.Bounds(), 0)
+			// The flipped tile at (1, 1) has its blue pixel at the top right.
+			if got := dst.NRGBAAt(3, 2); got != blue {
+				t.Errorf("flipped tile's top right = %v, want %v", got, blue)
+			}
+			if got := dst.NRGBAAt(2, 2); got != red {
+				t.Errorf("flipped tile's top left = %v, want %v", got, red)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCheckMap(t *testing.T) {
+	for _, tc := range []struct {
+		w, h, tw, th int
+		ok           bool
+	}{
+		{2, 3, 8, 8, true},
+		{0, 3, 8, 8, false},
+		{2, -1, 8, 8, false},
+		{2, 3, 0, 8, false},
+	} {
+		m := &Map{Width: tc.w, Height: tc.h, TileWidth: tc.tw, TileHeight: tc.th}
+		if err := checkMap(m, "orthogonal", false); (err == nil) != tc.ok {
+			t.Errorf("checkMap(%dx%d of %dx%d) = %v", tc.w, tc.h, tc.tw, tc.th, err)
+		}
+	}
+}
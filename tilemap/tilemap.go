@@ -0,0 +1,414 @@
+// Package tilemap loads orthogonal maps made with the Tiled editor
+// (https://www.mapeditor.org) and draws them onto an image buffer.
+package tilemap
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jncornett/bit"
+	"github.com/jncornett/bit/gfx"
+)
+
+// GID is a global tile ID, with Tiled's flip flags in its high bits.
+// The zero GID is an empty cell.
+type GID uint32
+
+const (
+	FlipX        GID = 0x80000000
+	FlipY        GID = 0x40000000
+	FlipDiagonal GID = 0x20000000
+	// flipHex is only meaningful for hexagonal maps, but must still be masked.
+	flipHex   GID = 0x10000000
+	flipFlags     = FlipX | FlipY | FlipDiagonal | flipHex
+)
+
+// ID returns the tile ID without its flip flags.
+func (g GID) ID() GID     { return g &^ flipFlags }
+func (g GID) Flags() GID  { return g & (FlipX | FlipY | FlipDiagonal) }
+func (g GID) Empty() bool { return g.ID() == 0 }
+
+// Properties are Tiled custom properties, as strings. Colors are in Tiled's
+// #AARRGGBB form.
+type Properties map[string]string
+
+func (p Properties) String(name string) string { return p[name] }
+
+func (p Properties) Bool(name string) bool {
+	b, _ := strconv.ParseBool(p[name])
+	return b
+}
+
+func (p Properties) Int(name string) int {
+	i, _ := strconv.Atoi(p[name])
+	return i
+}
+
+func (p Properties) Float(name string) float64 {
+	f, _ := strconv.ParseFloat(p[name], 64)
+	return f
+}
+
+type LayerKind uint8
+
+const (
+	TileLayer LayerKind = iota
+	ObjectLayer
+)
+
+// Layer is a tile or object layer. Group layers are flattened into their
+// children, which inherit their visibility, opacity and offset.
+type Layer struct {
+	Name       string
+	Kind       LayerKind
+	Visible    bool
+	Opacity    float64
+	Offset     gfx.Vec
+	Properties Properties
+	// Tiles are the cells of a tile layer, row by row.
+	Tiles []GID
+	// Objects are the objects of an object layer.
+	Objects []Object
+}
+
+type ObjectShape uint8
+
+const (
+	ShapeRect ObjectShape = iota
+	ShapeEllipse
+	ShapePoint
+	ShapePolygon
+	ShapePolyline
+	// ShapeTile is a tile object, drawn with GID.
+	ShapeTile
+)
+
+// Object is a shape placed in an object layer, or a collision shape of a tile.
+type Object struct {
+	ID         int
+	Name       string
+	Type       string
+	Shape      ObjectShape
+	Pos        gfx.Vec
+	Size       gfx.Vec
+	Rotation   float64 // degrees clockwise around Pos
+	GID        GID
+	Visible    bool
+	Points     []gfx.Vec // polygon and polyline vertices, relative to Pos
+	Properties Properties
+}
+
+// Bounds returns the axis-aligned bounds of the unrotated object.
+func (o Object) Bounds() gfx.Rect {
+	switch o.Shape {
+	case ShapePolygon, ShapePolyline:
+		if len(o.Points) == 0 {
+			return gfx.Rect{Min: o.Pos, Max: o.Pos}
+		}
+		r := gfx.Rect{Min: o.Points[0], Max: o.Points[0]}
+		for _, p := range o.Points[1:] {
+			r.Min, r.Max = r.Min.Min(p), r.Max.Max(p)
+		}
+		return r.Add(o.Pos)
+	case ShapeTile:
+		// Tile objects are anchored at their bottom left corner.
+		return gfx.Rect{Min: o.Pos.Sub(gfx.V(0, o.Size.Y)), Max: o.Pos.Add(gfx.V(o.Size.X, 0))}
+	}
+	return gfx.Rect{Min: o.Pos, Max: o.Pos.Add(o.Size)}
+}
+
+type AnimationFrame struct {
+	TileID   int
+	Duration time.Duration
+}
+
+// Tile is the per-tile data of a tileset.
+type Tile struct {
+	ID         int
+	Type       string
+	Properties Properties
+	Animation  []AnimationFrame
+	// Objects are the tile's collision shapes, relative to its top left corner.
+	Objects []Object
+	// Image is set for tiles of image collection tilesets.
+	Image bit.Sprite
+}
+
+type Tileset struct {
+	FirstGID   GID
+	Name       string
+	TileWidth  int
+	TileHeight int
+	Spacing    int
+	Margin     int
+	Columns    int
+	TileCount  int
+	Offset     gfx.Vec
+	Image      bit.Sprite
+	Properties Properties
+	Tiles      map[int]*Tile
+}
+
+// Sprite returns the image of a tile, by its ID within the tileset.
+func (ts *Tileset) Sprite(id int) (bit.Sprite, bool) {
+	if t := ts.Tiles[id]; t != nil && t.Image.NRGBA != nil {
+		return t.Image, true
+	}
+	if ts.Image.NRGBA == nil || ts.Columns <= 0 || id < 0 || id >= ts.TileCount {
+		return bit.Sprite{}, false
+	}
+	x := ts.Margin + (id%ts.Columns)*(ts.TileWidth+ts.Spacing)
+	y := ts.Margin + (id/ts.Columns)*(ts.TileHeight+ts.Spacing)
+	r := image.Rect(x, y, x+ts.TileWidth, y+ts.TileHeight).Add(ts.Image.Bounds().Min)
+	if !r.In(ts.Image.Bounds()) {
+		return bit.Sprite{}, false
+	}
+	return ts.Image.Sub(r), true
+}
+
+// Map is an orthogonal Tiled map.
+type Map struct {
+	Width, Height         int // in tiles
+	TileWidth, TileHeight int
+	Properties            Properties
+	BackgroundColor       color.NRGBA
+	Tilesets              []*Tileset
+	Layers                []*Layer
+
+	// flipped caches flipped tiles. Maps may be drawn from several
+	// goroutines, so it is guarded by mu.
+	mu      sync.Mutex
+	flipped map[GID]bit.Sprite
+}
+
+// Layer returns the first layer with the given name, or nil.
+func (m *Map) Layer(name string) *Layer {
+	for _, l := range m.Layers {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// Bounds returns the size of the map in pixels.
+func (m *Map) Bounds() gfx.Rect {
+	return gfx.Rect{Max: gfx.V(float64(m.Width*m.TileWidth), float64(m.Height*m.TileHeight))}
+}
+
+// TileCoord returns the cell containing a world position. It may be outside
+// of the map.
+func (m *Map) TileCoord(p gfx.Vec) (x, y int) {
+	return int(math.Floor(p.X / float64(m.TileWidth))), int(math.Floor(p.Y / float64(m.TileHeight)))
+}
+
+// CellRect returns the world rectangle of a cell.
+func (m *Map) CellRect(x, y int) gfx.Rect {
+	min := gfx.V(float64(x*m.TileWidth), float64(y*m.TileHeight))
+	return gfx.Rect{Min: min, Max: min.Add(gfx.V(float64(m.TileWidth), float64(m.TileHeight)))}
+}
+
+// At returns the tile in a cell of a tile layer, or the empty GID outside of
+// the map.
+func (m *Map) At(l *Layer, x, y int) GID {
+	if l.Kind != TileLayer || x < 0 || y < 0 || x >= m.Width || y >= m.Height {
+		return 0
+	}
+	return l.Tiles[y*m.Width+x]
+}
+
+// TileAt returns the tile of a layer at a world position, taking the layer
+// offset into account.
+func (m *Map) TileAt(l *Layer, p gfx.Vec) GID {
+	x, y := m.TileCoord(p.Sub(l.Offset))
+	return m.At(l, x, y)
+}
+
+// Tileset returns the tileset a tile belongs to, and its ID within it.
+func (m *Map) Tileset(g GID) (*Tileset, int, bool) {
+	id := g.ID()
+	if id == 0 {
+		return nil, 0, false
+	}
+	for i := len(m.Tilesets) - 1; i >= 0; i-- {
+		if ts := m.Tilesets[i]; id >= ts.FirstGID {
+			return ts, int(id - ts.FirstGID), true
+		}
+	}
+	return nil, 0, false
+}
+
+// Tile returns the per-tile data of a tile, if it has any.
+func (m *Map) Tile(g GID) *Tile {
+	ts, id, ok := m.Tileset(g)
+	if !ok {
+		return nil
+	}
+	return ts.Tiles[id]
+}
+
+// Collision returns the collision shapes of a tile.
+func (m *Map) Collision(g GID) []Object {
+	if t := m.Tile(g); t != nil {
+		return t.Objects
+	}
+	return nil
+}
+
+// Animate resolves an animated tile to the frame shown after elapsed time.
+// Other tiles are returned unchanged.
+func (m *Map) Animate(g GID, elapsed time.Duration) GID {
+	ts, id, ok := m.Tileset(g)
+	if !ok {
+		return g
+	}
+	t := ts.Tiles[id]
+	if t == nil || len(t.Animation) == 0 {
+		return g
+	}
+	var total time.Duration
+	for _, f := range t.Animation {
+		total += f.Duration
+	}
+	if total <= 0 {
+		return g
+	}
+	elapsed %= total
+	for _, f := range t.Animation {
+		if elapsed < f.Duration {
+			return (ts.FirstGID + GID(f.TileID)) | g.Flags()
+		}
+		elapsed -= f.Duration
+	}
+	return g
+}
+
+// Draw draws the visible layers, with the top left corner of view at the top
+// left corner of dst. Only the tiles overlapping view are drawn. Elapsed
+// selects the frame of animated tiles.
+func (m *Map) Draw(dst *image.NRGBA, view gfx.Rect, elapsed time.Duration) {
+	if m.BackgroundColor.A != 0 {
+		draw.Draw(dst, dst.Rect, image.NewUniform(m.BackgroundColor), image.Point{}, draw.Over)
+	}
+	for _, l := range m.Layers {
+		if l.Visible && l.Kind == TileLayer {
+			m.DrawLayer(dst, l, view, elapsed)
+		}
+	}
+}
+
+// DrawLayer draws a single tile layer like Draw, even if it is hidden.
+func (m *Map) DrawLayer(dst *image.NRGBA, l *Layer, view gfx.Rect, elapsed time.Duration) {
+	if l.Kind != TileLayer || l.Opacity <= 0 {
+		return
+	}
+	origin := gfx.V(float64(dst.Rect.Min.X), float64(dst.Rect.Min.Y)).Sub(view.Min).Add(l.Offset)
+	// Tiles taller or wider than a cell overhang the cells above and to the
+	// right of theirs, so look a little further.
+	overX, overY := 0, 0
+	for _, ts := range m.Tilesets {
+		overX = maxInt(overX, ceilDiv(ts.TileWidth, m.TileWidth)-1)
+		overY = maxInt(overY, ceilDiv(ts.TileHeight, m.TileHeight)-1)
+	}
+	x0, y0 := m.TileCoord(view.Min.Sub(l.Offset))
+	x1, y1 := m.TileCoord(view.Max.Sub(l.Offset))
+	x0, y0 = maxInt(x0-overX, 0), maxInt(y0, 0)
+	x1, y1 = minInt(x1, m.Width-1), minInt(y1+overY, m.Height-1)
+	var opts bit.DrawOptions
+	if l.Opacity < 1 {
+		opts.Tint = color.NRGBA{0xff, 0xff, 0xff, uint8(l.Opacity * 0xff)}
+	}
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			g := m.Animate(l.Tiles[y*m.Width+x], elapsed)
+			ts, _, ok := m.Tileset(g)
+			if !ok {
+				continue
+			}
+			s, ok := m.sprite(g)
+			if !ok {
+				continue
+			}
+			// Tiles are anchored at the bottom left corner of their cell.
+			size := s.Bounds().Size()
+			pos := gfx.V(float64(x*m.TileWidth), float64((y+1)*m.TileHeight-size.Y)).Add(ts.Offset)
+			s.Draw(dst, origin.Add(pos), opts)
+		}
+	}
+}
+
+// sprite returns the image of a tile with its flips applied. Flipped tiles
+// are cached, so that drawing them is as fast as drawing any other tile.
+func (m *Map) sprite(g GID) (bit.Sprite, bool) {
+	ts, id, ok := m.Tileset(g)
+	if !ok {
+		return bit.Sprite{}, false
+	}
+	s, ok := ts.Sprite(id)
+	if !ok || g.Flags() == 0 {
+		return s, ok
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if f, ok := m.flipped[g]; ok {
+		return f, true
+	}
+	f := flip(s, g)
+	if m.flipped == nil {
+		m.flipped = make(map[GID]bit.Sprite)
+	}
+	m.flipped[g] = f
+	return f, true
+}
+
+// flip applies Tiled's flips: first diagonal, then horizontal, then vertical.
+func flip(s bit.Sprite, g GID) bit.Sprite {
+	src := s.Bounds()
+	w, h := src.Dx(), src.Dy()
+	if g&FlipDiagonal != 0 {
+		w, h = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sx, sy := x, y
+			if g&FlipX != 0 {
+				sx = w - 1 - sx
+			}
+			if g&FlipY != 0 {
+				sy = h - 1 - sy
+			}
+			if g&FlipDiagonal != 0 {
+				sx, sy = sy, sx
+			}
+			dst.SetNRGBA(x, y, s.NRGBAAt(src.Min.X+sx, src.Min.Y+sy))
+		}
+	}
+	return bit.NewSprite(dst)
+}
+
+func ceilDiv(a, b int) int {
+	if b <= 0 {
+		return 0
+	}
+	return (a + b - 1) / b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,369 @@
+package tilemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jncornett/bit/gfx"
+)
+
+type tmxMap struct {
+	Orientation     string          `xml:"orientation,attr"`
+	Width           int             `xml:"width,attr"`
+	Height          int             `xml:"height,attr"`
+	TileWidth       int             `xml:"tilewidth,attr"`
+	TileHeight      int             `xml:"tileheight,attr"`
+	Infinite        bool            `xml:"infinite,attr"`
+	BackgroundColor string          `xml:"backgroundcolor,attr"`
+	Properties      []tmxProperty   `xml:"properties>property"`
+	Tilesets        []tmxTileset    `xml:"tileset"`
+	Layers          []tmxLayerGroup `xml:",any"`
+}
+
+type tmxProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+	Text  string `xml:",chardata"` // multi-line strings
+}
+
+type tmxTileset struct {
+	FirstGID   GID           `xml:"firstgid,attr"`
+	Source     string        `xml:"source,attr"`
+	Name       string        `xml:"name,attr"`
+	TileWidth  int           `xml:"tilewidth,attr"`
+	TileHeight int           `xml:"tileheight,attr"`
+	Spacing    int           `xml:"spacing,attr"`
+	Margin     int           `xml:"margin,attr"`
+	TileCount  int           `xml:"tilecount,attr"`
+	Columns    int           `xml:"columns,attr"`
+	Offset     tmxOffset     `xml:"tileoffset"`
+	Image      tmxImage      `xml:"image"`
+	Properties []tmxProperty `xml:"properties>property"`
+	Tiles      []tmxTile     `xml:"tile"`
+}
+
+type tmxOffset struct {
+	X float64 `xml:"x,attr"`
+	Y float64 `xml:"y,attr"`
+}
+
+type tmxImage struct {
+	Source string `xml:"source,attr"`
+}
+
+type tmxTile struct {
+	ID         int           `xml:"id,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+	Image      tmxImage      `xml:"image"`
+	Animation  []struct {
+		TileID   int `xml:"tileid,attr"`
+		Duration int `xml:"duration,attr"`
+	} `xml:"animation>frame"`
+	Objects []tmxObject `xml:"objectgroup>object"`
+}
+
+// tmxLayerGroup is any element that may appear among a map's layers.
+type tmxLayerGroup struct {
+	XMLName    xml.Name
+	Name       string          `xml:"name,attr"`
+	Visible    string          `xml:"visible,attr"`
+	Opacity    string          `xml:"opacity,attr"`
+	OffsetX    float64         `xml:"offsetx,attr"`
+	OffsetY    float64         `xml:"offsety,attr"`
+	Properties []tmxProperty   `xml:"properties>property"`
+	Data       tmxData         `xml:"data"`
+	Objects    []tmxObject     `xml:"object"`
+	Layers     []tmxLayerGroup `xml:",any"`
+}
+
+type tmxData struct {
+	Encoding    string `xml:"encoding,attr"`
+	Compression string `xml:"compression,attr"`
+	Text        string `xml:",chardata"`
+	Tiles       []struct {
+		GID GID `xml:"gid,attr"`
+	} `xml:"tile"`
+	Chunks []struct{} `xml:"chunk"`
+}
+
+type tmxObject struct {
+	ID         int           `xml:"id,attr"`
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	X          float64       `xml:"x,attr"`
+	Y          float64       `xml:"y,attr"`
+	Width      float64       `xml:"width,attr"`
+	Height     float64       `xml:"height,attr"`
+	Rotation   float64       `xml:"rotation,attr"`
+	GID        GID           `xml:"gid,attr"`
+	Visible    string        `xml:"visible,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+	Ellipse    *struct{}     `xml:"ellipse"`
+	Point      *struct{}     `xml:"point"`
+	Polygon    *tmxPoints    `xml:"polygon"`
+	Polyline   *tmxPoints    `xml:"polyline"`
+}
+
+type tmxPoints struct {
+	Points string `xml:"points,attr"`
+}
+
+func (l *loader) loadTMX(name string) (*Map, error) {
+	data, err := fs.ReadFile(l.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	var x tmxMap
+	if err := xml.Unmarshal(data, &x); err != nil {
+		return nil, err
+	}
+	m := &Map{
+		Width:      x.Width,
+		Height:     x.Height,
+		TileWidth:  x.TileWidth,
+		TileHeight: x.TileHeight,
+		Properties: tmxProperties(x.Properties),
+	}
+	if err := checkMap(m, x.Orientation, x.Infinite); err != nil {
+		return nil, err
+	}
+	if x.BackgroundColor != "" {
+		if m.BackgroundColor, err = parseColor(x.BackgroundColor); err != nil {
+			return nil, err
+		}
+	}
+	dir := path.Dir(name)
+	for _, xt := range x.Tilesets {
+		var ts *Tileset
+		if xt.Source != "" {
+			ts, err = l.tileset(dir, xt.Source)
+		} else {
+			ts, err = l.tmxTileset(dir, xt)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ts.FirstGID = xt.FirstGID
+		m.Tilesets = append(m.Tilesets, ts)
+	}
+	for _, xl := range x.Layers {
+		if err := tmxLayers(m, xl, true, 1, gfx.Vec{}); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (l *loader) loadTSX(name string) (*Tileset, error) {
+	data, err := fs.ReadFile(l.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	var xt tmxTileset
+	if err := xml.Unmarshal(data, &xt); err != nil {
+		return nil, err
+	}
+	return l.tmxTileset(path.Dir(name), xt)
+}
+
+func (l *loader) tmxTileset(dir string, xt tmxTileset) (*Tileset, error) {
+	ts := &Tileset{
+		Name:       xt.Name,
+		TileWidth:  xt.TileWidth,
+		TileHeight: xt.TileHeight,
+		Spacing:    xt.Spacing,
+		Margin:     xt.Margin,
+		Columns:    xt.Columns,
+		TileCount:  xt.TileCount,
+		Offset:     gfx.V(xt.Offset.X, xt.Offset.Y),
+		Properties: tmxProperties(xt.Properties),
+		Tiles:      make(map[int]*Tile),
+	}
+	var err error
+	if xt.Image.Source != "" {
+		if ts.Image, err = l.image(dir, xt.Image.Source); err != nil {
+			return nil, err
+		}
+	}
+	for _, xtile := range xt.Tiles {
+		t := &Tile{
+			ID:         xtile.ID,
+			Type:       firstNonEmpty(xtile.Class, xtile.Type),
+			Properties: tmxProperties(xtile.Properties),
+		}
+		for _, f := range xtile.Animation {
+			t.Animation = append(t.Animation, AnimationFrame{TileID: f.TileID, Duration: time.Duration(f.Duration) * time.Millisecond})
+		}
+		for _, o := range xtile.Objects {
+			obj, err := tmxObjectToObject(o)
+			if err != nil {
+				return nil, err
+			}
+			t.Objects = append(t.Objects, obj)
+		}
+		if xtile.Image.Source != "" {
+			if t.Image, err = l.image(dir, xtile.Image.Source); err != nil {
+				return nil, err
+			}
+		}
+		ts.Tiles[t.ID] = t
+	}
+	finishTileset(ts)
+	return ts, nil
+}
+
+// tmxLayers appends a layer, or the layers of a group, to m.
+func tmxLayers(m *Map, x tmxLayerGroup, visible bool, opacity float64, offset gfx.Vec) error {
+	visible = visible && x.Visible != "0"
+	if x.Opacity != "" {
+		o, err := strconv.ParseFloat(x.Opacity, 64)
+		if err != nil {
+			return fmt.Errorf("layer %q: bad opacity: %w", x.Name, err)
+		}
+		opacity *= o
+	}
+	offset = offset.Add(gfx.V(x.OffsetX, x.OffsetY))
+	l := &Layer{
+		Name:       x.Name,
+		Visible:    visible,
+		Opacity:    opacity,
+		Offset:     offset,
+		Properties: tmxProperties(x.Properties),
+	}
+	switch x.XMLName.Local {
+	case "layer":
+		l.Kind = TileLayer
+		tiles, err := tmxTiles(x.Data, m.Width*m.Height)
+		if err != nil {
+			return fmt.Errorf("layer %q: %w", x.Name, err)
+		}
+		l.Tiles = tiles
+	case "objectgroup":
+		l.Kind = ObjectLayer
+		for _, o := range x.Objects {
+			obj, err := tmxObjectToObject(o)
+			if err != nil {
+				return fmt.Errorf("layer %q: %w", x.Name, err)
+			}
+			l.Objects = append(l.Objects, obj)
+		}
+	case "group":
+		for _, child := range x.Layers {
+			if err := tmxLayers(m, child, visible, opacity, offset); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return nil // image layers and anything else we don't draw
+	}
+	m.Layers = append(m.Layers, l)
+	return nil
+}
+
+func tmxTiles(d tmxData, n int) ([]GID, error) {
+	if len(d.Chunks) > 0 {
+		return nil, fmt.Errorf("chunked tile data is not supported")
+	}
+	switch d.Encoding {
+	case "csv":
+		return decodeCSV(d.Text, n)
+	case "base64":
+		return decodeData(d.Text, d.Compression, n)
+	case "":
+		if len(d.Tiles) != n {
+			return nil, fmt.Errorf("tile data has %d cells, want %d", len(d.Tiles), n)
+		}
+		tiles := make([]GID, n)
+		for i, t := range d.Tiles {
+			tiles[i] = t.GID
+		}
+		return tiles, nil
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", d.Encoding)
+}
+
+func tmxObjectToObject(x tmxObject) (Object, error) {
+	o := Object{
+		ID:         x.ID,
+		Name:       x.Name,
+		Type:       firstNonEmpty(x.Class, x.Type),
+		Pos:        gfx.V(x.X, x.Y),
+		Size:       gfx.V(x.Width, x.Height),
+		Rotation:   x.Rotation,
+		GID:        x.GID,
+		Visible:    x.Visible != "0",
+		Properties: tmxProperties(x.Properties),
+	}
+	var err error
+	switch {
+	case x.GID != 0:
+		o.Shape = ShapeTile
+	case x.Ellipse != nil:
+		o.Shape = ShapeEllipse
+	case x.Point != nil:
+		o.Shape = ShapePoint
+	case x.Polygon != nil:
+		o.Shape = ShapePolygon
+		o.Points, err = tmxParsePoints(x.Polygon.Points)
+	case x.Polyline != nil:
+		o.Shape = ShapePolyline
+		o.Points, err = tmxParsePoints(x.Polyline.Points)
+	}
+	if err != nil {
+		return Object{}, fmt.Errorf("object %d: %w", x.ID, err)
+	}
+	return o, nil
+}
+
+// tmxParsePoints parses points of the form "x1,y1 x2,y2 ...".
+func tmxParsePoints(s string) ([]gfx.Vec, error) {
+	var points []gfx.Vec
+	for _, p := range strings.Fields(s) {
+		xs, ys, ok := strings.Cut(p, ",")
+		if !ok {
+			return nil, fmt.Errorf("bad point %q", p)
+		}
+		x, err := strconv.ParseFloat(xs, 64)
+		if err != nil {
+			return nil, err
+		}
+		y, err := strconv.ParseFloat(ys, 64)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, gfx.V(x, y))
+	}
+	return points, nil
+}
+
+func tmxProperties(xs []tmxProperty) Properties {
+	if len(xs) == 0 {
+		return nil
+	}
+	p := make(Properties, len(xs))
+	for _, x := range xs {
+		if x.Value == "" {
+			x.Value = x.Text
+		}
+		p[x.Name] = x.Value
+	}
+	return p
+}
+
+func firstNonEmpty(s ...string) string {
+	for _, x := range s {
+		if x != "" {
+			return x
+		}
+	}
+	return ""
+}
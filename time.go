@@ -2,6 +2,7 @@ package bit
 
 import (
 	"context"
+	"sync"
 	"time"
 )
 
@@ -47,3 +48,125 @@ func MakeClock(fps FPS) (start func() (ticks <-chan Tick, stop func())) {
 		return out, cancel
 	}
 }
+
+// MakeVirtualClock returns a deterministic clock whose ticks start at epoch and
+// are exactly step apart. Ticks are produced as fast as they are consumed,
+// regardless of wall-clock time. If n is positive the clock closes its channel
+// after n ticks.
+func MakeVirtualClock(epoch time.Time, step time.Duration, n int) (start func() (ticks <-chan Tick, stop func())) {
+	return func() (ticks <-chan Tick, stop func()) {
+		ctx, cancel := context.WithCancel(context.Background())
+		out := make(chan Tick)
+		go func() {
+			defer close(out)
+			tick := NewTick(epoch)
+			for i := 0; n <= 0 || i < n; i++ {
+				next := tick.Step(tick[2].Add(step))
+				select {
+				case <-ctx.Done():
+					return
+				case out <- next:
+					tick = next
+				}
+			}
+		}()
+		return out, cancel
+	}
+}
+
+// ManualClock is a deterministic clock that only ticks when told to.
+type ManualClock struct {
+	mu      sync.Mutex
+	tick    Tick
+	out     chan Tick
+	closed  bool
+	stopped chan struct{}
+	stop    sync.Once
+}
+
+func NewManualClock(epoch time.Time) *ManualClock {
+	return &ManualClock{
+		tick:    NewTick(epoch),
+		out:     make(chan Tick),
+		stopped: make(chan struct{}),
+	}
+}
+
+// Start implements the StartClock contract.
+func (c *ManualClock) Start() (ticks <-chan Tick, stop func()) {
+	return c.out, func() { c.stop.Do(func() { close(c.stopped) }) }
+}
+
+// Advance produces a tick d after the previous one. It blocks until the tick
+// has been received, and reports false if the clock was stopped or closed.
+func (c *ManualClock) Advance(d time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return false
+	}
+	next := c.tick.Step(c.tick[2].Add(d))
+	select {
+	case <-c.stopped:
+		return false
+	case c.out <- next:
+		c.tick = next
+		return true
+	}
+}
+
+// Close closes the tick channel, which ends an Engine.Run using this clock.
+func (c *ManualClock) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.out)
+	}
+}
+
+// fixedStep converts variable real-time ticks into a sequence of ticks that
+// are exactly Step apart.
+type fixedStep struct {
+	Step     time.Duration
+	MaxSteps int
+	tick     Tick
+	started  bool
+	acc      time.Duration
+}
+
+// Advance accumulates the real time elapsed during t. It returns the number of
+// steps that are due and how far, in [0, 1), the remaining time is into the
+// next step.
+func (f *fixedStep) Advance(t Tick) (steps int, alpha float64) {
+	f.start(t)
+	maxSteps := f.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+	f.acc += t.Delta()
+	steps = int(f.acc / f.Step)
+	if steps > maxSteps {
+		// Spiral of death: we cannot keep up, so drop the backlog.
+		steps = maxSteps
+		f.acc %= f.Step
+	} else {
+		f.acc -= time.Duration(steps) * f.Step
+	}
+	return steps, float64(f.acc) / float64(f.Step)
+}
+
+// start makes the fixed steps begin at the zero time of t, unless they
+// already have.
+func (f *fixedStep) start(t Tick) {
+	if !f.started {
+		f.started = true
+		f.tick = NewTick(t.Zero())
+	}
+}
+
+// Next returns the tick for the next fixed step.
+func (f *fixedStep) Next() Tick {
+	f.tick = f.tick.Step(f.tick[2].Add(f.Step))
+	return f.tick
+}
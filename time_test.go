@@ -0,0 +1,109 @@
+package bit
+
+import (
+	"image"
+	"testing"
+	"time"
+)
+
+var epoch = time.Unix(1000, 0)
+
+func TestFixedStepAdvance(t *testing.T) {
+	f := fixedStep{Step: 10 * time.Millisecond, MaxSteps: 3}
+	tick := NewTick(epoch)
+	for _, tt := range []struct {
+		delta time.Duration
+		steps int
+		alpha float64
+	}{
+		{5 * time.Millisecond, 0, 0.5},
+		{5 * time.Millisecond, 1, 0},
+		{25 * time.Millisecond, 2, 0.5},
+		{15 * time.Millisecond, 2, 0},
+		// Beyond MaxSteps the backlog is dropped.
+		{100*time.Millisecond + 3*time.Millisecond, 3, 0.3},
+	} {
+		tick = tick.Step(tick[2].Add(tt.delta))
+		steps, alpha := f.Advance(tick)
+		if steps != tt.steps || alpha < tt.alpha-1e-9 || alpha > tt.alpha+1e-9 {
+			t.Errorf("Advance(%v) = %d, %v, want %d, %v", tt.delta, steps, alpha, tt.steps, tt.alpha)
+		}
+	}
+}
+
+func TestFixedStepNext(t *testing.T) {
+	f := fixedStep{Step: 10 * time.Millisecond}
+	f.start(NewTick(epoch).Step(epoch.Add(time.Second)))
+	for i := 1; i <= 3; i++ {
+		next := f.Next()
+		if next.Zero() != epoch || next.Age() != time.Duration(i)*f.Step || next.Delta() != f.Step {
+			t.Fatalf("step %d: got %v", i, next)
+		}
+	}
+	// Starting again does not reset the steps.
+	f.start(NewTick(epoch.Add(time.Hour)))
+	if next := f.Next(); next.Zero() != epoch || next.Age() != 4*f.Step {
+		t.Errorf("after restart: got %v", next)
+	}
+}
+
+func TestLerpRenderState(t *testing.T) {
+	prev := RenderState{image.Rect(0, 0, 10, 10)}
+	curr := RenderState{image.Rect(10, 20, 20, 30)}
+	got := LerpRenderState(prev, curr, 0.5)
+	if want := image.Rect(5, 10, 15, 20); got[0] != want {
+		t.Errorf("LerpRenderState = %v, want %v", got[0], want)
+	}
+	if got := LerpRenderState(prev, curr, 0); got[0] != prev[0] {
+		t.Errorf("alpha 0 = %v, want %v", got[0], prev[0])
+	}
+	if got := LerpRenderState(nil, curr, 0.5); got[0] != curr[0] {
+		t.Errorf("mismatched lengths = %v, want curr", got[0])
+	}
+}
+
+func TestVirtualClock(t *testing.T) {
+	ticks, stop := MakeVirtualClock(epoch, 10*time.Millisecond, 3)()
+	defer stop()
+	var got []Tick
+	for tick := range ticks {
+		got = append(got, tick)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d ticks, want 3", len(got))
+	}
+	for i, tick := range got {
+		if tick.Zero() != epoch || tick.Delta() != 10*time.Millisecond || tick.Age() != time.Duration(i+1)*10*time.Millisecond {
+			t.Errorf("tick %d = %v", i, tick)
+		}
+	}
+}
+
+func TestVirtualClockStop(t *testing.T) {
+	ticks, stop := MakeVirtualClock(epoch, time.Millisecond, 0)()
+	<-ticks
+	stop()
+	for range ticks {
+	}
+}
+
+func TestManualClock(t *testing.T) {
+	c := NewManualClock(epoch)
+	ticks, stop := c.Start()
+	go func() {
+		c.Advance(time.Second)
+		c.Advance(2 * time.Second)
+		c.Close()
+	}()
+	var ages []time.Duration
+	for tick := range ticks {
+		ages = append(ages, tick.Age())
+	}
+	if len(ages) != 2 || ages[0] != time.Second || ages[1] != 3*time.Second {
+		t.Errorf("ages = %v", ages)
+	}
+	stop()
+	if c.Advance(time.Second) {
+		t.Error("Advance after Close reported true")
+	}
+}